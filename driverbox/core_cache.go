@@ -1,6 +1,9 @@
 package driverbox
 
-import "github.com/ibuilding-x/driver-box/v2/internal/cache"
+import (
+	"github.com/ibuilding-x/driver-box/v2/internal/cache"
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+)
 
 // CoreCache 获取核心缓存实例
 // 提供对系统核心缓存的访问，用于存储和检索运行时数据
@@ -16,3 +19,27 @@ import "github.com/ibuilding-x/driver-box/v2/internal/cache"
 func CoreCache() cache.CoreCache {
 	return cache.Get()
 }
+
+// PluginConfig 获取插件在核心缓存中的完整配置，包含通过 API、设备自动发现等方式新增的模型和设备
+// 参数:
+//   - pluginName: 插件名称
+//
+// 使用示例:
+//
+//	cfg := driverbox.PluginConfig("modbus")
+func PluginConfig(pluginName string) config.DeviceConfig {
+	return cache.GetConfig(pluginName)
+}
+
+// SetConfigStore 设置自定义配置存储，替代内置的 file、sqlite 存储
+// 需在 Start 之前调用，自定义存储的关闭由调用方负责
+// 参数:
+//   - store: 实现了 config.ConfigStore 接口的配置存储，为 nil 时恢复使用内置存储
+//
+// 使用示例:
+//
+//	driverbox.SetConfigStore(etcdStore)
+//	err := driverbox.Start()
+func SetConfigStore(store config.ConfigStore) {
+	cache.SetStore(store)
+}
@@ -51,6 +51,8 @@ func Start() error {
 			Log().Error("init export error", zap.Error(err))
 		}
 	}
+	// 为支持断网续传的Export打开缓存队列
+	export0.OpenQueues()
 
 	// 第四步：启动driver-box插件
 	err = loadPlugins()
@@ -92,6 +94,7 @@ func Stop() error {
 	crontab.Instance().Clear()
 
 	// 第二步：销毁所有Export模块
+	export0.CloseQueues()
 	for _, item := range export0.Exports {
 		e = item.Destroy()
 		if e != nil {
@@ -111,6 +114,7 @@ func Stop() error {
 
 	// 第五步：清除核心缓存数据
 	cache.Reset()
+	resetReportStates()
 
 	return nil
 }
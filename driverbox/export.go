@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"time"
 
 	"github.com/ibuilding-x/driver-box/v2/driverbox/export"
 	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
@@ -13,6 +14,7 @@ import (
 	"github.com/ibuilding-x/driver-box/v2/pkg/convutil"
 	"github.com/ibuilding-x/driver-box/v2/pkg/event"
 	"github.com/ibuilding-x/driver-box/v2/pkg/library"
+	"github.com/ibuilding-x/driver-box/v2/pkg/transform"
 	"go.uber.org/zap"
 )
 
@@ -82,6 +84,7 @@ func TriggerEvents(eventCode event.EventCode, key string, value interface{}) {
 //     - 对点位数据进行缓存过滤(根据报告模式过滤不变的点位值)
 //     - 如果设备没有数值数据则跳过
 //     - 将数据导出到所有已准备好的Export插件
+//     - 实现了ReliableExport的插件未就绪或导出失败时，数据缓存至磁盘队列待恢复后补发
 //
 // 数据过滤机制:
 //   - 根据报告模式过滤不变的点位值(ReportMode_Change)
@@ -99,15 +102,14 @@ func Export(deviceData []plugin.DeviceData) {
 				TriggerEvents(event.EventCode(evt.Code), data.ID, evt.Value)
 			}
 		}
-		pointCacheFilter(&data)
+		historical := pointCacheFilter(&data)
+		if len(historical) > 0 {
+			export0.Dispatch(plugin.DeviceData{ID: data.ID, Values: historical, ExportType: plugin.HistoricalExport})
+		}
 		if len(data.Values) == 0 {
 			continue
 		}
-		for _, export := range export0.Exports {
-			if export.IsReady() {
-				export.ExportTo(data)
-			}
-		}
+		export0.Dispatch(data)
 	}
 }
 
@@ -136,16 +138,20 @@ func BaseExport() base.BaseExport {
 // 参数:
 //   - deviceData: 指向设备数据的指针，函数会直接修改该数据
 //
+// 返回值:
+//   - []plugin.PointData: 乱序的补录点位，仅导出至历史数据存储
+//
 // 过滤逻辑:
+//   - 源时间早于影子中源时间的乱序数据不参与过滤、不更新影子，作为补录数据返回
 //   - 如果点位报告模式为ReportMode_Change且值未变化，则过滤掉该点位
 //   - 将处理后的数据缓存到设备影子中
 //   - 触发event.Exporting事件供其他模块处理
-func pointCacheFilter(deviceData *plugin.DeviceData) {
+func pointCacheFilter(deviceData *plugin.DeviceData) (historical []plugin.PointData) {
 	// 设备层驱动，对点位进行预处理
 	err := pointValueProcess(deviceData)
 	if err != nil {
 		Log().Error("device driver process error", zap.Any("deviceData", deviceData), zap.Error(err))
-		return
+		return nil
 	}
 	//获取完成点位加工后的真实 deviceData
 	originalData := plugin.DeviceData{
@@ -168,19 +174,23 @@ func pointCacheFilter(deviceData *plugin.DeviceData) {
 			continue
 		}
 
-		// 缓存比较
-		shadowValue, _ := Shadow().GetDevicePoint(deviceData.ID, point.PointName)
+		//乱序数据不更新上报状态及影子，仅供历史数据按源时间记录
+		if outOfOrder(deviceData.ID, point) {
+			Log().Debug("point data out of order", zap.String("pointName", p.Name()), zap.Int64("timestamp", point.Timestamp))
+			historical = append(historical, point)
+			continue
+		}
 
-		// 如果是周期上报模式，且缓存中有值，停止触发
-		if p.ReportMode() == config.ReportMode_Change && shadowValue == point.Value {
-			Log().Debug("point report mode is change, stop to trigger Export", zap.String("pointName", p.Name()))
+		// 根据上报模式、死区及上报间隔过滤
+		if !shouldReport(deviceData.ID, p, point, time.Now()) {
+			Log().Debug("point report filtered, stop to trigger Export", zap.String("pointName", p.Name()))
 		} else {
 			// 点位值类型名称转换
 			points = append(points, point)
 		}
 
 		// 缓存
-		if err := Shadow().SetDevicePoint(deviceData.ID, point.PointName, point.Value); err != nil {
+		if err := Shadow().SetDevicePointData(deviceData.ID, point); err != nil {
 			Log().Error("shadow store point value error", zap.Error(err), zap.Any("deviceId", deviceData.ID))
 		}
 	}
@@ -188,14 +198,16 @@ func pointCacheFilter(deviceData *plugin.DeviceData) {
 	deviceData.ExportType = plugin.RealTimeExport
 
 	TriggerEvents(event.Exporting, deviceData.ID, originalData)
+	return historical
 }
 
 // pointValueProcess 对点位值进行预处理
 // 该函数执行以下处理:
 // 1. 通过设备驱动库对点位值进行解码和加工
-// 2. 执行点位值类型转换
-// 3. 应用精度换算(scale)
-// 4. 应用小数位数保留
+// 2. 执行点位值转换链(transforms)
+// 3. 执行点位值类型转换
+// 4. 应用精度换算(scale)
+// 5. 应用小数位数保留
 // 参数:
 //   - deviceData: 指向设备数据的指针，函数会直接修改其中的值
 //
@@ -205,6 +217,7 @@ func pointCacheFilter(deviceData *plugin.DeviceData) {
 // 处理流程:
 //   - 检查设备是否存在及是否有驱动配置
 //   - 使用设备驱动对点位值进行解码处理
+//   - 执行点位配置的转换链
 //   - 执行类型转换确保值符合点位定义
 //   - 应用缩放因子调整数值精度
 //   - 应用小数位数限制
@@ -241,8 +254,26 @@ func pointValueProcess(deviceData *plugin.DeviceData) error {
 			}
 			continue
 		}
+		//数据质量异常且无值时，仅更新数据质量
+		if p.Value == nil && !p.Quality.IsGood() {
+			continue
+		}
+		//点位值转换链
+		raw := p.Value
+		if transforms := point.Transforms(); len(transforms) > 0 {
+			var err error
+			raw, err = transform.Forward(transforms, raw)
+			if err != nil {
+				Log().Error("transform point value error", zap.Error(err), zap.Any("deviceId", deviceData.ID),
+					zap.String("pointName", p.PointName), zap.Any("value", p.Value))
+				//转换失败时不上报原始值，仅更新数据质量
+				deviceData.Values[i].Value = nil
+				deviceData.Values[i].Quality = plugin.QualityBad
+				continue
+			}
+		}
 		//点位值类型还原
-		value, err := convutil.PointValue(p.Value, point.ValueType())
+		value, err := convutil.PointValue(raw, point.ValueType())
 		if err != nil {
 			if !strings.HasPrefix(deviceData.ID, "vrf/") {
 				Log().Error("convert point value error", zap.Error(err), zap.Any("deviceId", deviceData.ID),
@@ -267,10 +298,40 @@ func pointValueProcess(deviceData *plugin.DeviceData) error {
 			value = math.Trunc(value.(float64)*multiplier) / multiplier
 		}
 		deviceData.Values[i].Value = value
+		//量程校验，不覆盖插件上报的异常数据质量
+		if p.Quality.IsGood() && outOfRange(value, point) {
+			deviceData.Values[i].Quality = plugin.QualityOutOfRange
+		}
 	}
 	return nil
 }
 
+// outOfRange 点位值是否超出点位配置的量程
+func outOfRange(value interface{}, point config.Point) bool {
+	if valueType := point.ValueType(); valueType != config.ValueType_Int && valueType != config.ValueType_Float {
+		return false
+	}
+	v, err := convutil.Float64(value)
+	if err != nil {
+		return false
+	}
+	if min, ok := point.Min(); ok && v < min {
+		return true
+	}
+	if max, ok := point.Max(); ok && v > max {
+		return true
+	}
+	return false
+}
+
+// qualityEqual 比较数据质量，空值等同于 good
+func qualityEqual(a, b plugin.Quality) bool {
+	if a.IsGood() && b.IsGood() {
+		return true
+	}
+	return a == b
+}
+
 // multiplyWithFloat64 将任意数值类型与浮点数相乘
 // 该函数处理各种数值类型的乘法运算，并将其结果转换为float64
 // 参数:
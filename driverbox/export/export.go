@@ -66,3 +66,30 @@ type Export interface {
 	//   error - 销毁过程中发生的错误，成功返回nil
 	Destroy() error
 }
+
+// ReliableExport 支持断网续传的导出模块
+// 实现该接口的导出模块，在未就绪或导出失败时，设备数据将缓存至磁盘队列，
+// 待模块恢复后按原有顺序补发，适用于上行链路不稳定的场景
+type ReliableExport interface {
+	Export
+
+	// QueueName 缓存队列名称
+	// 用作磁盘队列的目录名，在所有导出模块中需保持唯一
+	QueueName() string
+
+	// Deliver 导出设备数据并返回导出结果
+	// 框架对实现了该接口的模块调用 Deliver 替代 ExportTo
+	// 返回值:
+	//   error - 导出失败时返回错误，数据将进入缓存队列等待重发
+	Deliver(deviceData plugin.DeviceData) error
+}
+
+// HistoricalReceiver 接收乱序补录数据的导出模块
+// 源时间早于设备影子的乱序点位不更新影子，以 plugin.HistoricalExport 类型单独分发，
+// 仅导出至实现该接口的模块，其他模块只接收实时数据
+type HistoricalReceiver interface {
+	Export
+
+	// ReceiveHistorical 标识模块接收 plugin.HistoricalExport 类型的设备数据
+	ReceiveHistorical()
+}
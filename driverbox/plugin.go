@@ -6,6 +6,8 @@ import (
 	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
 	"github.com/ibuilding-x/driver-box/v2/internal/cache"
 	"github.com/ibuilding-x/driver-box/v2/internal/export"
+	"github.com/ibuilding-x/driver-box/v2/internal/shadow"
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
 	"github.com/ibuilding-x/driver-box/v2/pkg/event"
 	"github.com/ibuilding-x/driver-box/v2/pkg/library"
 	"go.uber.org/zap"
@@ -104,6 +106,7 @@ func loadPlugins() error {
 //   - 从核心缓存获取所有设备
 //   - 检查设备是否已在影子服务中存在
 //   - 对不存在的设备调用AddDevice方法
+//   - 启用持久化时恢复设备的历史影子数据
 func initDeviceShadow() {
 	// 添加设备
 	for _, dev := range CoreCache().Devices() {
@@ -115,6 +118,16 @@ func initDeviceShadow() {
 		// 添加设备
 		Shadow().AddDevice(dev.ID, dev.ModelName)
 	}
+	// 恢复持久化数据，点位值按物模型定义的值类型还原
+	if err := shadow.Restore(func(deviceId, pointName string) config.ValueType {
+		point, ok := CoreCache().GetPointByDevice(deviceId, pointName)
+		if !ok {
+			return ""
+		}
+		return point.ValueType()
+	}); err != nil {
+		Log().Error("restore device shadow error", zap.Error(err))
+	}
 }
 
 // destroyPlugins 销毁所有已启动的插件
@@ -182,3 +195,16 @@ func ReloadPlugins() {
 		ReloadPlugin(name)
 	}
 }
+
+// ReloadConfig 重新加载驱动配置文件
+// 与核心缓存比对后仅应用增量变更，返回各插件的配置变更，key 为插件名称
+//
+// 重载流程:
+//   - 解析并校验发生变化的 config.json
+//   - 增删设备影子，触发 DeviceAdded、DeviceDeleting 事件
+//   - 仅重启受影响的连接，未变化的插件不受影响
+//
+// 配置文件被外部修改后会自动重载，无需手动调用
+func ReloadConfig() (map[string]*cache.ConfigDelta, error) {
+	return cache.Reload("api")
+}
@@ -21,8 +21,38 @@ const (
 
 	// RealTimeExport 实时上报类型，表示数据是实时变化上报的
 	RealTimeExport ExportType = "realTimeExport"
+
+	// HistoricalExport 乱序补录类型，表示数据源时间早于设备影子中的最新数据
+	// 仅导出至实现了 export.HistoricalReceiver 的模块
+	HistoricalExport ExportType = "historicalExport"
+)
+
+// Quality 点位数据质量
+// 空值等同于 QualityGood
+type Quality string
+
+const (
+	// QualityGood 数据正常
+	QualityGood Quality = "good"
+	// QualityUncertain 数据可信度不确定
+	QualityUncertain Quality = "uncertain"
+	// QualityBad 数据异常
+	QualityBad Quality = "bad"
+	// QualityStale 数据已过时，如超过 TTL 未更新或重启后恢复的历史值
+	QualityStale Quality = "stale"
+	// QualityCommFailure 设备通讯失败
+	QualityCommFailure Quality = "commFailure"
+	// QualityOutOfRange 数据超出点位量程
+	QualityOutOfRange Quality = "outOfRange"
+	// QualitySubstituted 数据为替代值，如人工置数或设备强制值
+	QualitySubstituted Quality = "substituted"
 )
 
+// IsGood 数据质量是否正常
+func (q Quality) IsGood() bool {
+	return q == "" || q == QualityGood
+}
+
 // PointData 点位数据结构
 // 表示单个设备点位的名称和值，用于在系统中传递点位信息
 type PointData struct {
@@ -33,6 +63,14 @@ type PointData struct {
 	// Value 点位值，可以是任意类型的数据，如数字、布尔值、字符串等
 	// 值的类型应与点位定义的ValueType匹配
 	Value interface{} `json:"value"`
+
+	// Quality 数据质量，为空表示正常
+	// 数据质量异常且Value为nil时，仅更新设备影子中的数据质量，保留原有值
+	Quality Quality `json:"quality,omitempty"`
+
+	// Timestamp 数据源时间戳，Unix 毫秒，0 表示以网关接收时间为准
+	// 适用于设备缓存后批量上报的历史数据，时间早于影子中已有数据的点位值不会覆盖影子
+	Timestamp int64 `json:"timestamp,omitempty"`
 }
 
 // DeviceData 设备数据结构
@@ -54,6 +92,10 @@ type DeviceData struct {
 	// ExportType 上报类型，标识数据的来源类型
 	// 底层的变化上报和实时上报等同于RealTimeExport
 	ExportType ExportType
+
+	// Replayed 是否为断网续传补发的缓存数据，由框架在补发时设置
+	// 导出模块可据此将数据标记为历史数据，而非设备的实时值
+	Replayed bool `json:"-"`
 }
 
 // PointReadValue 点位读操作的结构体
@@ -18,6 +18,10 @@ var (
 
 	// NotSupportDecode 协议适配器不支持解码功能时返回的错误
 	NotSupportDecode = errors.New("the protocol adapter does not support decode functions")
+
+	// WriteNotConfirmed 写入校验失败时返回的错误，设备已应答回读但值与写入值不一致
+	// 实现 WriteVerifier 的插件应包装该错误返回，核心据此不将设备视为可能离线
+	WriteNotConfirmed = errors.New("write not confirmed by device")
 )
 
 // Plugin 驱动插件接口
@@ -63,6 +67,31 @@ type Plugin interface {
 	Destroy() error
 }
 
+// PointValidator 点位配置校验接口，插件可选实现
+// 核心缓存在加载配置文件、新增模型时调用，用于校验插件扩展的点位字段（如 modbus 的 primaryTable、startAddress）
+type PointValidator interface {
+	// ValidatePoint 校验点位配置，返回错误的 Path 为点位内的字段名
+	ValidatePoint(point config.Point) config.ValidationErrors
+}
+
+// WriteVerifier 写入校验接口，插件可选实现
+// 实现该接口的插件在写操作中按点位 writePolicy 同步回读校验，核心不再对这些点位发起写后回读；
+// 未实现该接口的插件不允许配置 writePolicy
+type WriteVerifier interface {
+	// SupportWritePolicy 是否支持点位的 writePolicy 配置
+	SupportWritePolicy() bool
+}
+
+// ConnectionReloader 连接重载接口，插件可选实现
+// 配置热加载时仅重启受变更影响的连接，未实现该接口的插件将整体重启（Destroy 后重新 Initialize）
+type ConnectionReloader interface {
+	// ReloadConnections 重启指定的连接
+	// 参数:
+	//   c: 插件的最新配置
+	//   keys: 受影响的连接标识，已删除的连接需关闭，新增或变更的连接按最新配置重建
+	ReloadConnections(c config.DeviceConfig, keys []string) error
+}
+
 // Connector 设备连接器接口
 // 连接器负责与单个设备进行实际的通信操作
 // 定义了编码、发送和资源释放等功能
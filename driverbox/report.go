@@ -0,0 +1,122 @@
+package driverbox
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
+	export0 "github.com/ibuilding-x/driver-box/v2/internal/export"
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+	"github.com/ibuilding-x/driver-box/v2/pkg/convutil"
+)
+
+// reportState 点位最近一次上报的数据
+type reportState struct {
+	value   interface{}
+	quality plugin.Quality
+	at      time.Time
+}
+
+// reportStates 点位上报状态，key：设备ID，value：点位名称 -> 上报状态
+var reportStates = struct {
+	sync.Mutex
+	devices map[string]map[string]*reportState
+}{devices: make(map[string]map[string]*reportState)}
+
+func init() {
+	export0.OnDeviceDeleting(removeReportState)
+}
+
+// outOfOrder 点位数据的源时间是否早于影子中的源时间
+func outOfOrder(deviceId string, point plugin.PointData) bool {
+	if point.Timestamp <= 0 {
+		return false
+	}
+	shadowPoint, err := Shadow().GetDevicePointDetails(deviceId, point.PointName)
+	return err == nil && time.UnixMilli(point.Timestamp).Before(shadowPoint.SourceAt)
+}
+
+// shouldReport 判断点位数据是否需要触发上报，需要上报时记录上报状态
+// 当前点位从未上报过时，以设备影子中的值作为比较基准
+func shouldReport(deviceId string, p config.Point, point plugin.PointData, now time.Time) bool {
+	reportStates.Lock()
+	defer reportStates.Unlock()
+	points, ok := reportStates.devices[deviceId]
+	if !ok {
+		points = make(map[string]*reportState)
+		reportStates.devices[deviceId] = points
+	}
+	last, ok := points[point.PointName]
+	if !ok {
+		shadowPoint, err := Shadow().GetDevicePointDetails(deviceId, point.PointName)
+		if err == nil && !shadowPoint.UpdatedAt.IsZero() {
+			last = &reportState{value: shadowPoint.Value, quality: shadowPoint.Quality}
+		}
+	}
+	if !needReport(p, last, point, now) {
+		return false
+	}
+	points[point.PointName] = &reportState{value: point.Value, quality: point.Quality, at: now}
+	return true
+}
+
+// needReport 根据点位上报模式、死区及上报间隔判断是否需要上报，last 为空表示无比较基准
+func needReport(p config.Point, last *reportState, point plugin.PointData, now time.Time) bool {
+	if last == nil {
+		return true
+	}
+	//以影子值为基准时不存在上报时间，不做间隔控制
+	if !last.at.IsZero() {
+		elapsed := now.Sub(last.at).Seconds()
+		if max := p.MaxReportInterval(); max > 0 && elapsed >= max {
+			return true
+		}
+		if min := p.MinReportInterval(); min > 0 && elapsed < min {
+			return false
+		}
+	}
+	if p.ReportMode() != config.ReportMode_Change {
+		return true
+	}
+	if !qualityEqual(last.quality, point.Quality) {
+		return true
+	}
+	return valueChanged(p, last.value, point.Value)
+}
+
+// valueChanged 点位值相较上次上报值是否超出死区，未配置死区时比较是否相等
+func valueChanged(p config.Point, last, value interface{}) bool {
+	deadband, percent := p.Deadband(), p.DeadbandPercent()
+	if deadband <= 0 && percent <= 0 {
+		return !convutil.Equal(last, value)
+	}
+	lastValue, err1 := convutil.Float64(last)
+	currentValue, err2 := convutil.Float64(value)
+	if err1 != nil || err2 != nil {
+		return !convutil.Equal(last, value)
+	}
+	diff := math.Abs(currentValue - lastValue)
+	if deadband > 0 && diff <= deadband {
+		return false
+	}
+	//上次上报值为0时无法计算变化幅度，任意变化均视为超出死区
+	if percent > 0 && lastValue != 0 && diff/math.Abs(lastValue)*100 <= percent {
+		return false
+	}
+	return diff > 0
+}
+
+// removeReportState 删除设备的点位上报状态
+func removeReportState(deviceId string) {
+	reportStates.Lock()
+	defer reportStates.Unlock()
+	delete(reportStates.devices, deviceId)
+}
+
+// resetReportStates 清空点位上报状态
+func resetReportStates() {
+	reportStates.Lock()
+	defer reportStates.Unlock()
+	reportStates.devices = make(map[string]map[string]*reportState)
+}
@@ -0,0 +1,77 @@
+package driverbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
+	export0 "github.com/ibuilding-x/driver-box/v2/internal/export"
+	"github.com/ibuilding-x/driver-box/v2/internal/logger"
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+	"github.com/ibuilding-x/driver-box/v2/pkg/event"
+	"go.uber.org/zap"
+)
+
+func TestNeedReport(t *testing.T) {
+	now := time.Now()
+	last := &reportState{value: 100.0, at: now.Add(-10 * time.Second)}
+	cases := []struct {
+		name  string
+		point config.Point
+		value interface{}
+		want  bool
+	}{
+		{"change equal", config.Point{"reportMode": "change"}, 100.0, false},
+		{"change diff", config.Point{"reportMode": "change"}, 100.1, true},
+		{"deadband within", config.Point{"reportMode": "change", "deadband": 0.5}, 100.4, false},
+		{"deadband exceed", config.Point{"reportMode": "change", "deadband": 0.5}, 99.4, true},
+		{"percent within", config.Point{"reportMode": "change", "deadbandPercent": 1.0}, 101.0, false},
+		{"percent exceed", config.Point{"reportMode": "change", "deadbandPercent": 1.0}, 101.5, true},
+		{"throttle", config.Point{"minReportInterval": 30.0}, 200.0, false},
+		{"throttle passed", config.Point{"minReportInterval": 5.0}, 200.0, true},
+		{"heartbeat", config.Point{"reportMode": "change", "maxReportInterval": 5.0}, 100.0, true},
+		{"heartbeat not due", config.Point{"reportMode": "change", "maxReportInterval": 60.0}, 100.0, false},
+	}
+	for _, c := range cases {
+		got := needReport(c.point, last, plugin.PointData{PointName: "p", Value: c.value}, now)
+		if got != c.want {
+			t.Errorf("%s: needReport = %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	// 数据质量变化不受死区限制
+	p := config.Point{"reportMode": "change", "deadband": 10.0}
+	if !needReport(p, last, plugin.PointData{PointName: "p", Value: 100.0, Quality: plugin.QualityBad}, now) {
+		t.Error("quality change should be reported")
+	}
+}
+
+func TestReportState(t *testing.T) {
+	logger.Logger = zap.NewNop()
+	Shadow().AddDevice("report-d1", "m")
+	defer Shadow().DeleteDevice("report-d1")
+	now := time.Now()
+	point := plugin.PointData{PointName: "p", Value: 1, Timestamp: now.UnixMilli()}
+	if err := Shadow().SetDevicePointData("report-d1", point); err != nil {
+		t.Fatal(err)
+	}
+	if outOfOrder("report-d1", point) {
+		t.Error("same timestamp should not be out of order")
+	}
+	if !outOfOrder("report-d1", plugin.PointData{PointName: "p", Value: 2, Timestamp: now.Add(-time.Second).UnixMilli()}) {
+		t.Error("older timestamp should be out of order")
+	}
+	if outOfOrder("report-d1", plugin.PointData{PointName: "p", Value: 2}) {
+		t.Error("point without timestamp should not be out of order")
+	}
+
+	// 设备删除时清理上报状态
+	shouldReport("report-d1", config.Point{}, point, now)
+	export0.TriggerEvents(event.DeviceDeleting, "report-d1", nil)
+	reportStates.Lock()
+	_, ok := reportStates.devices["report-d1"]
+	reportStates.Unlock()
+	if ok {
+		t.Error("report state should be removed on device deleting")
+	}
+}
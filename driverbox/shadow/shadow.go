@@ -2,6 +2,8 @@ package shadow
 
 import (
 	"time"
+
+	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
 )
 
 // Device 设备影子数据结构
@@ -41,11 +43,20 @@ type DevicePoint struct {
 	// WriteValue 最近一次下发给设备的控制值
 	WriteValue interface{} `json:"writeValue"`
 
-	// UpdatedAt 点位值最后更新时间
+	// UpdatedAt 点位值最后更新时间,即网关接收时间
 	UpdatedAt time.Time `json:"updatedAt"`
 
+	// SourceAt 点位值的数据源时间,未指定时与UpdatedAt一致
+	SourceAt time.Time `json:"sourceAt"`
+
 	// WriteAt 写入值最后更新时间
 	WriteAt time.Time `json:"writeAt"`
+
+	// Stale 点位值是否为重启后恢复的历史值,收到设备最新数据后置为false
+	Stale bool `json:"stale"`
+
+	// Quality 点位数据质量,设备离线或超过TTL未更新时自动置为异常
+	Quality plugin.Quality `json:"quality"`
 }
 
 // DeviceShadow 设备影子
@@ -92,6 +103,16 @@ type DeviceShadow interface {
 	//
 	// 此方法更新设备点位的当前值并记录更新时间
 	SetDevicePoint(id, pointName string, value interface{}) (err error)
+	// SetDevicePointData 设置设备点位值及数据质量
+	// 参数:
+	//   id: 设备唯一标识符
+	//   data: 点位数据
+	// 返回值:
+	//   error: 设置过程中发生的错误
+	//
+	// 数据质量异常且值为nil时，仅更新点位数据质量
+	// 数据源时间早于影子中已有数据时，不做更新
+	SetDevicePointData(id string, data plugin.PointData) (err error)
 	// GetDevicePoint 获取设备点位值
 	// 参数:
 	//   id: 设备唯一标识符
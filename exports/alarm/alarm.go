@@ -0,0 +1,240 @@
+package alarm
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/ibuilding-x/driver-box/v2/driverbox"
+	"github.com/ibuilding-x/driver-box/v2/pkg/convutil"
+	"go.uber.org/zap"
+)
+
+var (
+	errAlarmNotFound     = errors.New("alarm not found")
+	errAlarmAcked        = errors.New("alarm is already acknowledged")
+	errAlarmRuleNotFound = errors.New("alarm rule not found")
+)
+
+// pointState 单个设备点位的告警状态
+type pointState struct {
+	deviceID  string
+	pointName string
+	// 当前未清除的告警
+	alarm *Alarm
+	// 越限但尚未达到告警延时的类型、开始时间及最新值
+	pendingType  Type
+	pendingSince time.Time
+	pendingValue float64
+	pendingRule  rule
+	// 搁置截止时间
+	shelvedUntil time.Time
+}
+
+func (ps *pointState) shelved(now time.Time) bool {
+	return now.Before(ps.shelvedUntil)
+}
+
+// state 获取点位告警状态，不存在时创建
+func (export *export) state(deviceID, pointName string) *pointState {
+	key := deviceID + "/" + pointName
+	ps, ok := export.points[key]
+	if !ok {
+		ps = &pointState{deviceID: deviceID, pointName: pointName}
+		export.points[key] = ps
+	}
+	return ps
+}
+
+// ruleOf 获取设备点位的告警规则
+func ruleOf(deviceID, pointName string) (rule, bool) {
+	point, ok := driverbox.CoreCache().GetPointByDevice(deviceID, pointName)
+	if !ok {
+		return rule{}, false
+	}
+	return parseRule(point)
+}
+
+// evaluate 根据点位告警规则更新告警状态
+func (export *export) evaluate(deviceID, pointName string, value interface{}) {
+	r, ok := ruleOf(deviceID, pointName)
+	if !ok {
+		return
+	}
+	f, err := convutil.Float64(value)
+	if err != nil {
+		return
+	}
+	export.mutex.Lock()
+	defer export.mutex.Unlock()
+	export.update(deviceID, pointName, r, f, time.Now())
+}
+
+func (export *export) update(deviceID, pointName string, r rule, value float64, now time.Time) {
+	ps := export.state(deviceID, pointName)
+	var active Type
+	if ps.alarm != nil {
+		active = ps.alarm.Type
+	}
+	t := r.check(value, active)
+	if ps.alarm != nil {
+		if t == ps.alarm.Type {
+			ps.alarm.Value = value
+			return
+		}
+		ps.alarm.Value = value
+		export.clear(ps, now)
+	}
+	if t == "" || ps.shelved(now) {
+		ps.pendingType = ""
+		return
+	}
+	if ps.pendingType != t {
+		ps.pendingType = t
+		ps.pendingSince = now
+	}
+	if now.Sub(ps.pendingSince) < r.delay {
+		ps.pendingValue = value
+		ps.pendingRule = r
+		return
+	}
+	ps.pendingType = ""
+	export.raise(ps, r, t, value, now)
+}
+
+// checkDelay 越限持续时长达到告警延时后产生告警，避免点位值不再上报时告警无法产生
+func (export *export) checkDelay() {
+	export.mutex.Lock()
+	defer export.mutex.Unlock()
+	now := time.Now()
+	for _, ps := range export.points {
+		if ps.pendingType == "" || ps.alarm != nil || now.Sub(ps.pendingSince) < ps.pendingRule.delay {
+			continue
+		}
+		if ps.shelved(now) {
+			ps.pendingType = ""
+			continue
+		}
+		t := ps.pendingType
+		ps.pendingType = ""
+		export.raise(ps, ps.pendingRule, t, ps.pendingValue, now)
+	}
+}
+
+func (export *export) raise(ps *pointState, r rule, t Type, value float64, now time.Time) {
+	deviceID, pointName := ps.deviceID, ps.pointName
+	alarm := &Alarm{
+		DeviceID:  deviceID,
+		PointName: pointName,
+		Type:      t,
+		Severity:  r.severity,
+		State:     StateActive,
+		Value:     value,
+		Threshold: r.threshold(t),
+		RaiseTime: now,
+	}
+	if device, ok := driverbox.CoreCache().GetDevice(deviceID); ok {
+		alarm.ModelName = device.ModelName
+	}
+	if err := export.store.insert(alarm); err != nil {
+		driverbox.Log().Error("save alarm error", zap.String("deviceId", deviceID), zap.String("point", pointName), zap.Error(err))
+	}
+	ps.alarm = alarm
+	driverbox.Log().Warn("raise alarm", zap.String("deviceId", deviceID), zap.String("point", pointName),
+		zap.String("type", string(t)), zap.Float64("value", value))
+	export.emit(EVT_ALARM_RAISE, *alarm)
+}
+
+func (export *export) clear(ps *pointState, now time.Time) {
+	alarm := ps.alarm
+	ps.alarm = nil
+	alarm.State = StateCleared
+	alarm.ClearTime = &now
+	if err := export.store.update(alarm); err != nil {
+		driverbox.Log().Error("update alarm error", zap.Int64("id", alarm.ID), zap.Error(err))
+	}
+	driverbox.Log().Info("clear alarm", zap.String("deviceId", alarm.DeviceID), zap.String("point", alarm.PointName))
+	if !ps.shelved(now) {
+		export.emit(EVT_ALARM_CLEAR, *alarm)
+	}
+}
+
+// clearDevice 清除设备的所有告警
+func (export *export) clearDevice(deviceID string) {
+	export.mutex.Lock()
+	defer export.mutex.Unlock()
+	now := time.Now()
+	for key, ps := range export.points {
+		if ps.deviceID != deviceID {
+			continue
+		}
+		if ps.alarm != nil {
+			export.clear(ps, now)
+		}
+		delete(export.points, key)
+	}
+}
+
+// Ack 确认告警
+func (export *export) Ack(id int64) error {
+	export.mutex.Lock()
+	defer export.mutex.Unlock()
+	for _, ps := range export.points {
+		if ps.alarm == nil || ps.alarm.ID != id {
+			continue
+		}
+		if ps.alarm.State == StateAcknowledged {
+			return errAlarmAcked
+		}
+		now := time.Now()
+		ps.alarm.State = StateAcknowledged
+		ps.alarm.AckTime = &now
+		if err := export.store.update(ps.alarm); err != nil {
+			return err
+		}
+		export.emit(EVT_ALARM_ACK, *ps.alarm)
+		return nil
+	}
+	return errAlarmNotFound
+}
+
+// Shelve 搁置设备点位告警，duration 为 0 时取消搁置
+// 点位未配置告警规则且不存在告警状态时返回错误
+func (export *export) Shelve(deviceID, pointName string, duration time.Duration) error {
+	_, hasRule := ruleOf(deviceID, pointName)
+	export.mutex.Lock()
+	defer export.mutex.Unlock()
+	if _, ok := export.points[deviceID+"/"+pointName]; !ok && !hasRule {
+		return errAlarmRuleNotFound
+	}
+	ps := export.state(deviceID, pointName)
+	ps.shelvedUntil = time.Now().Add(duration)
+	ps.pendingType = ""
+	return nil
+}
+
+// List 查询未清除的告警，按告警时间倒序排列
+func (export *export) List(deviceID string, severity string) []Alarm {
+	export.mutex.Lock()
+	defer export.mutex.Unlock()
+	now := time.Now()
+	alarms := make([]Alarm, 0)
+	for _, ps := range export.points {
+		if ps.alarm == nil {
+			continue
+		}
+		if (deviceID != "" && ps.alarm.DeviceID != deviceID) || (severity != "" && ps.alarm.Severity != severity) {
+			continue
+		}
+		alarm := *ps.alarm
+		if ps.shelved(now) {
+			until := ps.shelvedUntil
+			alarm.ShelvedUntil = &until
+		}
+		alarms = append(alarms, alarm)
+	}
+	sort.Slice(alarms, func(i, j int) bool {
+		return alarms[i].RaiseTime.After(alarms[j].RaiseTime)
+	})
+	return alarms
+}
@@ -0,0 +1,156 @@
+package alarm
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ibuilding-x/driver-box/v2/internal/logger"
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logger.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+func newTestExport(t *testing.T) *export {
+	t.Setenv(config.EXPORT_ALARM_DATA_PATH, t.TempDir())
+	s, err := newStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = s.close()
+	})
+	return &export{points: make(map[string]*pointState), store: s}
+}
+
+func (export *export) alarmOf(deviceID, pointName string) *Alarm {
+	export.mutex.Lock()
+	defer export.mutex.Unlock()
+	ps, ok := export.points[deviceID+"/"+pointName]
+	if !ok {
+		return nil
+	}
+	return ps.alarm
+}
+
+func TestUpdateAck(t *testing.T) {
+	export := newTestExport(t)
+	r := rule{high: 30, hasHigh: true, deadband: 2, severity: SeverityMajor}
+	now := time.Now()
+
+	export.update("d1", "temp", r, 25, now)
+	if export.alarmOf("d1", "temp") != nil {
+		t.Fatal("normal value should not raise alarm")
+	}
+	export.update("d1", "temp", r, 31, now)
+	alarm := export.alarmOf("d1", "temp")
+	if alarm == nil || alarm.Type != TypeHigh || alarm.State != StateActive || alarm.Threshold != 30 || alarm.ID == 0 {
+		t.Fatalf("alarm = %+v", alarm)
+	}
+
+	if err := export.Ack(alarm.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := export.Ack(alarm.ID); err != errAlarmAcked {
+		t.Fatalf("ack twice err = %v", err)
+	}
+	if err := export.Ack(alarm.ID + 1); err != errAlarmNotFound {
+		t.Fatalf("ack unknown err = %v", err)
+	}
+
+	//死区内不恢复，告警期间记录最新值
+	export.update("d1", "temp", r, 29, now)
+	if alarm = export.alarmOf("d1", "temp"); alarm == nil || alarm.State != StateAcknowledged || alarm.Value != 29 {
+		t.Fatalf("alarm = %+v", alarm)
+	}
+	export.update("d1", "temp", r, 27, now)
+	if export.alarmOf("d1", "temp") != nil {
+		t.Fatal("alarm should be cleared")
+	}
+	history, err := export.store.history(historyQuery{DeviceID: "d1", State: StateCleared})
+	if err != nil || len(history) != 1 || history[0].AckTime == nil || history[0].ClearTime == nil {
+		t.Fatalf("history = %v, err = %v", history, err)
+	}
+	//事件按产生顺序排队发送
+	codes := make([]string, 0, len(export.events))
+	for _, e := range export.events {
+		codes = append(codes, string(e.code))
+	}
+	want := []string{string(EVT_ALARM_RAISE), string(EVT_ALARM_ACK), string(EVT_ALARM_CLEAR)}
+	if strings.Join(codes, ",") != strings.Join(want, ",") {
+		t.Fatalf("events = %v", codes)
+	}
+}
+
+func TestDelay(t *testing.T) {
+	export := newTestExport(t)
+	r := rule{low: 10, hasLow: true, delay: 5 * time.Second, severity: SeverityWarning}
+	now := time.Now()
+
+	export.update("d1", "temp", r, 9, now.Add(-3*time.Second))
+	export.update("d1", "temp", r, 8, now)
+	if export.alarmOf("d1", "temp") != nil {
+		t.Fatal("alarm should be delayed")
+	}
+	//延时内恢复，重新计时
+	export.update("d1", "temp", r, 11, now)
+	export.update("d1", "temp", r, 9, now.Add(3*time.Second))
+	export.update("d1", "temp", r, 9, now.Add(6*time.Second))
+	if export.alarmOf("d1", "temp") != nil {
+		t.Fatal("delay should restart after recovery")
+	}
+	export.update("d1", "temp", r, 7, now.Add(8*time.Second))
+	if alarm := export.alarmOf("d1", "temp"); alarm == nil || alarm.Type != TypeLow || alarm.Value != 7 {
+		t.Fatalf("alarm = %+v", alarm)
+	}
+
+	//点位值不再上报时由定时检查产生告警
+	export.update("d2", "temp", r, 9, now.Add(-10*time.Second))
+	if export.alarmOf("d2", "temp") != nil {
+		t.Fatal("alarm should be delayed")
+	}
+	export.checkDelay()
+	if alarm := export.alarmOf("d2", "temp"); alarm == nil || alarm.Value != 9 {
+		t.Fatalf("alarm = %+v", alarm)
+	}
+}
+
+func TestShelve(t *testing.T) {
+	export := newTestExport(t)
+	r := rule{high: 30, hasHigh: true}
+	now := time.Now()
+
+	if err := export.Shelve("unknown", "temp", time.Hour); err != errAlarmRuleNotFound {
+		t.Fatalf("shelve unknown point err = %v", err)
+	}
+	if len(export.points) != 0 {
+		t.Fatal("shelve unknown point should not create state")
+	}
+
+	//搁置期间告警照常清除，但不产生新的告警
+	export.update("d1", "temp", r, 31, now)
+	if err := export.Shelve("d1", "temp", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if alarms := export.List("d1", ""); len(alarms) != 1 || alarms[0].ShelvedUntil == nil {
+		t.Fatalf("alarms = %+v", alarms)
+	}
+	export.update("d1", "temp", r, 20, now)
+	export.update("d1", "temp", r, 31, now)
+	if export.alarmOf("d1", "temp") != nil {
+		t.Fatal("shelved point should not raise alarm")
+	}
+
+	if err := export.Shelve("d1", "temp", 0); err != nil {
+		t.Fatal(err)
+	}
+	export.update("d1", "temp", r, 32, time.Now())
+	if export.alarmOf("d1", "temp") == nil {
+		t.Fatal("alarm should be raised after unshelve")
+	}
+}
@@ -0,0 +1,103 @@
+package alarm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ibuilding-x/driver-box/v2/driverbox"
+)
+
+// 告警管理 API，统一挂载于 /api/v1/ 下
+const (
+	apiList      = "alarm/list"
+	apiAck       = "alarm/ack"
+	apiShelve    = "alarm/shelve"
+	apiUnshelve  = "alarm/unshelve"
+	apiHistory   = "alarm/history"
+	defaultLimit = 100
+)
+
+var errPointRequired = errors.New("devSn and point are required")
+
+func (export *export) registerApi() {
+	driverbox.BaseExport().HandleFunc(http.MethodGet, apiList, export.apiList)
+	driverbox.BaseExport().HandleFunc(http.MethodPost, apiAck, export.apiAck)
+	driverbox.BaseExport().HandleFunc(http.MethodPost, apiShelve, export.apiShelve)
+	driverbox.BaseExport().HandleFunc(http.MethodPost, apiUnshelve, export.apiUnshelve)
+	driverbox.BaseExport().HandleFunc(http.MethodGet, apiHistory, export.apiHistory)
+}
+
+// 未清除的告警列表，支持按设备、告警等级过滤
+// curl "http://127.0.0.1:8081/api/v1/alarm/list?devSn=xxx&severity=major"
+func (export *export) apiList(r *http.Request) (any, error) {
+	query := r.URL.Query()
+	return export.List(query.Get("devSn"), query.Get("severity")), nil
+}
+
+// 确认告警
+// curl -X POST "http://127.0.0.1:8081/api/v1/alarm/ack?id=1"
+func (export *export) apiAck(r *http.Request) (any, error) {
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id: %w", err)
+	}
+	return nil, export.Ack(id)
+}
+
+// 搁置设备点位告警，duration 格式如：30m、1h
+// curl -X POST "http://127.0.0.1:8081/api/v1/alarm/shelve?devSn=xxx&point=xxx&duration=1h"
+func (export *export) apiShelve(r *http.Request) (any, error) {
+	query := r.URL.Query()
+	deviceID, pointName := query.Get("devSn"), query.Get("point")
+	if deviceID == "" || pointName == "" {
+		return nil, errPointRequired
+	}
+	duration, err := time.ParseDuration(query.Get("duration"))
+	if err != nil || duration <= 0 {
+		return nil, fmt.Errorf("invalid duration: %s", query.Get("duration"))
+	}
+	return nil, export.Shelve(deviceID, pointName, duration)
+}
+
+// 取消搁置设备点位告警
+// curl -X POST "http://127.0.0.1:8081/api/v1/alarm/unshelve?devSn=xxx&point=xxx"
+func (export *export) apiUnshelve(r *http.Request) (any, error) {
+	query := r.URL.Query()
+	deviceID, pointName := query.Get("devSn"), query.Get("point")
+	if deviceID == "" || pointName == "" {
+		return nil, errPointRequired
+	}
+	return nil, export.Shelve(deviceID, pointName, 0)
+}
+
+// 查询告警历史，时间格式：2006-01-02 15:04:05
+// curl "http://127.0.0.1:8081/api/v1/alarm/history?devSn=xxx&point=xxx&state=cleared&startTime=2024-01-01%2000:00:00&limit=100"
+func (export *export) apiHistory(r *http.Request) (any, error) {
+	query := r.URL.Query()
+	q := historyQuery{
+		DeviceID:  query.Get("devSn"),
+		PointName: query.Get("point"),
+		State:     State(query.Get("state")),
+		Limit:     defaultLimit,
+	}
+	var err error
+	if v := query.Get("startTime"); v != "" {
+		if q.Start, err = time.ParseInLocation(time.DateTime, v, time.Local); err != nil {
+			return nil, fmt.Errorf("invalid startTime: %w", err)
+		}
+	}
+	if v := query.Get("endTime"); v != "" {
+		if q.End, err = time.ParseInLocation(time.DateTime, v, time.Local); err != nil {
+			return nil, fmt.Errorf("invalid endTime: %w", err)
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		if q.Limit, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid limit: %w", err)
+		}
+	}
+	return export.store.history(q)
+}
@@ -0,0 +1,50 @@
+package alarm
+
+import (
+	"github.com/ibuilding-x/driver-box/v2/driverbox"
+	"github.com/ibuilding-x/driver-box/v2/pkg/event"
+)
+
+const (
+	// EVT_ALARM_RAISE 产生告警事件，key 为设备ID，value 为 Alarm
+	EVT_ALARM_RAISE = event.EventCode("export.alarm.raise")
+	// EVT_ALARM_CLEAR 清除告警事件，key 为设备ID，value 为 Alarm
+	EVT_ALARM_CLEAR = event.EventCode("export.alarm.clear")
+	// EVT_ALARM_ACK 确认告警事件，key 为设备ID，value 为 Alarm
+	EVT_ALARM_ACK = event.EventCode("export.alarm.ack")
+)
+
+// alarmEvent 待发送的告警事件
+type alarmEvent struct {
+	code  event.EventCode
+	alarm Alarm
+}
+
+// emit 记录告警事件并通知发送任务，避免持有告警锁时回调其他 Export
+func (export *export) emit(code event.EventCode, alarm Alarm) {
+	export.eventsMutex.Lock()
+	export.events = append(export.events, alarmEvent{code: code, alarm: alarm})
+	export.eventsMutex.Unlock()
+	select {
+	case export.notify <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch 按产生顺序发送告警事件，保证同一告警的产生、确认、清除事件依次送达
+func (export *export) dispatch(notify <-chan struct{}, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-notify:
+		}
+		export.eventsMutex.Lock()
+		events := export.events
+		export.events = nil
+		export.eventsMutex.Unlock()
+		for _, e := range events {
+			driverbox.TriggerEvents(e.code, e.alarm.DeviceID, e.alarm)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package alarm
+
+import (
+	"sync"
+
+	"github.com/ibuilding-x/driver-box/v2/driverbox"
+	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
+	"github.com/ibuilding-x/driver-box/v2/pkg/crontab"
+	"github.com/ibuilding-x/driver-box/v2/pkg/event"
+	"go.uber.org/zap"
+)
+
+var driverInstance *export
+var once = &sync.Once{}
+
+// 告警管理插件，根据模型点位定义的告警规则产生、确认、清除告警
+type export struct {
+	// 点位告警状态，key：设备ID/点位名称
+	points map[string]*pointState
+	mutex  sync.Mutex
+	store  *store
+	// 告警延时检查任务
+	delayTask *crontab.Future
+	// 待发送的告警事件，按产生顺序依次发送
+	events      []alarmEvent
+	eventsMutex sync.Mutex
+	notify      chan struct{}
+	stop        chan struct{}
+	ready       bool
+}
+
+// EnableExport 加载告警管理Export插件
+func EnableExport() {
+	driverbox.EnableExport(newExport())
+}
+
+func newExport() *export {
+	once.Do(func() {
+		driverInstance = &export{}
+	})
+	return driverInstance
+}
+
+func (export *export) Init() error {
+	var err error
+	export.points = make(map[string]*pointState)
+	export.store, err = newStore()
+	if err != nil {
+		driverbox.Log().Error("init alarm store error", zap.Error(err))
+		return err
+	}
+	//恢复未清除的告警
+	alarms, err := export.store.active()
+	if err != nil {
+		driverbox.Log().Error("load active alarm error", zap.Error(err))
+		return err
+	}
+	for _, alarm := range alarms {
+		export.state(alarm.DeviceID, alarm.PointName).alarm = alarm
+	}
+	export.delayTask, err = driverbox.AddFunc("1s", export.checkDelay)
+	if err != nil {
+		return err
+	}
+	export.notify = make(chan struct{}, 1)
+	export.stop = make(chan struct{})
+	go export.dispatch(export.notify, export.stop)
+	export.registerApi()
+	export.ready = true
+	return nil
+}
+
+func (export *export) Destroy() error {
+	export.ready = false
+	if export.delayTask != nil {
+		export.delayTask.Disable()
+	}
+	if export.stop != nil {
+		close(export.stop)
+		export.stop = nil
+	}
+	if export.store != nil {
+		return export.store.close()
+	}
+	return nil
+}
+
+// ExportTo 校验点位值是否满足告警规则
+func (export *export) ExportTo(deviceData plugin.DeviceData) {
+	for _, point := range deviceData.Values {
+		export.evaluate(deviceData.ID, point.PointName, point.Value)
+	}
+}
+
+// OnEvent 删除设备时清除该设备的所有告警
+func (export *export) OnEvent(eventCode event.EventCode, key string, eventValue interface{}) error {
+	switch eventCode {
+	case event.DeviceDeleting:
+		export.clearDevice(key)
+	}
+	return nil
+}
+
+func (export *export) IsReady() bool {
+	return export.ready
+}
@@ -0,0 +1,106 @@
+package alarm
+
+import (
+	"time"
+
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+)
+
+// State 告警状态
+type State string
+
+const (
+	// StateActive 告警中，未确认
+	StateActive State = "active"
+	// StateAcknowledged 告警中，已确认
+	StateAcknowledged State = "acknowledged"
+	// StateCleared 告警已清除
+	StateCleared State = "cleared"
+)
+
+// Type 告警类型
+type Type string
+
+const (
+	// TypeHigh 高限告警
+	TypeHigh Type = "high"
+	// TypeLow 低限告警
+	TypeLow Type = "low"
+)
+
+const (
+	SeverityCritical = "critical"
+	SeverityMajor    = "major"
+	SeverityMinor    = "minor"
+	SeverityWarning  = "warning"
+)
+
+// Alarm 设备点位告警
+type Alarm struct {
+	ID        int64  `json:"id"`
+	DeviceID  string `json:"devSn"`
+	ModelName string `json:"modelName"`
+	PointName string `json:"point"`
+	Type      Type   `json:"type"`
+	Severity  string `json:"severity"`
+	State     State  `json:"state"`
+	// Value 告警期间最新的点位值
+	Value float64 `json:"value"`
+	// Threshold 触发告警的阈值
+	Threshold float64    `json:"threshold"`
+	RaiseTime time.Time  `json:"raiseTime"`
+	AckTime   *time.Time `json:"ackTime,omitempty"`
+	ClearTime *time.Time `json:"clearTime,omitempty"`
+	// ShelvedUntil 搁置截止时间，搁置期间不产生告警及事件通知
+	ShelvedUntil *time.Time `json:"shelvedUntil,omitempty"`
+}
+
+// rule 点位告警规则，来源于模型点位定义
+type rule struct {
+	high     float64
+	hasHigh  bool
+	low      float64
+	hasLow   bool
+	deadband float64
+	delay    time.Duration
+	severity string
+}
+
+// parseRule 解析点位告警规则，未配置高低限时返回 false
+func parseRule(point config.Point) (rule, bool) {
+	r := rule{
+		deadband: point.AlarmDeadband(),
+		delay:    time.Duration(point.AlarmDelay() * float64(time.Second)),
+		severity: point.AlarmSeverity(),
+	}
+	r.high, r.hasHigh = point.AlarmHigh()
+	r.low, r.hasLow = point.AlarmLow()
+	if r.severity == "" {
+		r.severity = SeverityWarning
+	}
+	return r, r.hasHigh || r.hasLow
+}
+
+// check 判断点位值的越限类型，未越限返回空字符串
+// active 为当前告警类型，处于告警中的点位需回落超出死区才视为恢复
+func (r rule) check(value float64, active Type) Type {
+	switch {
+	case active == TypeHigh && r.hasHigh && value > r.high-r.deadband:
+		return TypeHigh
+	case active == TypeLow && r.hasLow && value < r.low+r.deadband:
+		return TypeLow
+	case r.hasHigh && value > r.high:
+		return TypeHigh
+	case r.hasLow && value < r.low:
+		return TypeLow
+	}
+	return ""
+}
+
+// threshold 告警类型对应的阈值
+func (r rule) threshold(t Type) float64 {
+	if t == TypeHigh {
+		return r.high
+	}
+	return r.low
+}
@@ -0,0 +1,50 @@
+package alarm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+)
+
+func TestRule_Check(t *testing.T) {
+	r, ok := parseRule(config.Point{
+		"name":          "temp",
+		"alarmHigh":     float64(30),
+		"alarmLow":      float64(10),
+		"alarmDeadband": float64(2),
+		"alarmDelay":    float64(5),
+	})
+	if !ok {
+		t.Fatal("rule should be parsed")
+	}
+	if r.delay != 5*time.Second || r.severity != SeverityWarning {
+		t.Fatalf("unexpected rule: %+v", r)
+	}
+	tests := []struct {
+		value  float64
+		active Type
+		want   Type
+	}{
+		{20, "", ""},
+		{30, "", ""},
+		{31, "", TypeHigh},
+		{29, TypeHigh, TypeHigh},
+		{28, TypeHigh, ""},
+		{9, "", TypeLow},
+		{11, TypeLow, TypeLow},
+		{12, TypeLow, ""},
+		{9, TypeHigh, TypeLow},
+	}
+	for _, tt := range tests {
+		if got := r.check(tt.value, tt.active); got != tt.want {
+			t.Errorf("check(%v, %q) = %q, want %q", tt.value, tt.active, got, tt.want)
+		}
+	}
+}
+
+func TestParseRule_Disabled(t *testing.T) {
+	if _, ok := parseRule(config.Point{"name": "temp"}); ok {
+		t.Fatal("point without alarm limits should be ignored")
+	}
+}
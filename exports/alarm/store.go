@@ -0,0 +1,197 @@
+package alarm
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/glebarez/sqlite"
+	"github.com/ibuilding-x/driver-box/v2/driverbox"
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+	"github.com/ibuilding-x/driver-box/v2/pkg/crontab"
+	"go.uber.org/zap"
+)
+
+const schemaSQL = `
+CREATE TABLE IF NOT EXISTS alarm_history( -- 告警历史
+    id INTEGER PRIMARY KEY NOT NULL, -- 自增主键ID
+    device_id varchar(255) NOT NULL, -- 设备ID
+    model_name varchar(255), -- 模型名称
+    point_name varchar(255) NOT NULL, -- 点位名称
+    type varchar(32) NOT NULL, -- 告警类型：high、low
+    severity varchar(32) NOT NULL, -- 告警等级
+    state varchar(32) NOT NULL, -- 告警状态：active、acknowledged、cleared
+    value REAL NOT NULL, -- 最新点位值
+    threshold REAL NOT NULL, -- 告警阈值
+    raise_time INTEGER NOT NULL, -- 告警时间，毫秒时间戳
+    ack_time INTEGER, -- 确认时间，毫秒时间戳
+    clear_time INTEGER -- 清除时间，毫秒时间戳
+    );
+CREATE INDEX IF NOT EXISTS idx_alarm_device_time ON alarm_history(device_id,raise_time);
+CREATE INDEX IF NOT EXISTS idx_alarm_state ON alarm_history(state);
+`
+
+// store 告警历史存储
+type store struct {
+	db *sql.DB
+	// 已清除告警保存时长，单位：天
+	reservedDays int
+	clearTask    *crontab.Future
+}
+
+// historyQuery 告警历史查询条件
+type historyQuery struct {
+	DeviceID  string
+	PointName string
+	State     State
+	Start     time.Time
+	End       time.Time
+	Limit     int
+}
+
+func newStore() (*store, error) {
+	dir := os.Getenv(config.EXPORT_ALARM_DATA_PATH)
+	if dir == "" {
+		dir = filepath.Join(config.ResourcePath, "alarm")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", filepath.Join(dir, "alarm.db"))
+	if err != nil {
+		return nil, err
+	}
+	//sqlite 单连接写入，避免 database is locked
+	db.SetMaxOpenConns(1)
+	if _, err = db.Exec(schemaSQL); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	s := &store{
+		db:           db,
+		reservedDays: 30,
+	}
+	if v, e := strconv.Atoi(os.Getenv(config.EXPORT_ALARM_RESERVED_DAYS)); e == nil && v > 0 {
+		s.reservedDays = v
+	}
+	s.clearTask, err = driverbox.AddFunc("1h", s.clearExpired)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *store) close() error {
+	s.clearTask.Disable()
+	return s.db.Close()
+}
+
+// insert 保存新产生的告警，并回填告警ID
+func (s *store) insert(alarm *Alarm) error {
+	result, err := s.db.Exec("INSERT INTO alarm_history(device_id,model_name,point_name,type,severity,state,value,threshold,raise_time) VALUES(?,?,?,?,?,?,?,?,?)",
+		alarm.DeviceID, alarm.ModelName, alarm.PointName, alarm.Type, alarm.Severity, alarm.State, alarm.Value, alarm.Threshold, alarm.RaiseTime.UnixMilli())
+	if err != nil {
+		return err
+	}
+	alarm.ID, err = result.LastInsertId()
+	return err
+}
+
+// update 更新告警状态
+func (s *store) update(alarm *Alarm) error {
+	_, err := s.db.Exec("UPDATE alarm_history SET state=?,value=?,ack_time=?,clear_time=? WHERE id=?",
+		alarm.State, alarm.Value, unixMilli(alarm.AckTime), unixMilli(alarm.ClearTime), alarm.ID)
+	return err
+}
+
+// active 查询未清除的告警，用于服务重启后恢复告警状态
+func (s *store) active() ([]*Alarm, error) {
+	return s.query("SELECT id,device_id,model_name,point_name,type,severity,state,value,threshold,raise_time,ack_time,clear_time FROM alarm_history WHERE state != ?", StateCleared)
+}
+
+// history 查询告警历史，按告警时间倒序排列
+func (s *store) history(q historyQuery) ([]*Alarm, error) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+	if q.DeviceID != "" {
+		conditions = append(conditions, "device_id = ?")
+		args = append(args, q.DeviceID)
+	}
+	if q.PointName != "" {
+		conditions = append(conditions, "point_name = ?")
+		args = append(args, q.PointName)
+	}
+	if q.State != "" {
+		conditions = append(conditions, "state = ?")
+		args = append(args, q.State)
+	}
+	if !q.Start.IsZero() {
+		conditions = append(conditions, "raise_time >= ?")
+		args = append(args, q.Start.UnixMilli())
+	}
+	if !q.End.IsZero() {
+		conditions = append(conditions, "raise_time <= ?")
+		args = append(args, q.End.UnixMilli())
+	}
+	sqlStr := "SELECT id,device_id,model_name,point_name,type,severity,state,value,threshold,raise_time,ack_time,clear_time FROM alarm_history"
+	if len(conditions) > 0 {
+		sqlStr += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	sqlStr += " ORDER BY raise_time DESC, id DESC"
+	if q.Limit > 0 {
+		sqlStr += " LIMIT " + strconv.Itoa(q.Limit)
+	}
+	return s.query(sqlStr, args...)
+}
+
+func (s *store) query(sqlStr string, args ...interface{}) ([]*Alarm, error) {
+	rows, err := s.db.Query(sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	alarms := make([]*Alarm, 0)
+	for rows.Next() {
+		var alarm Alarm
+		var modelName sql.NullString
+		var raiseTime int64
+		var ackTime, clearTime sql.NullInt64
+		if err = rows.Scan(&alarm.ID, &alarm.DeviceID, &modelName, &alarm.PointName, &alarm.Type, &alarm.Severity,
+			&alarm.State, &alarm.Value, &alarm.Threshold, &raiseTime, &ackTime, &clearTime); err != nil {
+			return nil, err
+		}
+		alarm.ModelName = modelName.String
+		alarm.RaiseTime = time.UnixMilli(raiseTime)
+		alarm.AckTime = fromUnixMilli(ackTime)
+		alarm.ClearTime = fromUnixMilli(clearTime)
+		alarms = append(alarms, &alarm)
+	}
+	return alarms, rows.Err()
+}
+
+// clearExpired 清理过期的已清除告警
+func (s *store) clearExpired() {
+	expired := time.Now().Add(-24 * time.Duration(s.reservedDays) * time.Hour).UnixMilli()
+	if _, err := s.db.Exec("DELETE FROM alarm_history WHERE state = ? AND clear_time < ?", StateCleared, expired); err != nil {
+		driverbox.Log().Error("clear expired alarm error", zap.Error(err))
+	}
+}
+
+func unixMilli(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UnixMilli()
+}
+
+func fromUnixMilli(v sql.NullInt64) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := time.UnixMilli(v.Int64)
+	return &t
+}
@@ -108,7 +108,7 @@ func (export *export) deviceAutoDiscover(deviceId string, value interface{}) err
 		model.DevicePoints = points
 	}
 
-	err = driverbox.CoreCache().AddModel(deviceDiscover.ProtocolName, model)
+	err = driverbox.CoreCache().WithSource("discover").AddModel(deviceDiscover.ProtocolName, model)
 	if err != nil {
 		driverbox.Log().Error("device auto discover add model error", zap.String("deviceId", deviceId), zap.Any("value", value), zap.Any("error", err))
 		return err
@@ -116,7 +116,7 @@ func (export *export) deviceAutoDiscover(deviceId string, value interface{}) err
 	//添加设备
 	deviceDiscover.Device.ModelName = model.Name
 	deviceDiscover.Device.ConnectionKey = deviceDiscover.ConnectionKey
-	err = driverbox.CoreCache().AddOrUpdateDevice(deviceDiscover.Device)
+	err = driverbox.CoreCache().WithSource("discover").AddOrUpdateDevice(deviceDiscover.Device)
 	if err != nil {
 		driverbox.Log().Error("device auto discover add device error", zap.String("deviceId", deviceId), zap.Any("value", value), zap.Any("error", err))
 		return err
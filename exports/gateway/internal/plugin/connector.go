@@ -295,7 +295,7 @@ func (c *connector) syncModels(payload model.WSPayload) error {
 		var errCounter int
 		for _, model := range payload.Models {
 
-			err := driverbox.CoreCache().AddModel(ProtocolName, model)
+			err := driverbox.CoreCache().WithSource(ProtocolName).AddModel(ProtocolName, model)
 			if err != nil {
 				errCounter++
 				driverbox.Log().Error("gateway plugin add model failed", zap.Any("model", model), zap.Error(err))
@@ -343,7 +343,7 @@ func (c *connector) syncDevices(payload model.WSPayload) error {
 				device.Properties = localDevice.Properties
 			}
 
-			err := driverbox.CoreCache().AddOrUpdateDevice(device)
+			err := driverbox.CoreCache().WithSource(ProtocolName).AddOrUpdateDevice(device)
 			if err != nil {
 				errCounter++
 				driverbox.Log().Error("gateway plugin add device failed", zap.Any("device", device))
@@ -27,29 +27,60 @@ func (export0 *Export) writeRealTimeData(queue []deviceQueueData) {
 	for _, deviceData := range queue {
 		deviceId := deviceData.deviceData.ID
 		device, res := driverbox.CoreCache().GetDevice(deviceId)
-		pointData := map[string]interface{}{
-			"device_id":   deviceId,
-			"create_time": deviceData.addTime,
-		}
+		moId := ""
 		if res {
 			model, success := driverbox.CoreCache().GetModel(device.ModelName)
 			if success {
-				pointData["mo_id"] = model.ModelID
+				moId = model.ModelID
 			}
 		}
-		pointNum := len(deviceData.deviceData.Values)
-		if pointNum > 0 {
-			dataPoint := make(map[string]interface{}, pointNum)
-			for _, item := range deviceData.deviceData.Values {
-				dataPoint[item.PointName] = item.Value
+		//按数据源时间分组，未携带时间戳的点位以入队时间为准
+		groups := make(map[time.Time][]plugin.PointData)
+		times := make([]time.Time, 0, 1)
+		for _, item := range deviceData.deviceData.Values {
+			createTime := deviceData.addTime
+			if item.Timestamp > 0 {
+				createTime = time.UnixMilli(item.Timestamp)
 			}
-			pointStr, er := json.Marshal(dataPoint)
-			if er != nil {
-				driverbox.Log().Error(fmt.Sprintf("realTime points json marshal error %v", er.Error()))
+			if _, ok := groups[createTime]; !ok {
+				times = append(times, createTime)
+			}
+			groups[createTime] = append(groups[createTime], item)
+		}
+		if len(times) == 0 {
+			times = append(times, deviceData.addTime)
+		}
+		for _, createTime := range times {
+			pointData := map[string]interface{}{
+				"device_id":   deviceId,
+				"create_time": createTime,
+			}
+			if moId != "" {
+				pointData["mo_id"] = moId
 			}
-			pointData["point_data"] = string(pointStr)
+			if values := groups[createTime]; len(values) > 0 {
+				dataPoint := make(map[string]interface{}, len(values))
+				quality := make(map[string]plugin.Quality)
+				for _, item := range values {
+					if !item.Quality.IsGood() {
+						quality[item.PointName] = item.Quality
+						//仅携带数据质量的点位不记录数值
+						if item.Value == nil {
+							continue
+						}
+					}
+					dataPoint[item.PointName] = item.Value
+				}
+				pointStr, er := json.Marshal(dataPoint)
+				if er != nil {
+					driverbox.Log().Error(fmt.Sprintf("realTime points json marshal error %v", er.Error()))
+				}
+				pointData["point_data"] = string(pointStr)
+				qualityStr, _ := json.Marshal(quality)
+				pointData["quality"] = string(qualityStr)
+			}
+			batchRealTimeData.SaveDbData = append(batchRealTimeData.SaveDbData, pointData)
 		}
-		batchRealTimeData.SaveDbData = append(batchRealTimeData.SaveDbData, pointData)
 	}
 	export0.batchInsert(batchRealTimeData)
 }
@@ -84,10 +115,14 @@ func (export0 *Export) writeDeviceSnapshotData() {
 			if res {
 				pointData["mo_id"] = model.ModelID
 			}
+			quality := make(map[string]plugin.Quality)
 			if len(device.Points) > 0 {
 				dataPoint := make(map[string]interface{})
 				for _, item := range device.Points {
 					dataPoint[item.Name] = item.Value
+					if !item.Quality.IsGood() {
+						quality[item.Name] = item.Quality
+					}
 				}
 				pointStr, er := json.Marshal(dataPoint)
 				if er != nil {
@@ -107,6 +142,9 @@ func (export0 *Export) writeDeviceSnapshotData() {
 			} else {
 				meta["online"] = 0
 			}
+			if len(quality) > 0 {
+				meta["quality"] = quality
+			}
 
 			str, er := json.Marshal(meta)
 			if er != nil {
@@ -41,6 +41,7 @@ CREATE TABLE IF NOT EXISTS real_time_data( -- 设备影子实时数据，实时
     device_id varchar(255) NOT null, -- 设备ID
     mo_id varchar(255) ,-- 模型ID
     point_data TEXT NOT null, -- 设备影子数据，即物模型点位定义的数据，json格式,例如：{"pointName1":"pointValue2","pointName2":"pointValue2"}
+    quality TEXT DEFAULT '{}', -- 数据质量异常的点位，json格式,例如：{"pointName1":"commFailure"}
     create_time DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP --创建时间
     ); 
 
@@ -137,9 +138,9 @@ func (export0 *Export) Destroy() error {
 	return export0.db.Close()
 }
 
-// ExportTo 接收驱动数据
+// ExportTo 接收驱动数据，乱序补录数据按源时间存储
 func (export0 *Export) ExportTo(deviceData plugin.DeviceData) {
-	if plugin.RealTimeExport == deviceData.ExportType {
+	if plugin.RealTimeExport == deviceData.ExportType || plugin.HistoricalExport == deviceData.ExportType {
 		export0.realTimeDataQueue = append(export0.realTimeDataQueue, deviceQueueData{
 			deviceData: deviceData,
 			addTime:    time.Now(),
@@ -152,6 +153,9 @@ func (export0 *Export) ExportTo(deviceData plugin.DeviceData) {
 	}
 }
 
+// ReceiveHistorical 接收乱序补录数据
+func (export0 *Export) ReceiveHistorical() {}
+
 // OnEvent 接收事件数据
 func (export0 *Export) OnEvent(eventCode event.EventCode, key string, eventValue interface{}) error {
 	// 暂时不处理任何事件
@@ -194,9 +198,41 @@ func (export0 *Export) initHistoryDataDB() error {
 		driverbox.Log().Error(err.Error())
 		return err
 	}
+	//兼容旧版本数据库，补充数据质量字段
+	exists, err := export0.columnExists(TableNameRealTimeData, "quality")
+	if err != nil {
+		driverbox.Log().Error(err.Error())
+		return err
+	}
+	if !exists {
+		_, err = export0.db.Exec("ALTER TABLE " + TableNameRealTimeData + " ADD COLUMN quality TEXT DEFAULT '{}'")
+		if err != nil {
+			driverbox.Log().Error(err.Error())
+			return err
+		}
+	}
 	return nil
 }
 
+// columnExists 判断数据表中是否存在指定字段
+func (export0 *Export) columnExists(table, column string) (bool, error) {
+	rows, err := export0.db.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
 func (export0 *Export) clearExpiredData(day int) {
 	historyDataSql := "DELETE FROM snapshot_data where create_time < ?"
 	realTimeDataSql := "DELETE FROM real_time_data where create_time < ?"
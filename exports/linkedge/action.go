@@ -0,0 +1,225 @@
+package linkedge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ibuilding-x/driver-box/v2/driverbox"
+	"github.com/ibuilding-x/driver-box/v2/exports/linkedge/model"
+	"github.com/ibuilding-x/driver-box/v2/pkg/convutil"
+	"github.com/ibuilding-x/driver-box/v2/pkg/event"
+	"github.com/ibuilding-x/driver-box/v2/pkg/luautil"
+	"go.uber.org/zap"
+)
+
+const (
+	// 动作默认超时时长
+	defaultActionTimeout = 10 * time.Second
+	// 动作重试间隔
+	actionRetryInterval = time.Second
+	// 动作输出最大记录长度
+	maxActionOutput = 1024
+)
+
+// actionContext 动作执行上下文，供 HTTP 请求体模板及 Lua 脚本使用
+type actionContext struct {
+	ID     string              `json:"id"`
+	Name   string              `json:"name"`
+	Source model.TriggerSource `json:"source"`
+	Time   time.Time           `json:"time"`
+	// 联动深度
+	depth int
+}
+
+// emission 场景联动动作正在发布的事件
+type emission struct {
+	origin string
+	depth  int
+}
+
+// templateFuncs HTTP 请求体模板函数
+var templateFuncs = template.FuncMap{
+	// point 读取设备点位影子值
+	"point": func(deviceID, pointName string) interface{} {
+		value, _ := driverbox.Shadow().GetDevicePoint(deviceID, pointName)
+		return value
+	},
+	"json": func(v interface{}) (string, error) {
+		bs, err := json.Marshal(v)
+		return string(bs), err
+	},
+}
+
+// executeAction 执行 http、event、script、virtualPoint 动作，失败时按配置重试
+func (s *export) executeAction(action model.Action, ac actionContext, result *model.ActionResult) {
+	timeout := defaultActionTimeout
+	if len(action.Timeout) > 0 {
+		if d, err := time.ParseDuration(action.Timeout); err == nil && d > 0 {
+			timeout = d
+		}
+	}
+	var output string
+	var err error
+	for result.Attempts = 1; ; result.Attempts++ {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		output, err = s.doAction(ctx, action, ac)
+		cancel()
+		if err == nil || result.Attempts > action.Retry {
+			break
+		}
+		driverbox.Log().Warn("execute linkEdge action error, retry later", zap.String("linkEdge", ac.ID),
+			zap.String("type", string(action.Type)), zap.Int("attempts", result.Attempts), zap.Error(err))
+		time.Sleep(actionRetryInterval)
+	}
+	if len(output) > maxActionOutput {
+		output = output[:maxActionOutput]
+	}
+	result.Output = output
+	if err != nil {
+		driverbox.Log().Error("execute linkEdge action error", zap.String("linkEdge", ac.ID),
+			zap.String("type", string(action.Type)), zap.Error(err))
+		result.Status = model.ActionStatusFail
+		result.Error = err.Error()
+	}
+}
+
+func (s *export) doAction(ctx context.Context, action model.Action, ac actionContext) (string, error) {
+	switch action.Type {
+	case model.ActionTypeHttp:
+		return s.doHttpAction(ctx, action.HttpAction, ac)
+	case model.ActionTypeEvent:
+		key := action.Key
+		if len(key) == 0 {
+			key = ac.ID
+		}
+		//事件同步分发，分发期间可查询事件来源
+		done := s.markEmitting(action.Event, key, ac)
+		defer done()
+		driverbox.TriggerEvents(action.Event, key, action.EventValue)
+		return "", nil
+	case model.ActionTypeScript:
+		return "", luautil.DoString(ctx, action.Script, map[string]interface{}{"linkedge": ac})
+	case model.ActionTypeVirtualPoint:
+		return "", s.doVirtualPointAction(action.DevicePointAction)
+	default:
+		return "", errors.New("unsupported action type: " + string(action.Type))
+	}
+}
+
+// markEmitting 标记场景正在发布的事件，返回取消标记的函数
+func (s *export) markEmitting(code event.EventCode, key string, ac actionContext) func() {
+	k := string(code) + "/" + key
+	e := &emission{origin: ac.ID, depth: ac.depth}
+	s.emitMutex.Lock()
+	if s.emitting == nil {
+		s.emitting = make(map[string][]*emission)
+	}
+	s.emitting[k] = append(s.emitting[k], e)
+	s.emitMutex.Unlock()
+	return func() {
+		s.emitMutex.Lock()
+		defer s.emitMutex.Unlock()
+		list := s.emitting[k]
+		for i, v := range list {
+			if v == e {
+				list = append(list[:i], list[i+1:]...)
+				break
+			}
+		}
+		if len(list) == 0 {
+			delete(s.emitting, k)
+		} else {
+			s.emitting[k] = list
+		}
+	}
+}
+
+// emittedBy 查询事件是否由场景联动动作发布，返回发布该事件的场景及被触发场景的联动深度
+func (s *export) emittedBy(code event.EventCode, key string) (map[string]bool, int) {
+	s.emitMutex.Lock()
+	defer s.emitMutex.Unlock()
+	list := s.emitting[string(code)+"/"+key]
+	if len(list) == 0 {
+		return nil, 0
+	}
+	origins := make(map[string]bool, len(list))
+	depth := 0
+	for _, e := range list {
+		origins[e.origin] = true
+		if e.depth+1 > depth {
+			depth = e.depth + 1
+		}
+	}
+	return origins, depth
+}
+
+// doHttpAction 调用 HTTP Webhook，非 2xx 响应视为失败
+func (s *export) doHttpAction(ctx context.Context, action model.HttpAction, ac actionContext) (string, error) {
+	method := strings.ToUpper(action.Method)
+	if len(method) == 0 {
+		method = http.MethodPost
+	}
+	var body io.Reader
+	if len(action.Body) > 0 {
+		tpl, err := template.New("body").Funcs(templateFuncs).Parse(action.Body)
+		if err != nil {
+			return "", err
+		}
+		buf := &bytes.Buffer{}
+		if err = tpl.Execute(buf, ac); err != nil {
+			return "", err
+		}
+		body = buf
+	}
+	req, err := http.NewRequestWithContext(ctx, method, action.URL, body)
+	if err != nil {
+		return "", err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range action.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	bs, _ := io.ReadAll(io.LimitReader(resp.Body, maxActionOutput))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return string(bs), fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return string(bs), nil
+}
+
+// doVirtualPointAction 设置虚拟点位，仅更新设备影子，不下发至设备
+func (s *export) doVirtualPointAction(action model.DevicePointAction) error {
+	points := action.Points
+	if action.DevicePoint != "" {
+		points = append([]model.DevicePointActionItem{{Point: action.DevicePoint, Value: fmt.Sprint(action.Value)}}, points...)
+	}
+	for _, p := range points {
+		var value interface{} = p.Value
+		// 模型中定义的点位按点位类型转换
+		if point, ok := driverbox.CoreCache().GetPointByDevice(action.DeviceID, p.Point); ok {
+			v, err := convutil.PointValue(p.Value, point.ValueType())
+			if err != nil {
+				return fmt.Errorf("convert %s value error: %v", p.Point, err)
+			}
+			value = v
+		}
+		if err := driverbox.Shadow().SetDevicePoint(action.DeviceID, p.Point, value); err != nil {
+			return fmt.Errorf("set %s.%s error: %v", action.DeviceID, p.Point, err)
+		}
+	}
+	return nil
+}
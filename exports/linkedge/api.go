@@ -0,0 +1,221 @@
+package linkedge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ibuilding-x/driver-box/v2/driverbox"
+	"github.com/ibuilding-x/driver-box/v2/exports/linkedge/model"
+)
+
+// 场景联动 API，统一挂载于 /api/v1/ 下
+const (
+	apiList    = "linkedge/list"
+	apiGet     = "linkedge/get"
+	apiCreate  = "linkedge/create"
+	apiUpdate  = "linkedge/update"
+	apiDelete  = "linkedge/delete"
+	apiTrigger = "linkedge/trigger"
+	apiPreview = "linkedge/preview"
+	apiEnable  = "linkedge/enable"
+	apiDisable = "linkedge/disable"
+	apiLast    = "linkedge/last"
+	apiRecords = "linkedge/records"
+)
+
+var (
+	errIdRequired  = errors.New("id is required")
+	errLinkEdgeNil = errors.New("linkEdge not found")
+)
+
+// configView 场景联动配置及其最近一次执行结果
+type configView struct {
+	model.Config
+	LastResult *model.ExecuteResult `json:"lastResult"`
+}
+
+func (export *export) registerApi() {
+	driverbox.BaseExport().HandleFunc(http.MethodGet, apiList, export.apiList)
+	driverbox.BaseExport().HandleFunc(http.MethodGet, apiGet, export.apiGet)
+	driverbox.BaseExport().HandleFunc(http.MethodPost, apiCreate, export.apiCreate)
+	driverbox.BaseExport().HandleFunc(http.MethodPost, apiUpdate, export.apiUpdate)
+	driverbox.BaseExport().HandleFunc(http.MethodPost, apiDelete, export.apiDelete)
+	driverbox.BaseExport().HandleFunc(http.MethodPost, apiTrigger, export.apiTrigger)
+	driverbox.BaseExport().HandleFunc(http.MethodPost, apiPreview, export.apiPreview)
+	driverbox.BaseExport().HandleFunc(http.MethodPost, apiEnable, func(r *http.Request) (any, error) {
+		return nil, export.apiEnable(r, true)
+	})
+	driverbox.BaseExport().HandleFunc(http.MethodPost, apiDisable, func(r *http.Request) (any, error) {
+		return nil, export.apiEnable(r, false)
+	})
+	driverbox.BaseExport().HandleFunc(http.MethodGet, apiLast, export.apiLast)
+	driverbox.BaseExport().HandleFunc(http.MethodGet, apiRecords, export.apiRecords)
+}
+
+// 场景联动列表，支持按标签过滤
+// curl http://127.0.0.1:8081/api/v1/linkedge/list?tag=xxx
+func (export *export) apiList(r *http.Request) (any, error) {
+	configs, err := export.GetList(r.URL.Query().Get("tag"))
+	if err != nil {
+		return nil, err
+	}
+	list := make([]configView, 0, len(configs))
+	for _, c := range configs {
+		list = append(list, export.toView(c))
+	}
+	return list, nil
+}
+
+// 获取场景联动详情
+func (export *export) apiGet(r *http.Request) (any, error) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		return nil, errIdRequired
+	}
+	c, err := export.Get(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errLinkEdgeNil, err)
+	}
+	return export.toView(c), nil
+}
+
+// 创建场景联动，未指定 id 时自动生成
+// curl -X POST -H "Content-Type: application/json" -d '{"name":"test","enable":true,"action":[...]}' http://127.0.0.1:8081/api/v1/linkedge/create
+func (export *export) apiCreate(r *http.Request) (any, error) {
+	c, err := readConfig(r)
+	if err != nil {
+		return nil, err
+	}
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	if err = export.Create(c); err != nil {
+		return nil, err
+	}
+	return c.ID, nil
+}
+
+// 更新场景联动
+func (export *export) apiUpdate(r *http.Request) (any, error) {
+	c, err := readConfig(r)
+	if err != nil {
+		return nil, err
+	}
+	if c.ID == "" {
+		return nil, errIdRequired
+	}
+	if _, err = export.Get(c.ID); err != nil {
+		return nil, fmt.Errorf("%w: %v", errLinkEdgeNil, err)
+	}
+	return nil, export.Update(c)
+}
+
+// 删除场景联动
+func (export *export) apiDelete(r *http.Request) (any, error) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		return nil, errIdRequired
+	}
+	return nil, export.Delete(id)
+}
+
+// 手动触发场景联动，返回本次执行结果
+func (export *export) apiTrigger(r *http.Request) (any, error) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		return nil, errIdRequired
+	}
+	if err := export.Trigger(id); err != nil {
+		return nil, err
+	}
+	result, _ := export.GetExecuteResult(id)
+	return result, nil
+}
+
+// 预览场景联动，不做持久化
+func (export *export) apiPreview(r *http.Request) (any, error) {
+	c, err := readConfig(r)
+	if err != nil {
+		return nil, err
+	}
+	// 预览场景无需 id，仅校验其余配置项
+	checked := c
+	if checked.ID == "" {
+		checked.ID = "preview"
+	}
+	if err = checked.Validate(); err != nil {
+		return nil, err
+	}
+	return nil, export.Preview(c)
+}
+
+// 启用/禁用场景联动
+func (export *export) apiEnable(r *http.Request, enable bool) error {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		return errIdRequired
+	}
+	return export.Enable(id, enable)
+}
+
+// 获取最近一次执行的场景联动
+func (export *export) apiLast(_ *http.Request) (any, error) {
+	c, err := export.GetLast()
+	if err != nil {
+		return nil, err
+	}
+	if c.ID == "" {
+		return nil, nil
+	}
+	return export.toView(c), nil
+}
+
+// 查询场景联动执行记录，时间格式：2006-01-02 15:04:05
+// curl "http://127.0.0.1:8081/api/v1/linkedge/records?id=xxx&startTime=2024-01-01%2000:00:00&endTime=2024-01-02%2000:00:00&limit=100"
+func (export *export) apiRecords(r *http.Request) (any, error) {
+	query := r.URL.Query()
+	var start, end time.Time
+	var err error
+	if v := query.Get("startTime"); v != "" {
+		if start, err = time.ParseInLocation(time.DateTime, v, time.Local); err != nil {
+			return nil, fmt.Errorf("invalid startTime: %w", err)
+		}
+	}
+	if v := query.Get("endTime"); v != "" {
+		if end, err = time.ParseInLocation(time.DateTime, v, time.Local); err != nil {
+			return nil, fmt.Errorf("invalid endTime: %w", err)
+		}
+	}
+	limit := 100
+	if v := query.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid limit: %w", err)
+		}
+	}
+	return export.GetRecords(query.Get("id"), start, end, limit)
+}
+
+func (export *export) toView(c model.Config) configView {
+	view := configView{Config: c}
+	if result, ok := export.GetExecuteResult(c.ID); ok {
+		view.LastResult = &result
+	}
+	return view
+}
+
+func readConfig(r *http.Request) (model.Config, error) {
+	var c model.Config
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return c, err
+	}
+	defer r.Body.Close()
+	err = json.Unmarshal(body, &c)
+	return c, err
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/ibuilding-x/driver-box/v2/exports/linkedge/model"
 	"github.com/ibuilding-x/driver-box/v2/pkg/config"
 	"github.com/ibuilding-x/driver-box/v2/pkg/event"
+	"github.com/ibuilding-x/driver-box/v2/pkg/expression"
 	"github.com/robfig/cron/v3"
 	"go.uber.org/zap"
 )
@@ -36,8 +37,23 @@ type export struct {
 	schedules map[string]*cron.Cron
 	//点位触发器
 	triggerConditions map[string][]model.DevicePointCondition
-	ready             bool
-	ConfigPath        string
+	//设备事件触发器
+	eventTriggers map[string][]model.Trigger
+	//表达式条件的编译结果，key：场景联动ID、表达式
+	expressions map[string]map[string]*expression.Expression
+	//保护配置缓存及触发器
+	mutex sync.RWMutex
+	//场景联动动作正在发布的事件，用于识别事件来源
+	emitting  map[string][]*emission
+	emitMutex sync.Mutex
+	//最近一次执行结果
+	executeResults map[string]model.ExecuteResult
+	resultMutex    sync.RWMutex
+	//执行记录，销毁时关闭
+	records      *recordStore
+	recordsMutex sync.RWMutex
+	ready        bool
+	ConfigPath   string
 }
 
 func (export *export) Init() error {
@@ -45,18 +61,32 @@ func (export *export) Init() error {
 	export.ConfigPath = filepath.Join(config.ResourcePath, "linkedge")
 	//启动场景联动服务
 	export.triggerConditions = make(map[string][]model.DevicePointCondition)
+	export.eventTriggers = make(map[string][]model.Trigger)
+	export.expressions = make(map[string]map[string]*expression.Expression)
 	export.configs = make(map[string]model.Config)
 	export.schedules = make(map[string]*cron.Cron)
+	export.executeResults = make(map[string]model.ExecuteResult)
+	//执行记录不可用时不影响场景联动运行
+	records, err := newRecordStore()
+	export.recordsMutex.Lock()
+	export.records = records
+	export.recordsMutex.Unlock()
+	if err != nil {
+		driverbox.Log().Error("init linkEdge record store error", zap.Error(err))
+	}
 	//启动场景联动
 	configs, e := export.GetList()
 	if e != nil {
 		return e
 	}
 	for _, config := range configs {
-		e = export.registerTrigger(config.ID)
+		triggers, e := export.buildTriggers(config)
 		if e != nil {
 			return e
 		}
+		export.mutex.Lock()
+		export.applyTriggers(config, triggers)
+		export.mutex.Unlock()
 	}
 
 	err = export.NewService()
@@ -64,15 +94,25 @@ func (export *export) Init() error {
 		driverbox.Log().Error(fmt.Sprintf("init linkEdge service error:%v", err))
 		return err
 	}
+	export.registerApi()
 	export.ready = true
 	return nil
 }
 func (export *export) Destroy() error {
 	export.ready = false
+	export.mutex.Lock()
 	for key, c := range export.schedules {
 		driverbox.Log().Info("stop linkEdge cron", zap.String("id", key))
 		c.Stop()
 	}
+	export.mutex.Unlock()
+	export.recordsMutex.Lock()
+	defer export.recordsMutex.Unlock()
+	if export.records != nil {
+		e := export.records.close()
+		export.records = nil
+		return e
+	}
 	return nil
 }
 func newExport() *export {
@@ -101,6 +141,8 @@ func (export *export) OnEvent(eventCode event.EventCode, key string, eventValue
 		for _, datum := range data {
 			export.devicePointTriggerHandler(datum, true)
 		}
+	default:
+		export.deviceEventTriggerHandler(eventCode, key, eventValue)
 	}
 	return nil
 }
@@ -17,6 +17,10 @@ import (
 	"github.com/ibuilding-x/driver-box/v2/driverbox"
 	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
 	"github.com/ibuilding-x/driver-box/v2/exports/linkedge/model"
+	"github.com/ibuilding-x/driver-box/v2/pkg/convutil"
+	"github.com/ibuilding-x/driver-box/v2/pkg/event"
+	"github.com/ibuilding-x/driver-box/v2/pkg/expression"
+	"github.com/ibuilding-x/driver-box/v2/pkg/metrics"
 	"github.com/robfig/cron/v3"
 	"go.uber.org/zap"
 )
@@ -28,11 +32,27 @@ const (
 	ExecuteResultPartSuccess = "partSuccess"
 	// ExecuteResultAllFail 全部失败
 	ExecuteResultAllFail = "fail"
+	// ExecuteResultSkipped 静默期内或不满足执行条件，未执行
+	ExecuteResultSkipped = "skipped"
 )
 
 // ErrActionListIsEmpty action 列表为空错误
 var ErrActionListIsEmpty = errors.New("linkEdge action list cannot be empty")
 
+var (
+	errLinkEdgeDisabled = errors.New("linkEdge is disable now")
+	errRecordDisabled   = errors.New("linkEdge record is unavailable")
+)
+
+// skipError 静默期内或不满足执行条件而未执行，不属于执行失败
+type skipError struct {
+	error
+}
+
+func (e skipError) Unwrap() error {
+	return e.error
+}
+
 func (s *export) NewService() error {
 
 	return nil
@@ -40,90 +60,156 @@ func (s *export) NewService() error {
 
 // Create 创建场景联动规则
 func (s *export) Create(model model.Config) error {
-
-	if _, exists := s.configs[model.ID]; exists {
-		return errors.New("linkEdge id is exists")
-	}
-
 	// 空 Action 校验
 	if len(model.Action) == 0 {
 		return ErrActionListIsEmpty
 	}
-	bytes, err := json.Marshal(model)
-	if err != nil {
+	if err := model.Validate(); err != nil {
 		return err
 	}
-	//持久化
-	// fix: 偶现写入的文件内容为空
-	file := path.Join(s.ConfigPath, model.ID+".json")
-	f, err := os.Create(file)
+	triggers, err := s.buildTriggers(model)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-
-	if _, err = f.Write(bytes); err != nil {
-		return err
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, exists := s.configs[model.ID]; exists {
+		return errors.New("linkEdge id is exists")
 	}
-	if err = f.Sync(); err != nil {
+	//持久化
+	if err = s.saveConfig(model); err != nil {
 		return err
 	}
-
 	//启动场景联动
-	err = s.registerTrigger(model.ID)
-	if err != nil {
-		//注册触发器存在异常,清理脏数据
-		_ = s.Delete(model.ID)
-		return err
-	}
+	s.applyTriggers(model, triggers)
 	return nil
 }
 
-// 注册触发器
-func (s *export) registerTrigger(id string) error {
-	m, e := s.Get(id)
-	if e != nil {
-		return e
-	}
-	//注册触发器
+// ruleTriggers 单个场景联动的触发器
+type ruleTriggers struct {
+	schedule   *cron.Cron
+	conditions []model.DevicePointCondition
+	events     []model.Trigger
+	//场景及动作中表达式条件的编译结果
+	expressions map[string]*expression.Expression
+}
+
+// buildTriggers 解析场景联动的触发器，解析失败时不影响已生效的规则
+func (s *export) buildTriggers(m model.Config) (ruleTriggers, error) {
+	var t ruleTriggers
 	for _, trigger := range m.Trigger {
 		switch trigger.Type {
 		case model.TriggerTypeSchedule:
-			schedule, exists := s.schedules[m.ID]
-			if !exists {
-				schedule = cron.New()
-				s.schedules[m.ID] = schedule
-				schedule.Start()
+			if t.schedule == nil {
+				t.schedule = cron.New()
 			}
-			_, e = schedule.AddFunc(trigger.Cron, func() {
-				s.Trigger(m.ID)
-			})
-			if e != nil {
-				return e
-			} else {
-				driverbox.Log().Info(fmt.Sprintf("add schedule trigger:%v", trigger.Cron))
+			source := model.TriggerSource{Type: model.SourceTypeSchedule, Cron: trigger.Cron}
+			if _, e := t.schedule.AddFunc(trigger.Cron, func() {
+				s.trigger(m.ID, 0, source)
+			}); e != nil {
+				return t, e
 			}
-			break
+			driverbox.Log().Info(fmt.Sprintf("add schedule trigger:%v", trigger.Cron))
 		case model.TriggerTypeDevicePoint:
 			//注册eKuiper监听设备点位状态
 			if len(trigger.DeviceID) == 0 || len(trigger.DevicePoint) == 0 || len(trigger.Condition) == 0 || len(trigger.Value) == 0 {
 				bs, _ := json.Marshal(trigger.DevicePointTrigger)
-				return errors.New("invalid trigger:" + string(bs))
+				return t, errors.New("invalid trigger:" + string(bs))
 			}
-
-			//定义触发器
-			triggers, _ := s.triggerConditions[id]
-			triggers = append(triggers, trigger.DevicePointCondition)
-			s.triggerConditions[id] = triggers
-			break
+			t.conditions = append(t.conditions, trigger.DevicePointCondition)
 		case model.TriggerTypeDeviceEvent:
-			break
+			if len(trigger.Event) == 0 {
+				bs, _ := json.Marshal(trigger.DeviceEventTrigger)
+				return t, errors.New("invalid trigger:" + string(bs))
+			}
+			t.events = append(t.events, trigger)
 		default:
 			bs, _ := json.Marshal(trigger)
 			driverbox.Log().Error(fmt.Sprintf("unsupport trigger type:%s", string(bs)))
 		}
 	}
-	return nil
+	//预编译场景及动作中的表达式条件
+	conditions := append([]model.Condition{}, m.Condition...)
+	for _, action := range m.Action {
+		conditions = append(conditions, action.Condition...)
+	}
+	for _, condition := range conditions {
+		if condition.Type != model.ConditionTypeExpression {
+			continue
+		}
+		if _, ok := t.expressions[condition.Expression]; ok {
+			continue
+		}
+		e, err := expression.Compile(condition.Expression)
+		if err != nil {
+			return t, err
+		}
+		if t.expressions == nil {
+			t.expressions = make(map[string]*expression.Expression)
+		}
+		t.expressions[condition.Expression] = e
+	}
+	return t, nil
+}
+
+// applyTriggers 替换场景联动的配置缓存及触发器，调用方需持有写锁
+func (s *export) applyTriggers(m model.Config, t ruleTriggers) {
+	s.removeTriggers(m.ID)
+	s.configs[m.ID] = m
+	if len(t.conditions) > 0 {
+		s.triggerConditions[m.ID] = t.conditions
+	}
+	if len(t.events) > 0 {
+		s.eventTriggers[m.ID] = t.events
+	}
+	if len(t.expressions) > 0 {
+		s.expressions[m.ID] = t.expressions
+	}
+	if t.schedule != nil {
+		s.schedules[m.ID] = t.schedule
+		t.schedule.Start()
+	}
+}
+
+// removeTriggers 清理场景联动的配置缓存及触发器，调用方需持有写锁
+func (s *export) removeTriggers(id string) {
+	delete(s.configs, id)
+	delete(s.triggerConditions, id)
+	delete(s.eventTriggers, id)
+	delete(s.expressions, id)
+	// 清理当前场景ID的所有时间表触发器
+	if task, exists := s.schedules[id]; exists {
+		task.Stop()
+		delete(s.schedules, id)
+	}
+}
+
+// saveConfig 持久化场景联动配置，先写临时文件再替换，避免写入中断导致原配置丢失
+func (s *export) saveConfig(model model.Config) error {
+	bytes, err := json.Marshal(model)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(s.ConfigPath, os.ModePerm); err != nil {
+		return err
+	}
+	// fix: 偶现写入的文件内容为空
+	file := path.Join(s.ConfigPath, model.ID+".json")
+	f, err := os.Create(file + ".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(bytes); err == nil {
+		err = f.Sync()
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), file)
 }
 
 // Delete 删除场景联动规则
@@ -133,44 +219,63 @@ func (s *export) Delete(id string) error {
 	}
 	driverbox.Log().Info(fmt.Sprintf("delete linkEdge:%v", id))
 
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 	//删除配置
-	delete(s.configs, id)
-	delete(s.triggerConditions, id)
+	s.removeTriggers(id)
 	file := path.Join(s.ConfigPath, id+".json")
-	e := os.Remove(file)
-	if e != nil {
-		return e
-	}
-
-	// 清理当前场景ID的所有时间表触发器
-	driverbox.Log().Info(fmt.Sprintf("clear schedule trigger..."))
-	task, exists := s.schedules[id]
-	if exists {
-		task.Stop()
-		delete(s.schedules, id)
-	}
-	return nil
+	return os.Remove(file)
 }
 
 // Update UpdateLinkEdgeStatus 调整联动规则状态,用于启停控制
+// 新配置校验及触发器解析通过后再替换，失败时原规则保持不变
 func (s *export) Update(model model.Config) error {
 	// action 为空校验
 	if len(model.Action) <= 0 {
 		return ErrActionListIsEmpty
 	}
-	e := s.Delete(model.ID)
+	if e := model.Validate(); e != nil {
+		return e
+	}
+	triggers, e := s.buildTriggers(model)
 	if e != nil {
 		return e
 	}
-	return s.Create(model)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if e = s.saveConfig(model); e != nil {
+		return e
+	}
+	s.applyTriggers(model, triggers)
+	return nil
+}
+
+// Enable 启用/禁用场景联动
+func (s *export) Enable(id string, enable bool) error {
+	config, e := s.Get(id)
+	if e != nil {
+		return e
+	}
+	if config.Enable == enable {
+		return nil
+	}
+	config.Enable = enable
+	return s.Update(config)
 }
 
-// TriggerLinkEdge 触发场景联动规则
+// TriggerLinkEdge 手动触发场景联动规则
 // id: 场景联动ID
-// source: 场景触发来源
 func (s *export) Trigger(id string) error {
+	return s.trigger(id, 0, model.TriggerSource{Type: model.SourceTypeManual})
+}
+
+// trigger 触发场景联动规则
+// id: 场景联动ID
+// depth: 联动深度，由场景联动动作发布的事件触发时大于 0
+// source: 场景触发来源
+func (s *export) trigger(id string, depth int, source model.TriggerSource) error {
 	//记录场景执行记录
-	e := s.triggerLinkEdge(id, 0)
+	e := s.triggerLinkEdge(id, depth, source)
 	if e != nil {
 		driverbox.Log().Error(fmt.Sprintf("linkEdge:%s trigger", e.Error()))
 		return e
@@ -179,17 +284,35 @@ func (s *export) Trigger(id string) error {
 	config, e := s.Get(id)
 	if e == nil {
 		config.ExecuteTime = time.Now()
-		s.configs[id] = config
+		s.mutex.Lock()
+		//执行期间规则可能已被删除
+		if _, ok := s.configs[id]; ok {
+			s.configs[id] = config
+		}
+		s.mutex.Unlock()
 	}
 	return e
 }
 
 func (s *export) Execute(config model.Config) error {
-	return s.triggerLinkEdge("", 0, config)
+	return s.triggerLinkEdge("", 0, model.TriggerSource{Type: model.SourceTypeManual}, config)
 }
 
 // depth:联动深度
-func (s *export) triggerLinkEdge(id string, depth int, conf ...model.Config) error {
+func (s *export) triggerLinkEdge(id string, depth int, source model.TriggerSource, conf ...model.Config) (err error) {
+	record := &model.ExecuteRecord{
+		LinkEdgeID: id,
+		Source:     source,
+		Conditions: make([]model.ConditionResult, 0),
+		Actions:    make([]model.ActionResult, 0),
+		StartTime:  time.Now(),
+	}
+	//预览情况下未持久化场景联动，id为空
+	if id != "" {
+		defer func() {
+			s.finishRecord(record, err)
+		}()
+	}
 	if depth > 10 {
 		return errors.New("execute level is too deep, max deep:" + strconv.Itoa(depth))
 	}
@@ -208,30 +331,40 @@ func (s *export) triggerLinkEdge(id string, depth int, conf ...model.Config) err
 	}
 
 	if !config.Enable {
-		return errors.New("linkEdge is disable now")
+		return errLinkEdgeDisabled
 	}
 	//静默期判断
 	if config.SilentPeriod > 0 {
 		//缓存场景联动执行时间
 		if time.Now().Add(-time.Duration(config.SilentPeriod) * time.Second).Before(config.ExecuteTime) {
-			return errors.New("execute frequency is too high")
+			return skipError{errors.New("execute frequency is too high")}
 		}
 	}
 	//校验执行条件
-	e = s.checkConditions(config.Condition)
+	e = s.checkConditions(id, config.Condition, &record.Conditions)
 	if e != nil {
-		return errors.New("check condition error: " + e.Error())
+		return skipError{errors.New("check condition error: " + e.Error())}
 	}
 
 	//组合相同设备的点位action
 	actions := make(map[string][]plugin.PointData)
-	sucCount := 0
+	//设备点位动作在 record.Actions 中的下标，待设备写入完成后回填结果
+	deviceActions := make(map[string][]int)
 	//执行动作
-	for _, action := range config.Action {
+	for i, action := range config.Action {
+		result := model.ActionResult{
+			Index:     i,
+			Type:      action.Type,
+			Status:    model.ActionStatusSuccess,
+			StartTime: time.Now(),
+		}
 		//判断执行动作是否匹配条件
-		e = s.checkConditions(action.Condition)
+		e = s.checkConditions(id, action.Condition, &result.Conditions)
 		if e != nil {
-			sucCount++
+			result.Status = model.ActionStatusSkipped
+			result.Error = e.Error()
+			result.EndTime = time.Now()
+			record.Actions = append(record.Actions, result)
 			continue
 		}
 
@@ -260,35 +393,54 @@ func (s *export) triggerLinkEdge(id string, depth int, conf ...model.Config) err
 					})
 				}
 			}
+			deviceActions[deviceID] = append(deviceActions[deviceID], len(record.Actions))
 		case model.ActionTypeLinkEdge:
-			sucCount++
-			go s.triggerLinkEdge(action.ID, depth+1)
+			go s.triggerLinkEdge(action.ID, depth+1, model.TriggerSource{Type: model.SourceTypeLinkEdge, LinkEdgeID: id})
+		case model.ActionTypeHttp, model.ActionTypeEvent, model.ActionTypeScript, model.ActionTypeVirtualPoint:
+			s.executeAction(action, actionContext{
+				ID:     id,
+				Name:   config.Name,
+				Source: source,
+				Time:   time.Now(),
+				depth:  depth,
+			}, &result)
 		default:
 			bytes, _ := json.Marshal(action)
 			driverbox.Log().Error(fmt.Sprintf("unsupport action:%s", string(bytes)))
+			result.Status = model.ActionStatusFail
+			result.Error = "unsupported action type: " + string(action.Type)
 		}
 
 		//场景执行后休眠指定时长
 		if len(action.Sleep) > 0 {
 			d, err := time.ParseDuration(action.Sleep)
 			if err == nil {
+				sleepAt := time.Now()
 				time.Sleep(d)
+				result.Sleep = time.Since(sleepAt).Milliseconds()
 			}
 		}
+		result.EndTime = time.Now()
+		record.Actions = append(record.Actions, result)
 	}
 	//遍历执行actions,按连接分组
 	connectGroup := make(map[string]map[string][]plugin.PointData)
+	//设备写入结果
+	deviceErrors := make(map[string]error)
+	var mutex sync.Mutex
 	for deviceId, points := range actions {
 		// 跳过未知设备
 		if !driverbox.Shadow().HasDevice(deviceId) {
 			// 事件信息：场景ID、设备ID
 			driverbox.Log().Error("unknown device", zap.String("deviceId", deviceId), zap.String("linkEdge", id))
 			//driverbox.TriggerEvents(event.UnknownDevice, id, deviceId)
+			deviceErrors[deviceId] = errors.New("unknown device")
 			continue
 		}
 		device, ok := driverbox.CoreCache().GetDevice(deviceId)
 		if !ok {
 			driverbox.Log().Error("get device error", zap.String("deviceId", deviceId))
+			deviceErrors[deviceId] = errors.New("get device error")
 			continue
 		}
 		group, ok := connectGroup[device.ConnectionKey]
@@ -308,36 +460,97 @@ func (s *export) triggerLinkEdge(id string, depth int, conf ...model.Config) err
 				device, ok := driverbox.Shadow().GetDevice(deviceId)
 				if !ok || !device.Online {
 					driverbox.Log().Error("device offline, skip action", zap.String("deviceId", deviceId), zap.String("linkedge", id))
+					mutex.Lock()
+					deviceErrors[deviceId] = errors.New("device offline")
+					mutex.Unlock()
 					continue
 				}
 				err := driverbox.WritePoints(deviceId, points)
 				if err != nil {
 					driverbox.Log().Error("execute linkEdge error", zap.String("linkEdge", id),
 						zap.String("deviceId", deviceId), zap.Any("points", points), zap.Error(err))
+					mutex.Lock()
+					deviceErrors[deviceId] = err
+					mutex.Unlock()
 				} else {
-					sucCount = sucCount + len(points)
 					driverbox.Log().Info(fmt.Sprintf("execute linkEdge:%s action", id))
 				}
 			}
 		}(devices)
 	}
 	wg.Wait()
-	//预览情况下未持久化场景联动，id为空
-	if id != "" {
-		// value:全部成功\部分成功\全部失败
-		if sucCount == len(config.Action) {
-			driverbox.TriggerEvents(EVT_TRIGGER, id, ExecuteResultAllSuccess)
-		} else if sucCount == 0 {
-			driverbox.TriggerEvents(EVT_TRIGGER, id, ExecuteResultAllFail)
-		} else {
-			driverbox.TriggerEvents(EVT_TRIGGER, id, ExecuteResultPartSuccess)
+	//回填设备点位动作执行结果
+	for deviceId, indexes := range deviceActions {
+		e, ok := deviceErrors[deviceId]
+		for _, i := range indexes {
+			record.Actions[i].EndTime = time.Now()
+			if ok {
+				record.Actions[i].Status = model.ActionStatusFail
+				record.Actions[i].Error = e.Error()
+			}
 		}
 	}
+	// value:全部成功\部分成功\全部失败
+	sucCount := 0
+	for _, result := range record.Actions {
+		if result.Status != model.ActionStatusFail {
+			sucCount++
+		}
+	}
+	record.Result = ExecuteResultPartSuccess
+	if sucCount == len(record.Actions) {
+		record.Result = ExecuteResultAllSuccess
+	} else if sucCount == 0 {
+		record.Result = ExecuteResultAllFail
+	}
+	if id != "" {
+		driverbox.TriggerEvents(EVT_TRIGGER, id, record.Result)
+	}
 
 	return nil
 }
 
-func (s *export) checkConditions(conditions []model.Condition) error {
+// finishRecord 完成场景联动执行记录，缓存执行结果并持久化
+// 未启用的场景不产生记录；跳过执行的场景仅保存执行记录，不计入执行次数，也不覆盖最近一次执行结果
+func (s *export) finishRecord(record *model.ExecuteRecord, err error) {
+	if errors.Is(err, errLinkEdgeDisabled) {
+		return
+	}
+	record.EndTime = time.Now()
+	var skip skipError
+	if errors.As(err, &skip) {
+		record.Result = ExecuteResultSkipped
+		record.Error = err.Error()
+	} else {
+		if err != nil {
+			record.Result = ExecuteResultAllFail
+			record.Error = err.Error()
+		}
+		s.setExecuteResult(record.LinkEdgeID, record.Result, err)
+		metrics.LinkEdgeExecutions.Inc(record.LinkEdgeID, record.Result)
+	}
+	s.recordsMutex.RLock()
+	defer s.recordsMutex.RUnlock()
+	if s.records == nil {
+		return
+	}
+	s.records.save(record)
+}
+
+// GetRecords 查询场景联动执行记录
+// id: 场景联动ID，为空时查询全部场景
+// start、end: 执行开始时间范围，零值表示不限制
+// limit: 最多返回条数，<=0 表示不限制
+func (s *export) GetRecords(id string, start, end time.Time, limit int) ([]model.ExecuteRecord, error) {
+	s.recordsMutex.RLock()
+	defer s.recordsMutex.RUnlock()
+	if s.records == nil {
+		return nil, errRecordDisabled
+	}
+	return s.records.query(id, start, end, limit)
+}
+
+func (s *export) checkConditions(id string, conditions []model.Condition, results *[]model.ConditionResult) error {
 	//优先执行点位持续时间条件校验
 	err := s.checkListTimeCondition(conditions)
 	if err != nil {
@@ -349,80 +562,148 @@ func (s *export) checkConditions(conditions []model.Condition) error {
 		if condition.Type == model.ConditionTypeLastTime {
 			continue
 		}
-		switch condition.Type {
-		case model.ConditionTypeDevicePoint:
-			//注册eKuiper监听设备点位状态
-			if len(condition.DeviceID) == 0 || len(condition.DevicePoint) == 0 || len(condition.Condition) == 0 || len(condition.Value) == 0 {
-				bytes, _ := json.Marshal(condition.DevicePointCondition)
-				return errors.New("invalid trigger:" + string(bytes))
-			}
-			pointValue, err := driverbox.Shadow().GetDevicePoint(condition.DeviceID, condition.DevicePoint)
+		err = s.checkCondition(id, condition, now)
+		//记录条件校验结果
+		if results != nil {
+			result := model.ConditionResult{Condition: condition, Passed: err == nil}
 			if err != nil {
-				return fmt.Errorf("get device:%v point:%v value error:%v", condition.DeviceID, condition.DevicePoint, err)
-			}
-			//driverbox.Log().Info(fmt.Sprintf("point value:%s", point))
-			err = s.checkConditionValue(condition.DevicePointCondition, pointValue)
-			if err != nil {
-				return err
-			}
-		case model.ConditionTypeExecuteTime:
-			if condition.Begin > now {
-				return errors.New("execution time has not started")
-			}
-			if condition.End < now {
-				return errors.New("execution time has expired")
-			}
-		case model.ConditionTypeDateInterval:
-			if condition.BeginDate == "" || condition.EndDate == "" {
-				return nil
+				result.Error = err.Error()
 			}
+			*results = append(*results, result)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-			begin, err := s.parseDate(condition.BeginDate)
-			if err != nil {
-				return errors.New("execution begin date parse error")
-			}
-			end, err := s.parseDate(condition.EndDate)
-			if err != nil {
-				return errors.New("execution end date parse error")
-			}
+// checkCondition 校验单个执行条件
+func (s *export) checkCondition(id string, condition model.Condition, now int64) error {
+	switch condition.Type {
+	case model.ConditionTypeDevicePoint:
+		//注册eKuiper监听设备点位状态
+		if len(condition.DeviceID) == 0 || len(condition.DevicePoint) == 0 || len(condition.Condition) == 0 || len(condition.Value) == 0 {
+			bytes, _ := json.Marshal(condition.DevicePointCondition)
+			return errors.New("invalid trigger:" + string(bytes))
+		}
+		pointValue, err := driverbox.Shadow().GetDevicePoint(condition.DeviceID, condition.DevicePoint)
+		if err != nil {
+			return fmt.Errorf("get device:%v point:%v value error:%v", condition.DeviceID, condition.DevicePoint, err)
+		}
+		//driverbox.Log().Info(fmt.Sprintf("point value:%s", point))
+		err = s.checkConditionValue(condition.DevicePointCondition, pointValue)
+		if err != nil {
+			return err
+		}
+	case model.ConditionTypeExecuteTime:
+		if condition.Begin > now {
+			return errors.New("execution time has not started")
+		}
+		if condition.End < now {
+			return errors.New("execution time has expired")
+		}
+	case model.ConditionTypeDateInterval:
+		if condition.BeginDate == "" || condition.EndDate == "" {
+			return nil
+		}
 
-			yearDay := time.Now().YearDay()
-			if end.After(begin) {
-				if yearDay >= begin.YearDay() && yearDay <= end.YearDay() {
-					return nil
-				}
-			} else {
-				if (yearDay >= 1 && yearDay <= end.YearDay()) || (yearDay >= begin.YearDay() && yearDay <= 366) {
-					return nil
-				}
-			}
+		begin, err := s.parseDate(condition.BeginDate)
+		if err != nil {
+			return errors.New("execution begin date parse error")
+		}
+		end, err := s.parseDate(condition.EndDate)
+		if err != nil {
+			return errors.New("execution end date parse error")
+		}
 
-			return errors.New("execution time is not yet available")
-		case model.ConditionTypeYears:
-			if !condition.YearsCondition.Verify(time.Now().Year()) {
-				return errors.New("mismatch years condition")
-			}
-		case model.ConditionTypeMonths:
-			if !condition.MonthsCondition.Verify(int(time.Now().Month())) {
-				return errors.New("mismatch months condition")
-			}
-		case model.ConditionTypeDays:
-			if !condition.DaysCondition.Verify(time.Now().Day()) {
-				return errors.New("mismatch days condition")
-			}
-		case model.ConditionTypeWeeks:
-			if !condition.WeeksCondition.Verify(int(time.Now().Weekday())) {
-				return errors.New("mismatch weeks condition")
+		yearDay := time.Now().YearDay()
+		if end.After(begin) {
+			if yearDay >= begin.YearDay() && yearDay <= end.YearDay() {
+				return nil
 			}
-		case model.ConditionTypeTimes:
-			if !condition.TimesCondition.Verify(time.Now()) {
-				return errors.New("mismatch times condition")
+		} else {
+			if (yearDay >= 1 && yearDay <= end.YearDay()) || (yearDay >= begin.YearDay() && yearDay <= 366) {
+				return nil
 			}
 		}
+
+		return errors.New("execution time is not yet available")
+	case model.ConditionTypeYears:
+		if !condition.YearsCondition.Verify(time.Now().Year()) {
+			return errors.New("mismatch years condition")
+		}
+	case model.ConditionTypeMonths:
+		if !condition.MonthsCondition.Verify(int(time.Now().Month())) {
+			return errors.New("mismatch months condition")
+		}
+	case model.ConditionTypeDays:
+		if !condition.DaysCondition.Verify(time.Now().Day()) {
+			return errors.New("mismatch days condition")
+		}
+	case model.ConditionTypeWeeks:
+		if !condition.WeeksCondition.Verify(int(time.Now().Weekday())) {
+			return errors.New("mismatch weeks condition")
+		}
+	case model.ConditionTypeTimes:
+		if !condition.TimesCondition.Verify(time.Now()) {
+			return errors.New("mismatch times condition")
+		}
+	case model.ConditionTypeExpression:
+		e, err := s.compiledExpression(id, condition.Expression)
+		if err != nil {
+			return err
+		}
+		ok, err := e.Bool(s.resolveExpressionVariable)
+		if err != nil {
+			return fmt.Errorf("evaluate expression %s error: %v", condition.Expression, err)
+		}
+		if !ok {
+			return fmt.Errorf("mismatch expression condition: %s", condition.Expression)
+		}
 	}
 	return nil
 }
 
+// compiledExpression 获取场景联动注册时编译的表达式，预览等未注册的场景即时编译
+func (s *export) compiledExpression(id, src string) (*expression.Expression, error) {
+	s.mutex.RLock()
+	e, ok := s.expressions[id][src]
+	s.mutex.RUnlock()
+	if ok {
+		return e, nil
+	}
+	return expression.Compile(src)
+}
+
+// resolveExpressionVariable 解析表达式中的设备点位变量
+// devSn.point 返回点位影子值，devSn.point.updatedAt 返回点位更新时间（毫秒时间戳）
+func (s *export) resolveExpressionVariable(path []string) (interface{}, error) {
+	name := strings.Join(path, ".")
+	switch {
+	case len(path) == 2:
+		value, err := driverbox.Shadow().GetDevicePoint(path[0], path[1])
+		if err != nil {
+			return nil, fmt.Errorf("get %s error: %v", name, err)
+		}
+		if value == nil {
+			return nil, fmt.Errorf("%s value is unavailable", name)
+		}
+		return value, nil
+	case len(path) == 3 && path[2] == model.ExpressionFieldUpdatedAt:
+		point, err := driverbox.Shadow().GetDevicePointDetails(path[0], path[1])
+		if err != nil {
+			return nil, fmt.Errorf("get %s error: %v", name, err)
+		}
+		if point.UpdatedAt.IsZero() {
+			return nil, fmt.Errorf("%s is unavailable", name)
+		}
+		return point.UpdatedAt.UnixMilli(), nil
+	default:
+		return nil, fmt.Errorf("unknown variable %s", name)
+	}
+}
+
 func (s *export) checkListTimeCondition(conditions []model.Condition) error {
 	//return errors.New("功能未迁移...")
 	return nil
@@ -447,6 +728,24 @@ func (s *export) checkConditionValue(condition model.DevicePointCondition, point
 			return fmt.Errorf("unSupport condition type:%v for string point:%v ,value:%v", condition.Condition, condition.DevicePoint, pointValue)
 		}
 		return nil
+	case bool:
+		conditionValue, e1 := convutil.Bool(condition.Value)
+		if e1 != nil {
+			return e1
+		}
+		switch condition.Condition {
+		case model.ConditionEq:
+			if conditionValue != pointValue {
+				return e
+			}
+		case model.ConditionNe:
+			if conditionValue == pointValue {
+				return e
+			}
+		default:
+			return fmt.Errorf("unSupport condition type:%v for bool point:%v ,value:%v", condition.Condition, condition.DevicePoint, pointValue)
+		}
+		return nil
 	default:
 		pointValue, e1 := strconv.ParseFloat(fmt.Sprintf("%v", pointValue), 32)
 		if e1 != nil {
@@ -496,7 +795,9 @@ func (s *export) checkConditionValue(condition model.DevicePointCondition, point
 }
 
 func (s *export) Get(id string) (model.Config, error) {
+	s.mutex.RLock()
 	config, exists := s.configs[id]
+	s.mutex.RUnlock()
 	if exists {
 		return config, nil
 	}
@@ -513,9 +814,13 @@ func (s *export) Get(id string) (model.Config, error) {
 	if err != nil {
 		return config, err
 	}
-	err = json.Unmarshal(body, &config)
+	if err = json.Unmarshal(body, &config); err != nil {
+		return config, err
+	}
+	s.mutex.Lock()
 	s.configs[id] = config
-	return config, err
+	s.mutex.Unlock()
+	return config, nil
 }
 
 func (s *export) GetList(tag ...string) ([]model.Config, error) {
@@ -533,7 +838,8 @@ func (s *export) GetList(tag ...string) ([]model.Config, error) {
 		}
 	}
 	filepath.Walk(s.ConfigPath, func(path string, d fs.FileInfo, err error) error {
-		if !d.IsDir() {
+		//忽略未完成写入的临时文件
+		if !d.IsDir() && strings.HasSuffix(d.Name(), ".json") {
 			files = append(files, d.Name())
 		}
 		return nil
@@ -580,6 +886,28 @@ func (s *export) GetLast() (c model.Config, err error) {
 	return
 }
 
+// GetExecuteResult 获取场景联动最近一次执行结果
+func (s *export) GetExecuteResult(id string) (model.ExecuteResult, bool) {
+	s.resultMutex.RLock()
+	defer s.resultMutex.RUnlock()
+	result, ok := s.executeResults[id]
+	return result, ok
+}
+
+// setExecuteResult 记录场景联动执行结果
+func (s *export) setExecuteResult(id string, result string, err error) {
+	r := model.ExecuteResult{
+		Result:      result,
+		ExecuteTime: time.Now(),
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	s.resultMutex.Lock()
+	defer s.resultMutex.Unlock()
+	s.executeResults[id] = r
+}
+
 // parseDate 解析日期
 // 修复：02-29 问题
 func (s *export) parseDate(d string) (time.Time, error) {
@@ -594,10 +922,13 @@ func (s *export) parseDate(d string) (time.Time, error) {
 // 提示：不真实创建场景，仅看执行效果使用
 func (s *export) Preview(config model.Config) error {
 	// 记录场景执行记录
-	return s.triggerLinkEdge("", 0, config)
+	return s.triggerLinkEdge("", 0, model.TriggerSource{Type: model.SourceTypeManual}, config)
 }
 
 func (s *export) devicePointTriggerHandler(deviceData plugin.DeviceData, handleDuration bool) {
+	//持续时间条件会回写触发器状态
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 	// 循环点位
 	for _, pointData := range deviceData.Values {
 		// 循环场景
@@ -651,9 +982,15 @@ func (s *export) devicePointTriggerHandler(deviceData plugin.DeviceData, handleD
 				}
 
 				// 触发场景
+				source := model.TriggerSource{
+					Type:        model.SourceTypeDevicePoint,
+					DeviceID:    deviceData.ID,
+					DevicePoint: pointData.PointName,
+					Value:       pointData.Value,
+				}
 				go func(linkEdgeId string) {
 					driverbox.Log().Info("trigger linkEdge", zap.String("id", linkEdgeId))
-					e := s.Trigger(linkEdgeId)
+					e := s.trigger(linkEdgeId, 0, source)
 					if e != nil {
 						driverbox.Log().Error("trigger linkEdge error", zap.String("id", linkEdgeId), zap.Error(e))
 					}
@@ -663,3 +1000,52 @@ func (s *export) devicePointTriggerHandler(deviceData plugin.DeviceData, handleD
 		}
 	}
 }
+
+// deviceEventTriggerHandler 设备事件触发场景联动
+func (s *export) deviceEventTriggerHandler(code event.EventCode, deviceID string, value interface{}) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if len(s.eventTriggers) == 0 {
+		return
+	}
+	// 设备删除事件在缓存更新前且未持有缓存锁时通知，依旧可以查询设备信息
+	var modelName string
+	for _, triggers := range s.eventTriggers {
+		for _, trigger := range triggers {
+			if trigger.Event != code || len(trigger.ModelName) == 0 || len(modelName) > 0 {
+				continue
+			}
+			if device, ok := driverbox.CoreCache().GetDevice(deviceID); ok {
+				modelName = device.ModelName
+			}
+		}
+	}
+	// 场景联动动作发布的事件，不再触发发布该事件的场景
+	origins, depth := s.emittedBy(code, deviceID)
+	for sceneID, triggers := range s.eventTriggers {
+		for _, trigger := range triggers {
+			if !trigger.Match(code, deviceID, modelName, value) {
+				continue
+			}
+			if origins[sceneID] {
+				driverbox.Log().Debug("ignore event emitted by linkEdge itself", zap.String("id", sceneID), zap.String("event", string(code)))
+				break
+			}
+			source := model.TriggerSource{
+				Type:     model.SourceTypeDeviceEvent,
+				DeviceID: deviceID,
+				Event:    string(code),
+				Value:    value,
+			}
+			go func(linkEdgeId string) {
+				driverbox.Log().Info("trigger linkEdge", zap.String("id", linkEdgeId), zap.String("event", string(code)))
+				e := s.trigger(linkEdgeId, depth, source)
+				if e != nil {
+					driverbox.Log().Error("trigger linkEdge error", zap.String("id", linkEdgeId), zap.Error(e))
+				}
+			}(sceneID)
+			// 同一场景仅触发一次
+			break
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package linkedge
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ibuilding-x/driver-box/v2/driverbox"
+	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
+	"github.com/ibuilding-x/driver-box/v2/exports/linkedge/model"
+	export0 "github.com/ibuilding-x/driver-box/v2/internal/export"
+	"github.com/ibuilding-x/driver-box/v2/internal/logger"
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+	"github.com/ibuilding-x/driver-box/v2/pkg/expression"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+func newTestExport(t *testing.T) *export {
+	logger.Logger = zap.NewNop()
+	t.Setenv(config.EXPORT_LINKEDGE_RECORD_PATH, t.TempDir())
+	records, err := newRecordStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &export{
+		ConfigPath:        t.TempDir(),
+		configs:           make(map[string]model.Config),
+		schedules:         make(map[string]*cron.Cron),
+		triggerConditions: make(map[string][]model.DevicePointCondition),
+		eventTriggers:     make(map[string][]model.Trigger),
+		expressions:       make(map[string]map[string]*expression.Expression),
+		executeResults:    make(map[string]model.ExecuteResult),
+		records:           records,
+	}
+	t.Cleanup(func() {
+		_ = s.Destroy()
+	})
+	return s
+}
+
+// newHttpConfig 以 HTTP 动作的响应状态码控制执行结果
+func newHttpConfig(id string, status int) (model.Config, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	action := model.Action{Type: model.ActionTypeHttp}
+	action.URL = server.URL
+	return model.Config{ID: id, Enable: true, Action: []model.Action{action}}, server.Close
+}
+
+func lastRecord(t *testing.T, s *export, id string) model.ExecuteRecord {
+	records, err := s.GetRecords(id, time.Time{}, time.Time{}, 1)
+	if err != nil || len(records) == 0 {
+		t.Fatalf("records = %v, err = %v", records, err)
+	}
+	return records[0]
+}
+
+func TestCreateUpdate(t *testing.T) {
+	s := newTestExport(t)
+	c, stop := newHttpConfig("rule", http.StatusOK)
+	defer stop()
+	c.Trigger = []model.Trigger{{Type: model.TriggerTypeSchedule, ScheduleTrigger: model.ScheduleTrigger{Cron: "0 * * * *"}}}
+	if err := s.Create(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Create(c); err == nil {
+		t.Fatal("duplicate id should be rejected")
+	}
+
+	//更新失败时原规则保持不变
+	invalid := c
+	invalid.Name = "invalid"
+	invalid.Trigger = []model.Trigger{{Type: model.TriggerTypeSchedule, ScheduleTrigger: model.ScheduleTrigger{Cron: "* *"}}}
+	if err := s.Update(invalid); err == nil {
+		t.Fatal("invalid cron should be rejected")
+	}
+	if got, err := s.Get(c.ID); err != nil || got.Name != c.Name {
+		t.Fatalf("get = %v, err = %v", got, err)
+	}
+	if _, ok := s.schedules[c.ID]; !ok {
+		t.Fatal("schedule trigger lost after failed update")
+	}
+
+	//触发器类型变更后旧触发器被清理
+	c.Trigger = []model.Trigger{{Type: model.TriggerTypeDevicePoint, DevicePointTrigger: model.DevicePointTrigger{
+		DevicePointCondition: model.DevicePointCondition{DeviceID: "d1", DevicePoint: "p1", Condition: model.ConditionEq, Value: "1"},
+	}}}
+	if err := s.Update(c); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.schedules[c.ID]; ok {
+		t.Fatal("schedule trigger should be removed")
+	}
+	if len(s.triggerConditions[c.ID]) != 1 {
+		t.Fatalf("trigger conditions = %v", s.triggerConditions[c.ID])
+	}
+	list, err := s.GetList()
+	if err != nil || len(list) != 1 {
+		t.Fatalf("list = %v, err = %v", list, err)
+	}
+
+	if err = s.Delete(c.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Get(c.ID); err == nil {
+		t.Fatal("rule should be deleted")
+	}
+}
+
+func TestExpressionCache(t *testing.T) {
+	s := newTestExport(t)
+	c, stop := newHttpConfig("rule", http.StatusOK)
+	defer stop()
+	condition := model.Condition{Type: model.ConditionTypeExpression}
+	condition.Expression = "1 > 2"
+	c.Condition = []model.Condition{condition}
+	if err := s.Create(c); err != nil {
+		t.Fatal(err)
+	}
+	//注册时编译，执行时复用
+	if _, ok := s.expressions[c.ID][condition.Expression]; !ok {
+		t.Fatal("expression should be compiled on create")
+	}
+	if err := s.Trigger(c.ID); err == nil || lastRecord(t, s, c.ID).Result != ExecuteResultSkipped {
+		t.Fatalf("mismatch expression should skip, err = %v", err)
+	}
+	if err := s.Delete(c.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.expressions[c.ID]; ok {
+		t.Fatal("compiled expression should be removed on delete")
+	}
+}
+
+func TestTriggerRecord(t *testing.T) {
+	s := newTestExport(t)
+	ok, stop := newHttpConfig("ok", http.StatusOK)
+	defer stop()
+	fail, stop2 := newHttpConfig("fail", http.StatusInternalServerError)
+	defer stop2()
+	for _, c := range []model.Config{ok, fail} {
+		if err := s.Create(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.Trigger(ok.ID); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := s.GetExecuteResult(ok.ID); result.Result != ExecuteResultAllSuccess {
+		t.Fatalf("result = %v", result)
+	}
+	if err := s.Trigger(fail.ID); err != nil {
+		t.Fatal(err)
+	}
+	failRecord := lastRecord(t, s, fail.ID)
+	if failRecord.Result != ExecuteResultAllFail || failRecord.Actions[0].Status != model.ActionStatusFail {
+		t.Fatalf("record = %+v", failRecord)
+	}
+
+	//不满足执行条件：记录为 skipped，不覆盖最近一次执行结果
+	ok.Condition = []model.Condition{{Type: model.ConditionTypeExecuteTime, ExecuteTimeCondition: model.ExecuteTimeCondition{
+		Begin: time.Now().Add(time.Hour).UnixMilli(),
+		End:   time.Now().Add(2 * time.Hour).UnixMilli(),
+	}}}
+	if err := s.Update(ok); err != nil {
+		t.Fatal(err)
+	}
+	var skip skipError
+	if err := s.Trigger(ok.ID); !errors.As(err, &skip) {
+		t.Fatalf("err = %v", err)
+	}
+	record := lastRecord(t, s, ok.ID)
+	if record.Result != ExecuteResultSkipped || len(record.Conditions) != 1 || record.Conditions[0].Passed {
+		t.Fatalf("record = %+v", record)
+	}
+	if result, _ := s.GetExecuteResult(ok.ID); result.Result != ExecuteResultAllSuccess {
+		t.Fatalf("result = %v", result)
+	}
+
+	//禁用的场景不产生记录
+	if err := s.Enable(fail.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Trigger(fail.ID); !errors.Is(err, errLinkEdgeDisabled) {
+		t.Fatalf("err = %v", err)
+	}
+	if got := lastRecord(t, s, fail.ID); got.ID != failRecord.ID {
+		t.Fatalf("disabled rule should not be recorded, got %+v", got)
+	}
+	if result, _ := s.GetExecuteResult(fail.ID); result.Result != ExecuteResultAllFail {
+		t.Fatalf("result = %v", result)
+	}
+}
+
+func TestDevicePointTrigger(t *testing.T) {
+	s := newTestExport(t)
+	c, stop := newHttpConfig("rule", http.StatusOK)
+	defer stop()
+	c.Trigger = []model.Trigger{{Type: model.TriggerTypeDevicePoint, DevicePointTrigger: model.DevicePointTrigger{
+		DevicePointCondition: model.DevicePointCondition{DeviceID: "d1", DevicePoint: "temp", Condition: model.ConditionGt, Value: "30"},
+	}}}
+	if err := s.Create(c); err != nil {
+		t.Fatal(err)
+	}
+
+	s.ExportTo(plugin.DeviceData{ID: "d1", Values: []plugin.PointData{{PointName: "temp", Value: 25}}})
+	s.ExportTo(plugin.DeviceData{ID: "d2", Values: []plugin.PointData{{PointName: "temp", Value: 35}}})
+	time.Sleep(100 * time.Millisecond)
+	if _, ok := s.GetExecuteResult(c.ID); ok {
+		t.Fatal("unmatched point should not trigger")
+	}
+
+	s.ExportTo(plugin.DeviceData{ID: "d1", Values: []plugin.PointData{{PointName: "temp", Value: 35}}})
+	//执行记录在执行结果之后保存
+	deadline := time.Now().Add(time.Second)
+	for {
+		if records, _ := s.GetRecords(c.ID, time.Time{}, time.Time{}, 1); len(records) > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("linkEdge not triggered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	record := lastRecord(t, s, c.ID)
+	if record.Source.Type != model.SourceTypeDevicePoint || record.Source.DeviceID != "d1" || record.Result != ExecuteResultAllSuccess {
+		t.Fatalf("record = %+v", record)
+	}
+}
+
+func TestEventActionLoop(t *testing.T) {
+	s := newTestExport(t)
+	s.ready = true
+	exports := export0.Exports
+	export0.Exports = append(exports[:0:0], s)
+	defer func() {
+		export0.Exports = exports
+	}()
+	trigger := model.Trigger{Type: model.TriggerTypeDeviceEvent, DeviceEventTrigger: model.DeviceEventTrigger{Event: "custom"}}
+	//场景 a 发布的事件可触发场景 b，但不会再次触发场景 a
+	emit := model.Action{Type: model.ActionTypeEvent}
+	emit.Event = "custom"
+	emit.Key = "d1"
+	a := model.Config{ID: "a", Enable: true, Trigger: []model.Trigger{trigger}, Action: []model.Action{emit}}
+	b, stop := newHttpConfig("b", http.StatusOK)
+	defer stop()
+	b.Trigger = []model.Trigger{trigger}
+	for _, c := range []model.Config{a, b} {
+		if err := s.Create(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	driverbox.TriggerEvents("custom", "d1", nil)
+	time.Sleep(300 * time.Millisecond)
+	for id, want := range map[string]int{"a": 1, "b": 2} {
+		records, err := s.GetRecords(id, time.Time{}, time.Time{}, 0)
+		if err != nil || len(records) != want {
+			t.Errorf("%s records = %d, want %d, err = %v", id, len(records), want, err)
+		}
+	}
+}
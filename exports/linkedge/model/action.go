@@ -1,5 +1,7 @@
 package model
 
+import "github.com/ibuilding-x/driver-box/v2/pkg/event"
+
 // ActionType 执行动作类型
 type ActionType string
 
@@ -8,6 +10,14 @@ const (
 	ActionTypeDevicePoint ActionType = "devicePoint"
 	// ActionTypeLinkEdge 执行类型：触发场景联动
 	ActionTypeLinkEdge ActionType = "linkEdge"
+	// ActionTypeHttp 执行类型：调用 HTTP Webhook
+	ActionTypeHttp ActionType = "http"
+	// ActionTypeEvent 执行类型：发布自定义事件
+	ActionTypeEvent ActionType = "event"
+	// ActionTypeScript 执行类型：执行 Lua 脚本
+	ActionTypeScript ActionType = "script"
+	// ActionTypeVirtualPoint 执行类型：设置虚拟点位，仅更新设备影子
+	ActionTypeVirtualPoint ActionType = "virtualPoint"
 )
 
 type Action struct {
@@ -16,8 +26,15 @@ type Action struct {
 	Condition []Condition `json:"condition"`
 	// Sleep 执行后休眠时长
 	Sleep string `json:"sleep"`
+	// Retry 执行失败后的重试次数（http、event、script、virtualPoint 动作有效）
+	Retry int `json:"retry"`
+	// Timeout 单次执行超时时长，默认 10s（http、script 动作有效）
+	Timeout string `json:"timeout"`
 	DevicePointAction
 	SceneAction
+	HttpAction
+	EventAction
+	ScriptAction
 }
 
 // DevicePointAction 设备点位动作
@@ -44,3 +61,26 @@ type DevicePointActionItem struct {
 type SceneAction struct {
 	ID string `json:"id"`
 }
+
+// HttpAction HTTP Webhook 动作
+type HttpAction struct {
+	URL string `json:"url"`
+	// Method 请求方法，默认 POST
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
+	// Body 请求体模板，采用 text/template 语法
+	Body string `json:"body"`
+}
+
+// EventAction 发布事件动作
+type EventAction struct {
+	Event event.EventCode `json:"event"`
+	// Key 事件key，默认为场景联动ID
+	Key        string      `json:"key"`
+	EventValue interface{} `json:"eventValue"`
+}
+
+// ScriptAction Lua 脚本动作
+type ScriptAction struct {
+	Script string `json:"script"`
+}
@@ -37,6 +37,8 @@ const (
 	ConditionTypeWeeks ConditionType = "weeks"
 	// ConditionTypeTimes 执行条件-时间段（数组）
 	ConditionTypeTimes ConditionType = "times"
+	// ConditionTypeExpression 执行条件-多点位表达式
+	ConditionTypeExpression ConditionType = "expression"
 )
 
 // Condition 条件
@@ -51,6 +53,7 @@ type Condition struct {
 	DaysCondition
 	WeeksCondition
 	TimesCondition
+	ExpressionCondition
 }
 
 // DevicePointCondition 设备点位条件
@@ -117,3 +120,13 @@ type TimesCondition struct {
 	BeginTime string `json:"begin_time"`
 	EndTime   string `json:"end_time"`
 }
+
+// ExpressionFieldUpdatedAt 表达式中点位更新时间字段
+const ExpressionFieldUpdatedAt = "updatedAt"
+
+// ExpressionCondition 表达式条件
+// 示例：dev1.temp - dev2.temp > 3 && dev3.mode == "cool"
+// 变量格式为 设备ID.点位名称，追加 .updatedAt 可获取点位更新时间（毫秒时间戳）
+type ExpressionCondition struct {
+	Expression string `json:"expression"`
+}
@@ -34,3 +34,13 @@ func (c *Config) ExistTag(tag string) bool {
 
 	return false
 }
+
+// ExecuteResult 场景联动最近一次执行结果
+type ExecuteResult struct {
+	// Result 执行结果：success、partSuccess、fail
+	Result string `json:"result"`
+	// Error 执行失败原因
+	Error string `json:"error,omitempty"`
+	// ExecuteTime 执行时间
+	ExecuteTime time.Time `json:"executeTime"`
+}
@@ -0,0 +1,91 @@
+package model
+
+import "time"
+
+// SourceType 场景触发来源类型
+type SourceType string
+
+const (
+	// SourceTypeSchedule 定时触发
+	SourceTypeSchedule SourceType = "schedule"
+	// SourceTypeDevicePoint 设备点位触发
+	SourceTypeDevicePoint SourceType = "devicePoint"
+	// SourceTypeDeviceEvent 设备事件触发
+	SourceTypeDeviceEvent SourceType = "deviceEvent"
+	// SourceTypeManual 手动触发
+	SourceTypeManual SourceType = "manual"
+	// SourceTypeLinkEdge 由其他场景联动触发
+	SourceTypeLinkEdge SourceType = "linkEdge"
+)
+
+// ActionStatus 动作执行状态
+type ActionStatus string
+
+const (
+	// ActionStatusSuccess 执行成功
+	ActionStatusSuccess ActionStatus = "success"
+	// ActionStatusFail 执行失败
+	ActionStatusFail ActionStatus = "fail"
+	// ActionStatusSkipped 不满足动作执行条件，跳过
+	ActionStatusSkipped ActionStatus = "skipped"
+)
+
+// TriggerSource 场景触发来源
+type TriggerSource struct {
+	Type SourceType `json:"type"`
+	// Cron 定时触发的表达式
+	Cron string `json:"cron,omitempty"`
+	// DeviceID 触发设备
+	DeviceID string `json:"devSn,omitempty"`
+	// DevicePoint 触发点位
+	DevicePoint string `json:"point,omitempty"`
+	// Event 触发事件code
+	Event string `json:"event,omitempty"`
+	// Value 触发时的点位值或事件值
+	Value interface{} `json:"value,omitempty"`
+	// LinkEdgeID 上级场景联动ID
+	LinkEdgeID string `json:"linkEdgeId,omitempty"`
+}
+
+// ConditionResult 条件校验结果
+type ConditionResult struct {
+	Condition Condition `json:"condition"`
+	// Passed 是否满足条件
+	Passed bool `json:"passed"`
+	// Error 不满足条件的原因
+	Error string `json:"error,omitempty"`
+}
+
+// ActionResult 动作执行结果
+type ActionResult struct {
+	// Index 动作在配置中的序号
+	Index  int          `json:"index"`
+	Type   ActionType   `json:"type"`
+	Status ActionStatus `json:"status"`
+	Error  string       `json:"error,omitempty"`
+	// Attempts 实际执行次数，包含重试
+	Attempts int `json:"attempts,omitempty"`
+	// Output 动作输出，如 HTTP 响应内容
+	Output string `json:"output,omitempty"`
+	// Conditions 动作执行条件的校验结果
+	Conditions []ConditionResult `json:"conditions,omitempty"`
+	// Sleep 动作执行后实际休眠时长，单位：毫秒
+	Sleep     int64     `json:"sleep,omitempty"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+}
+
+// ExecuteRecord 场景联动执行记录
+type ExecuteRecord struct {
+	ID         int64         `json:"id"`
+	LinkEdgeID string        `json:"linkEdgeId"`
+	Source     TriggerSource `json:"source"`
+	// Result 执行结果：success、partSuccess、fail、skipped
+	Result string `json:"result"`
+	// Error 执行中断原因，如静默期、条件不满足等
+	Error      string            `json:"error,omitempty"`
+	Conditions []ConditionResult `json:"conditions"`
+	Actions    []ActionResult    `json:"actions"`
+	StartTime  time.Time         `json:"startTime"`
+	EndTime    time.Time         `json:"endTime"`
+}
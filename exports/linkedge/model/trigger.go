@@ -1,5 +1,11 @@
 package model
 
+import (
+	"fmt"
+
+	"github.com/ibuilding-x/driver-box/v2/pkg/event"
+)
+
 // TriggerType 触发器类型
 type TriggerType string
 
@@ -8,7 +14,7 @@ const (
 	TriggerTypeSchedule TriggerType = "schedule"
 	// TriggerTypeDevicePoint 设备点位触发器
 	TriggerTypeDevicePoint TriggerType = "devicePoint"
-	// TriggerTypeDeviceEvent 设备事件触发器（暂未使用）
+	// TriggerTypeDeviceEvent 设备事件触发器
 	TriggerTypeDeviceEvent TriggerType = "deviceEvent"
 )
 
@@ -17,6 +23,7 @@ type Trigger struct {
 	Type TriggerType `json:"type"`
 	ScheduleTrigger
 	DevicePointTrigger
+	DeviceEventTrigger
 }
 
 // ScheduleTrigger 定时触发器
@@ -30,6 +37,29 @@ type DevicePointTrigger struct {
 }
 
 // DeviceEventTrigger 设备事件触发器
-// 提示：暂未使用
+// 设备ID过滤复用 devSn 字段，为空时匹配所有设备
 type DeviceEventTrigger struct {
+	// Event 事件code，如：deviceOnline、deviceAdded、deviceDeleting 或 Lua 驱动自定义事件
+	Event event.EventCode `json:"event"`
+	// ModelName 模型名称，为空时匹配所有模型
+	ModelName string `json:"modelName"`
+	// EventValue 事件值，为空时匹配所有值。设备在离线事件：true 上线，false 离线
+	EventValue string `json:"eventValue"`
+}
+
+// Match 判断事件是否满足触发器过滤条件
+func (t Trigger) Match(code event.EventCode, deviceID string, modelName string, value interface{}) bool {
+	if t.Event != code {
+		return false
+	}
+	if len(t.DeviceID) > 0 && t.DeviceID != deviceID {
+		return false
+	}
+	if len(t.ModelName) > 0 && t.ModelName != modelName {
+		return false
+	}
+	if len(t.EventValue) > 0 && t.EventValue != fmt.Sprint(value) {
+		return false
+	}
+	return true
 }
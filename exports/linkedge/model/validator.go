@@ -1,7 +1,15 @@
 package model
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
 	"time"
+
+	"github.com/ibuilding-x/driver-box/v2/pkg/expression"
+	"github.com/robfig/cron/v3"
+	"github.com/yuin/gopher-lua/parse"
 )
 
 // intInSlice 检查数字是否在切片中
@@ -98,3 +106,197 @@ func (c TimesCondition) Check() bool {
 	}
 	return true
 }
+
+// Check 检查表达式语法及变量格式
+func (c ExpressionCondition) Check() error {
+	if len(c.Expression) == 0 {
+		return errors.New("expression is required")
+	}
+	e, err := expression.Compile(c.Expression)
+	if err != nil {
+		return fmt.Errorf("invalid expression: %w", err)
+	}
+	for _, path := range e.Variables() {
+		if len(path) == 2 || (len(path) == 3 && path[2] == ExpressionFieldUpdatedAt) {
+			continue
+		}
+		return fmt.Errorf("invalid variable %q, expected devSn.point or devSn.point.%s", strings.Join(path, "."), ExpressionFieldUpdatedAt)
+	}
+	return nil
+}
+
+// Validate 校验场景联动配置合法性
+// 返回所有不合规项组成的错误，全部合规时返回 nil
+func (c Config) Validate() error {
+	var errs []error
+	if len(c.ID) == 0 {
+		errs = append(errs, errors.New("id is required"))
+	}
+	for i, trigger := range c.Trigger {
+		if e := trigger.validate(); e != nil {
+			errs = append(errs, fmt.Errorf("trigger[%d]: %w", i, e))
+		}
+	}
+	for i, condition := range c.Condition {
+		if e := condition.validate(); e != nil {
+			errs = append(errs, fmt.Errorf("condition[%d]: %w", i, e))
+		}
+	}
+	if len(c.Action) == 0 {
+		errs = append(errs, errors.New("action list cannot be empty"))
+	}
+	for i, action := range c.Action {
+		if action.Type == ActionTypeLinkEdge && len(c.ID) > 0 && action.ID == c.ID {
+			errs = append(errs, fmt.Errorf("action[%d]: linkEdge cannot trigger itself", i))
+			continue
+		}
+		if e := action.validate(); e != nil {
+			errs = append(errs, fmt.Errorf("action[%d]: %w", i, e))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t Trigger) validate() error {
+	switch t.Type {
+	case TriggerTypeSchedule:
+		if len(t.Cron) == 0 {
+			return errors.New("cron is required")
+		}
+		if _, e := cron.ParseStandard(t.Cron); e != nil {
+			return fmt.Errorf("invalid cron %q: %w", t.Cron, e)
+		}
+	case TriggerTypeDevicePoint:
+		return t.DevicePointCondition.validate()
+	case TriggerTypeDeviceEvent:
+		if len(t.Event) == 0 {
+			return errors.New("event is required")
+		}
+	default:
+		return fmt.Errorf("unsupported trigger type: %q", t.Type)
+	}
+	return nil
+}
+
+func (c Condition) validate() error {
+	switch c.Type {
+	case ConditionTypeDevicePoint:
+		return c.DevicePointCondition.validate()
+	case ConditionTypeExecuteTime:
+		if c.Begin > c.End {
+			return errors.New("begin must be earlier than end")
+		}
+	case ConditionTypeLastTime, ConditionTypeDateInterval:
+	case ConditionTypeYears:
+		if !c.YearsCondition.Check() {
+			return errors.New("invalid years")
+		}
+	case ConditionTypeMonths:
+		if !c.MonthsCondition.Check() {
+			return errors.New("invalid months, valid range is 1-12")
+		}
+	case ConditionTypeDays:
+		if !c.DaysCondition.Check() {
+			return errors.New("invalid days, valid range is 1-31")
+		}
+	case ConditionTypeWeeks:
+		if !c.WeeksCondition.Check() {
+			return errors.New("invalid weeks, valid range is 0-6")
+		}
+	case ConditionTypeTimes:
+		if !c.TimesCondition.Check() {
+			return errors.New("begin_time must be earlier than end_time")
+		}
+	case ConditionTypeExpression:
+		return c.ExpressionCondition.Check()
+	default:
+		return fmt.Errorf("unsupported condition type: %q", c.Type)
+	}
+	return nil
+}
+
+func (c DevicePointCondition) validate() error {
+	if len(c.DeviceID) == 0 || len(c.DevicePoint) == 0 || len(c.Condition) == 0 || len(c.Value) == 0 {
+		return errors.New("devSn, point, condition and value are required")
+	}
+	switch c.Condition {
+	case ConditionEq, ConditionNe, ConditionGt, ConditionGe, ConditionLt, ConditionLe:
+	default:
+		return fmt.Errorf("unsupported condition symbol: %q", c.Condition)
+	}
+	if c.Duration < 0 {
+		return errors.New("duration cannot be negative")
+	}
+	return nil
+}
+
+func (a Action) validate() error {
+	if len(a.Sleep) > 0 {
+		if _, e := time.ParseDuration(a.Sleep); e != nil {
+			return fmt.Errorf("invalid sleep %q: %w", a.Sleep, e)
+		}
+	}
+	if len(a.Timeout) > 0 {
+		if _, e := time.ParseDuration(a.Timeout); e != nil {
+			return fmt.Errorf("invalid timeout %q: %w", a.Timeout, e)
+		}
+	}
+	if a.Retry < 0 {
+		return errors.New("retry must be greater than or equal to 0")
+	}
+	for i, condition := range a.Condition {
+		if e := condition.validate(); e != nil {
+			return fmt.Errorf("condition[%d]: %w", i, e)
+		}
+	}
+	switch a.Type {
+	case ActionTypeDevicePoint, ActionTypeLinkEdge:
+		// 设备点位按设备合并写入、场景嵌套异步执行，不支持重试及超时
+		if a.Retry > 0 || len(a.Timeout) > 0 {
+			return fmt.Errorf("retry and timeout are not supported for %s action", a.Type)
+		}
+	case ActionTypeEvent, ActionTypeVirtualPoint:
+		if len(a.Timeout) > 0 {
+			return fmt.Errorf("timeout is not supported for %s action", a.Type)
+		}
+	}
+	switch a.Type {
+	case ActionTypeDevicePoint, ActionTypeVirtualPoint:
+		if len(a.DeviceID) == 0 {
+			return errors.New("devSn is required")
+		}
+		if len(a.DevicePoint) == 0 && len(a.Points) == 0 {
+			return errors.New("points is required")
+		}
+		for i, p := range a.Points {
+			if len(p.Point) == 0 {
+				return fmt.Errorf("points[%d]: point is required", i)
+			}
+		}
+	case ActionTypeLinkEdge:
+		if len(a.ID) == 0 {
+			return errors.New("id is required")
+		}
+	case ActionTypeHttp:
+		if len(a.URL) == 0 {
+			return errors.New("url is required")
+		}
+		if _, e := template.New("body").Parse(a.Body); e != nil {
+			return fmt.Errorf("invalid body template: %w", e)
+		}
+	case ActionTypeEvent:
+		if len(a.Event) == 0 {
+			return errors.New("event is required")
+		}
+	case ActionTypeScript:
+		if len(a.Script) == 0 {
+			return errors.New("script is required")
+		}
+		if _, e := parse.Parse(strings.NewReader(a.Script), "<linkedge>"); e != nil {
+			return fmt.Errorf("invalid script: %w", e)
+		}
+	default:
+		return fmt.Errorf("unsupported action type: %q", a.Type)
+	}
+	return nil
+}
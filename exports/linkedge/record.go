@@ -0,0 +1,155 @@
+package linkedge
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	_ "github.com/glebarez/sqlite"
+	"github.com/ibuilding-x/driver-box/v2/driverbox"
+	"github.com/ibuilding-x/driver-box/v2/exports/linkedge/model"
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+	"github.com/ibuilding-x/driver-box/v2/pkg/crontab"
+	"go.uber.org/zap"
+)
+
+const recordSchemaSQL = `
+CREATE TABLE IF NOT EXISTS linkedge_record( -- 场景联动执行记录
+    id INTEGER PRIMARY KEY NOT NULL, -- 自增主键ID
+    linkedge_id varchar(255) NOT NULL, -- 场景联动ID
+    source_type varchar(32) NOT NULL, -- 触发来源类型
+    result varchar(32) NOT NULL, -- 执行结果
+    detail TEXT NOT NULL, -- 执行明细，json格式
+    start_time INTEGER NOT NULL, -- 开始时间，毫秒时间戳
+    end_time INTEGER NOT NULL -- 结束时间，毫秒时间戳
+    );
+CREATE INDEX IF NOT EXISTS idx_linkedge_time ON linkedge_record(linkedge_id,start_time);
+`
+
+// recordStore 场景联动执行记录存储
+type recordStore struct {
+	db *sql.DB
+	// 保存时长，单位：天
+	reservedDays int
+	// 单个场景最多保留记录数
+	maxCount  int
+	clearTask *crontab.Future
+}
+
+func newRecordStore() (*recordStore, error) {
+	dir := os.Getenv(config.EXPORT_LINKEDGE_RECORD_PATH)
+	if dir == "" {
+		dir = filepath.Join(config.ResourcePath, "linkedge_record")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", filepath.Join(dir, "record.db"))
+	if err != nil {
+		return nil, err
+	}
+	//sqlite 单连接写入，避免 database is locked
+	db.SetMaxOpenConns(1)
+	if _, err = db.Exec(recordSchemaSQL); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	store := &recordStore{
+		db:           db,
+		reservedDays: 7,
+		maxCount:     1000,
+	}
+	if v, e := strconv.Atoi(os.Getenv(config.EXPORT_LINKEDGE_RECORD_RESERVED_DAYS)); e == nil && v > 0 {
+		store.reservedDays = v
+	}
+	if v, e := strconv.Atoi(os.Getenv(config.EXPORT_LINKEDGE_RECORD_MAX_COUNT)); e == nil && v > 0 {
+		store.maxCount = v
+	}
+	store.clearTask, err = driverbox.AddFunc("1h", store.clearExpired)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+func (store *recordStore) close() error {
+	store.clearTask.Disable()
+	return store.db.Close()
+}
+
+// save 保存执行记录
+func (store *recordStore) save(record *model.ExecuteRecord) {
+	detail, err := json.Marshal(record)
+	if err != nil {
+		driverbox.Log().Error("marshal linkEdge record error", zap.String("id", record.LinkEdgeID), zap.Error(err))
+		return
+	}
+	result, err := store.db.Exec("INSERT INTO linkedge_record(linkedge_id,source_type,result,detail,start_time,end_time) VALUES (?,?,?,?,?,?)",
+		record.LinkEdgeID, string(record.Source.Type), record.Result, string(detail), record.StartTime.UnixMilli(), record.EndTime.UnixMilli())
+	if err != nil {
+		driverbox.Log().Error("save linkEdge record error", zap.String("id", record.LinkEdgeID), zap.Error(err))
+		return
+	}
+	record.ID, _ = result.LastInsertId()
+}
+
+// query 按场景ID及时间范围查询执行记录，按开始时间倒序
+func (store *recordStore) query(id string, start, end time.Time, limit int) ([]model.ExecuteRecord, error) {
+	query := "SELECT id,detail FROM linkedge_record WHERE 1=1"
+	args := make([]interface{}, 0)
+	if id != "" {
+		query += " AND linkedge_id = ?"
+		args = append(args, id)
+	}
+	if !start.IsZero() {
+		query += " AND start_time >= ?"
+		args = append(args, start.UnixMilli())
+	}
+	if !end.IsZero() {
+		query += " AND start_time <= ?"
+		args = append(args, end.UnixMilli())
+	}
+	query += " ORDER BY start_time DESC"
+	if limit > 0 {
+		query += " LIMIT " + strconv.Itoa(limit)
+	}
+	rows, err := store.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	records := make([]model.ExecuteRecord, 0)
+	for rows.Next() {
+		var rowId int64
+		var detail string
+		if err = rows.Scan(&rowId, &detail); err != nil {
+			return nil, err
+		}
+		var record model.ExecuteRecord
+		if err = json.Unmarshal([]byte(detail), &record); err != nil {
+			return nil, err
+		}
+		record.ID = rowId
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// clearExpired 清理过期及超出数量上限的执行记录
+func (store *recordStore) clearExpired() {
+	expired := time.Now().Add(-24 * time.Duration(store.reservedDays) * time.Hour).UnixMilli()
+	if _, err := store.db.Exec("DELETE FROM linkedge_record WHERE start_time < ?", expired); err != nil {
+		driverbox.Log().Error("clear expired linkEdge record error", zap.Error(err))
+	}
+	_, err := store.db.Exec(`DELETE FROM linkedge_record WHERE id IN (
+    SELECT id FROM (
+        SELECT id, ROW_NUMBER() OVER (PARTITION BY linkedge_id ORDER BY start_time DESC) AS rn FROM linkedge_record
+    ) WHERE rn > ?)`, store.maxCount)
+	if err != nil {
+		driverbox.Log().Error("clear overflow linkEdge record error", zap.Error(err))
+	}
+}
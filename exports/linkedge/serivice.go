@@ -1,6 +1,10 @@
 package linkedge
 
-import "github.com/ibuilding-x/driver-box/v2/exports/linkedge/model"
+import (
+	"time"
+
+	"github.com/ibuilding-x/driver-box/v2/exports/linkedge/model"
+)
 
 type IService interface {
 	Get(id string) (model.Config, error)
@@ -31,4 +35,27 @@ type IService interface {
 	// 参数: config - 要执行的场景联动配置对象
 	// 返回: error - 错误信息
 	Execute(config model.Config) error
+
+	// Preview 预览场景联动执行效果，不做持久化
+	// 参数: config - 要预览的场景联动配置对象
+	// 返回: error - 错误信息
+	Preview(config model.Config) error
+
+	// Enable 启用或禁用指定ID的场景联动
+	// 参数: id - 场景联动配置的唯一标识符, enable - 是否启用
+	// 返回: error - 错误信息
+	Enable(id string, enable bool) error
+
+	// GetLast 获取最后一次执行的场景联动
+	GetLast() (model.Config, error)
+
+	// GetExecuteResult 获取指定场景联动最近一次执行结果
+	// 参数: id - 场景联动配置的唯一标识符
+	// 返回: 执行结果, 是否存在执行记录
+	GetExecuteResult(id string) (model.ExecuteResult, bool)
+
+	// GetRecords 查询场景联动执行记录，按执行时间倒序
+	// 参数: id - 场景联动ID，为空时查询全部; start、end - 执行时间范围，零值表示不限制; limit - 最多返回条数
+	// 返回: 执行记录列表, 错误信息
+	GetRecords(id string, start, end time.Time, limit int) ([]model.ExecuteRecord, error)
 }
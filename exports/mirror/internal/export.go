@@ -130,7 +130,7 @@ func (export *Export) autoCreateMirrorDevice(deviceId string) error {
 		ConnectionKey: mirror.MirrorConnectionKey,
 	}
 
-	driverbox.CoreCache().UpdateDeviceProperty(deviceId, PropertyKeyAutoMirrorTo, mirrorDevice.ID)
+	driverbox.CoreCache().WithSource(mirror.ProtocolName).UpdateDeviceProperty(deviceId, PropertyKeyAutoMirrorTo, mirrorDevice.ID)
 	if _, ok := driverbox.CoreCache().GetDevice(mirrorDevice.ID); ok {
 		driverbox.Log().Info("auto create mirror device ignore, device already exists", zap.String("deviceId", mirrorDevice.ID))
 		return nil
@@ -169,23 +169,24 @@ func (export *Export) autoCreateMirrorDevice(deviceId string) error {
 	mirrorModel.DevicePoints = points
 
 	//第三步：配置持久化
-	e := driverbox.CoreCache().AddModel(mirror.ProtocolName, mirrorModel)
-	if e != nil {
-		driverbox.Log().Error("add mirror model error", zap.Error(e))
-		return e
-	}
-	e = driverbox.CoreCache().AddOrUpdateDevice(mirrorDevice)
+	e := driverbox.CoreCache().WithSource(mirror.ProtocolName).AddModel(mirror.ProtocolName, mirrorModel)
 	if e != nil {
 		driverbox.Log().Error("add mirror model error", zap.Error(e))
 		return e
 	}
+	//设备引用的连接需先存在
 	p, c := driverbox.CoreCache().GetConnection(mirror.MirrorConnectionKey)
 	if c == nil {
-		driverbox.CoreCache().AddConnection(mirror.ProtocolName, mirror.MirrorConnectionKey, make(map[string]string))
+		driverbox.CoreCache().WithSource(mirror.ProtocolName).AddConnection(mirror.ProtocolName, mirror.MirrorConnectionKey, make(map[string]string))
 	} else if p != mirror.ProtocolName {
 		driverbox.Log().Error("mirror connection key already exists")
 		return errors.New("mirror connection key already exists")
 	}
+	e = driverbox.CoreCache().WithSource(mirror.ProtocolName).AddOrUpdateDevice(mirrorDevice)
+	if e != nil {
+		driverbox.Log().Error("add mirror model error", zap.Error(e))
+		return e
+	}
 	//ready为false，说明不存在mirror目录
 	if export.plugin.IsReady() {
 		e = export.plugin.UpdateMirrorMapping(mirrorModel, mirrorDevice)
@@ -44,7 +44,7 @@ func (p *Plugin) Initialize(c config.DeviceConfig) {
 	for _, model := range c.DeviceModels {
 		deviceCount := len(model.Devices)
 		if deviceCount == 0 {
-			_ = driverbox.CoreCache().DeleteModel(model.Name)
+			_ = driverbox.CoreCache().WithSource(ProtocolName).DeleteModel(model.Name)
 			driverbox.Log().Warn("delete model because of none device", zap.Any("model", model))
 			continue
 		}
@@ -59,7 +59,7 @@ func (p *Plugin) Initialize(c config.DeviceConfig) {
 	}
 	//删除无用的连接
 	if len(c.DeviceModels) == 0 {
-		_ = driverbox.CoreCache().DeleteConnection(MirrorConnectionKey)
+		_ = driverbox.CoreCache().WithSource(ProtocolName).DeleteConnection(MirrorConnectionKey)
 	}
 	p.ready = true
 }
@@ -0,0 +1,125 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/ibuilding-x/driver-box/v2/driverbox"
+	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
+	"go.uber.org/zap"
+)
+
+const (
+	// CommandWrite 写点位命令
+	CommandWrite = "write"
+	// CommandRead 读点位命令
+	CommandRead = "read"
+
+	// 读命令等待设备返回最新值的超时时间
+	readTimeout = 5 * time.Second
+	// 读命令检查影子值的间隔
+	readCheckInterval = 50 * time.Millisecond
+)
+
+var errReadTimeout = errors.New("read points timeout")
+
+// Command 下行命令
+type Command struct {
+	// ID 请求ID，原样返回用于关联响应
+	ID string `json:"id"`
+	// Action 命令类型：write、read
+	Action   string             `json:"action"`
+	DeviceID string             `json:"deviceId"`
+	Points   []plugin.PointData `json:"points"`
+	// ResponseTopic 响应主题，为空时使用默认响应主题
+	ResponseTopic string `json:"responseTopic"`
+}
+
+// CommandResponse 命令响应
+type CommandResponse struct {
+	ID       string `json:"id"`
+	DeviceID string `json:"deviceId"`
+	Success  bool   `json:"success"`
+	Error    string `json:"error,omitempty"`
+	// Data 读命令返回设备在命令之后上报的点位值
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+// onCommand 处理下行命令，执行结果发布至响应主题
+func (export *MqttExport) onCommand(client mqtt.Client, message mqtt.Message) {
+	var cmd Command
+	if err := json.Unmarshal(message.Payload(), &cmd); err != nil {
+		driverbox.Log().Error("invalid mqtt command", zap.String("topic", message.Topic()), zap.Error(err))
+		return
+	}
+	go func() {
+		resp := export.execute(cmd)
+		topic := cmd.ResponseTopic
+		if topic == "" {
+			topic = export.topic(export.ResponseTopic, defaultResponseTopic, cmd.DeviceID, modelName(cmd.DeviceID))
+		}
+		bytes, _ := json.Marshal(resp)
+		if err := export.publish(topic, false, bytes); err != nil {
+			driverbox.Log().Error("publish mqtt command response error", zap.String("topic", topic), zap.Error(err))
+		}
+	}()
+}
+
+func (export *MqttExport) execute(cmd Command) CommandResponse {
+	resp := CommandResponse{ID: cmd.ID, DeviceID: cmd.DeviceID}
+	var err error
+	switch {
+	case cmd.DeviceID == "":
+		err = errors.New("deviceId is required")
+	case len(cmd.Points) == 0:
+		err = errors.New("points is required")
+	case cmd.Action == CommandWrite:
+		err = driverbox.WritePoints(cmd.DeviceID, cmd.Points)
+	case cmd.Action == CommandRead:
+		resp.Data, err = readPoints(cmd.DeviceID, cmd.Points)
+	default:
+		err = errors.New("unsupported action: " + cmd.Action)
+	}
+	if err != nil {
+		driverbox.Log().Error("execute mqtt command error", zap.String("id", cmd.ID), zap.String("deviceId", cmd.DeviceID), zap.Error(err))
+		resp.Error = err.Error()
+		return resp
+	}
+	resp.Success = true
+	return resp
+}
+
+// readPoints 下发读命令，等待影子中的点位值在命令之后更新，超时未更新时返回错误，不返回旧值
+func readPoints(deviceID string, points []plugin.PointData) (map[string]interface{}, error) {
+	requestAt := time.Now()
+	if err := driverbox.ReadPoints(deviceID, points); err != nil {
+		return nil, err
+	}
+	pending := make(map[string]bool, len(points))
+	for _, p := range points {
+		pending[p.PointName] = true
+	}
+	data := make(map[string]interface{}, len(pending))
+	deadline := requestAt.Add(readTimeout)
+	for {
+		for name := range pending {
+			point, err := driverbox.Shadow().GetDevicePointDetails(deviceID, name)
+			if err != nil {
+				return nil, err
+			}
+			if point.UpdatedAt.After(requestAt) {
+				data[name] = point.Value
+				delete(pending, name)
+			}
+		}
+		if len(pending) == 0 {
+			return data, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, errReadTimeout
+		}
+		time.Sleep(readCheckInterval)
+	}
+}
@@ -1,43 +1,100 @@
 package mqtt
 
 import (
-	"crypto/tls"
 	"encoding/json"
-	"log"
+	"errors"
+	"sync/atomic"
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/ibuilding-x/driver-box/v2/driverbox"
 	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
 	"github.com/ibuilding-x/driver-box/v2/pkg/event"
 	"go.uber.org/zap"
 )
 
+const (
+	// 默认遥测主题
+	defaultTelemetryTopic = "driverbox/{serialNo}/{modelName}/{deviceId}/telemetry"
+	// 默认事件主题
+	defaultEventTopic = "driverbox/{serialNo}/{deviceId}/event"
+	// 默认网关在线状态主题，同时作为遗嘱主题
+	defaultStatusTopic = "driverbox/{serialNo}/status"
+	// 默认下行命令主题
+	defaultCommandTopic = "driverbox/{serialNo}/command"
+	// 默认命令响应主题
+	defaultResponseTopic = "driverbox/{serialNo}/command/response"
+	// 发布超时时长
+	publishTimeout = 5 * time.Second
+)
+
 type MqttExport struct {
-	Broker      string `json:"broker"`
-	Username    string `json:"username"`
-	Password    string `json:"password"`
-	ClientID    string `json:"client_id"`
-	init        bool
-	client      mqtt.Client
-	handler     mqtt.MessageHandler
-	ExportTopic string
+	Broker   string `json:"broker"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	ClientID string `json:"client_id"`
+	// ExportTopic 兼容旧版本，配置后所有设备数据发布至该主题
+	ExportTopic string `json:"exportTopic"`
+	// TelemetryTopic 遥测主题模板，支持变量：{serialNo}、{modelName}、{deviceId}
+	TelemetryTopic string `json:"telemetryTopic"`
+	// ModelTopics 按模型名称指定遥测主题模板，优先级高于 TelemetryTopic
+	ModelTopics map[string]string `json:"modelTopics"`
+	// DeviceTopics 按设备ID指定遥测主题模板，优先级高于 ModelTopics
+	DeviceTopics map[string]string `json:"deviceTopics"`
+	// EventTopic 设备事件主题模板，支持变量：{serialNo}、{modelName}、{deviceId}
+	EventTopic string `json:"eventTopic"`
+	// StatusTopic 网关在线状态主题模板，断线时由 Broker 发布遗嘱消息
+	StatusTopic string `json:"statusTopic"`
+	// CommandTopic 下行命令订阅主题模板，为 "-" 时不订阅
+	CommandTopic string `json:"commandTopic"`
+	// ResponseTopic 命令默认响应主题模板，命令中指定 responseTopic 时以命令为准
+	ResponseTopic string `json:"responseTopic"`
+	// QoS 消息服务质量等级：0、1、2
+	QoS byte `json:"qos"`
+	// Retain 遥测消息是否保留
+	Retain bool `json:"retain"`
+	// TLS 证书配置，broker 为 ssl://、tls://、mqtts:// 时生效
+	TLS TLSConfig `json:"tls"`
+	// Mode 消息模式：json（默认）、sparkplugB
+	Mode string `json:"mode"`
+	// Sparkplug Sparkplug B 模式配置
+	Sparkplug SparkplugConfig `json:"sparkplug"`
+
+	ready  atomic.Bool
+	client mqtt.Client
+	node   *sparkplugNode
 }
 
 func (export *MqttExport) Init() error {
-	if len(export.ExportTopic) == 0 {
-		panic("exportTopic is blank")
+	if export.QoS > 2 {
+		return errors.New("invalid qos, must be 0, 1 or 2")
 	}
 	options := mqtt.NewClientOptions()
 	options.AddBroker(export.Broker)
 	options.SetUsername(export.Username)
 	options.SetPassword(export.Password)
 	options.SetClientID(export.ClientID)
-	// tsl 设置
-	if options.Servers[0].Scheme == "ssl" {
-		options.SetTLSConfig(&tls.Config{
-			InsecureSkipVerify: true,
-		})
+	if isTLS(options.Servers[0].Scheme) {
+		tlsConfig, err := export.TLS.build()
+		if err != nil {
+			return err
+		}
+		options.SetTLSConfig(tlsConfig)
 	}
+	if export.sparkplugMode() {
+		if err := export.initSparkplug(options); err != nil {
+			return err
+		}
+	} else {
+		// 遗嘱消息：网关离线
+		will, _ := json.Marshal(statusMessage{Online: false})
+		options.SetWill(export.topic(export.StatusTopic, defaultStatusTopic, "", ""), string(will), 1, true)
+	}
+	// 断线自动重连，首次连接失败时持续重试，不阻塞启动
+	options.SetAutoReconnect(true)
+	options.SetConnectRetry(true)
+	options.SetConnectRetryInterval(5 * time.Second)
+	options.SetMaxReconnectInterval(time.Minute)
 	options.SetOnConnectHandler(export.onConnectHandler)
 	options.SetConnectionLostHandler(export.onConnectionLostHandler)
 	export.client = mqtt.NewClient(options)
@@ -48,35 +105,104 @@ func (export *MqttExport) Init() error {
 	return nil
 }
 
-// onConnectHandler 连接成功
+// onConnectHandler 连接成功，发布在线状态并订阅下行命令
 func (export *MqttExport) onConnectHandler(client mqtt.Client) {
-	log.Println("mqttExport init success")
-	export.init = true
+	driverbox.Log().Info("mqtt export connected", zap.String("broker", export.Broker))
+	if export.sparkplugMode() {
+		export.onSparkplugConnect(client)
+		export.ready.Store(true)
+		return
+	}
+	status, _ := json.Marshal(statusMessage{Online: true})
+	client.Publish(export.topic(export.StatusTopic, defaultStatusTopic, "", ""), 1, true, status)
+	if export.CommandTopic != "-" {
+		topic := export.topic(export.CommandTopic, defaultCommandTopic, "", "")
+		token := client.Subscribe(topic, export.QoS, export.onCommand)
+		if token.WaitTimeout(publishTimeout) && token.Error() != nil {
+			driverbox.Log().Error("subscribe mqtt command topic error", zap.String("topic", topic), zap.Error(token.Error()))
+		}
+	}
+	export.ready.Store(true)
 }
 
-// onConnectionLostHandler 连接丢失
+// onConnectionLostHandler 连接丢失，数据缓存至断网续传队列，待自动重连后补发
 func (export *MqttExport) onConnectionLostHandler(client mqtt.Client, err error) {
-	log.Fatal("local mqtt connect lost", zap.Error(err))
+	driverbox.Log().Warn("mqtt export connection lost", zap.String("broker", export.Broker), zap.Error(err))
+	export.ready.Store(false)
 }
 
 // ExportTo 导出消息：写入Edgex总线、MQTT上云
 func (export *MqttExport) ExportTo(deviceData plugin.DeviceData) {
-	log.Println("export...")
-	bytes, _ := json.Marshal(deviceData)
-	token := export.client.Publish(export.ExportTopic, 0, false, bytes)
-	if token.Error() != nil {
-		log.Fatal(token.Error())
+	if err := export.Deliver(deviceData); err != nil {
+		driverbox.Log().Error("mqtt export error", zap.String("deviceId", deviceData.ID), zap.Error(err))
+	}
+}
+
+// QueueName 断网续传队列名称
+func (export *MqttExport) QueueName() string {
+	return "mqtt_" + export.ClientID
+}
+
+// Deliver 发布设备数据，发布失败时由框架缓存待补发
+func (export *MqttExport) Deliver(deviceData plugin.DeviceData) error {
+	if export.sparkplugMode() {
+		return export.deliverSparkplug(deviceData)
+	}
+	bytes, err := json.Marshal(deviceData)
+	if err != nil {
+		return err
 	}
+	return export.publish(export.telemetryTopic(deviceData.ID), export.Retain, bytes)
 }
 
-// 继承Export OnEvent接口
+// 继承Export OnEvent接口，设备相关事件发布至事件主题
 func (export *MqttExport) OnEvent(eventCode event.EventCode, key string, eventValue interface{}) error {
-	if event.DeviceOnline == eventCode {
-		export.client.Publish("/driverbox/event/"+export.ClientID, 0, false, map[string]any{"deviceId": key, "online": eventValue})
+	switch eventCode {
+	case event.DoExport, event.Exporting, event.ServiceStatus:
+		// 内部流程事件，不对外发布
+		return nil
 	}
-	return nil
+	if !export.ready.Load() {
+		return nil
+	}
+	if export.sparkplugMode() {
+		return export.onSparkplugEvent(eventCode, key, eventValue)
+	}
+	bytes, err := json.Marshal(eventMessage{
+		Event:     eventCode,
+		DeviceID:  key,
+		Value:     eventValue,
+		Timestamp: time.Now().UnixMilli(),
+	})
+	if err != nil {
+		return err
+	}
+	return export.publish(export.topic(export.EventTopic, defaultEventTopic, key, modelName(key)), false, bytes)
 }
 
 func (export *MqttExport) IsReady() bool {
-	return export.init
+	return export.ready.Load()
+}
+
+func (export *MqttExport) Destroy() error {
+	export.ready.Store(false)
+	if export.client != nil && export.client.IsConnected() {
+		// 主动断开时 Broker 不会发布遗嘱，需手动发布离线状态
+		if export.sparkplugMode() {
+			export.destroySparkplug()
+		} else {
+			status, _ := json.Marshal(statusMessage{Online: false})
+			export.client.Publish(export.topic(export.StatusTopic, defaultStatusTopic, "", ""), 1, true, status).WaitTimeout(publishTimeout)
+		}
+		export.client.Disconnect(250)
+	}
+	return nil
+}
+
+func (export *MqttExport) publish(topic string, retain bool, payload []byte) error {
+	token := export.client.Publish(topic, export.QoS, retain, payload)
+	if !token.WaitTimeout(publishTimeout) {
+		return errors.New("mqtt publish timeout")
+	}
+	return token.Error()
 }
@@ -0,0 +1,347 @@
+package mqtt
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/ibuilding-x/driver-box/v2/driverbox"
+	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
+	"github.com/ibuilding-x/driver-box/v2/exports/mqtt/sparkplug"
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+	"github.com/ibuilding-x/driver-box/v2/pkg/event"
+	"go.uber.org/zap"
+)
+
+const (
+	// ModeJSON 默认模式，JSON 格式消息
+	ModeJSON = "json"
+	// ModeSparkplugB Eclipse Sparkplug B 模式
+	ModeSparkplugB = "sparkplugB"
+
+	sparkplugNamespace = "spBv1.0"
+	// 节点控制指标：重新发布 BIRTH 消息
+	metricRebirth = "Node Control/Rebirth"
+	metricBdSeq   = "bdSeq"
+	// 节点信息指标：节点下的设备数量
+	metricDeviceCount = "Node Info/Device Count"
+	// 点位单位属性
+	propertyEngUnit = "engUnit"
+)
+
+// SparkplugConfig Sparkplug B 模式配置
+type SparkplugConfig struct {
+	GroupID string `json:"groupId"`
+	// EdgeNodeID 边缘节点ID，默认为网关序列号
+	EdgeNodeID string `json:"edgeNodeId"`
+}
+
+// sparkplugNode Sparkplug B 边缘节点会话状态
+type sparkplugNode struct {
+	// 保证消息按序号顺序发布
+	mutex sync.Mutex
+	seq   uint64
+	// 每次建立新的 MQTT 会话时递增，NBIRTH 与 NDEATH 中保持一致
+	bdSeq uint64
+	// 当前会话中已发布 DBIRTH 的设备
+	births map[string]bool
+	// 节点下的设备，由设备增删事件维护，事件处理中无需回查核心缓存
+	devices map[string]bool
+}
+
+func (export *MqttExport) sparkplugMode() bool {
+	return export.Mode == ModeSparkplugB
+}
+
+func (export *MqttExport) edgeNodeID() string {
+	if export.Sparkplug.EdgeNodeID != "" {
+		return export.Sparkplug.EdgeNodeID
+	}
+	return driverbox.GetMetadata().SerialNo
+}
+
+// sparkplugTopic 生成 Sparkplug 主题：spBv1.0/{groupId}/{messageType}/{edgeNodeId}[/{deviceId}]
+func (export *MqttExport) sparkplugTopic(messageType string, deviceID string) string {
+	topic := strings.Join([]string{sparkplugNamespace, export.Sparkplug.GroupID, messageType, export.edgeNodeID()}, "/")
+	if deviceID != "" {
+		topic += "/" + deviceID
+	}
+	return topic
+}
+
+// initSparkplug 设置 NDEATH 遗嘱，重连前递增 bdSeq
+func (export *MqttExport) initSparkplug(options *mqtt.ClientOptions) error {
+	if export.Sparkplug.GroupID == "" {
+		return errors.New("sparkplug groupId is required")
+	}
+	export.node = &sparkplugNode{births: make(map[string]bool), devices: make(map[string]bool)}
+	if err := export.setDeathWill(options); err != nil {
+		return err
+	}
+	options.SetReconnectingHandler(func(client mqtt.Client, options *mqtt.ClientOptions) {
+		export.node.mutex.Lock()
+		export.node.bdSeq = (export.node.bdSeq + 1) % 256
+		export.node.mutex.Unlock()
+		if err := export.setDeathWill(options); err != nil {
+			driverbox.Log().Error("set sparkplug NDEATH error", zap.Error(err))
+		}
+	})
+	return nil
+}
+
+func (export *MqttExport) setDeathWill(options *mqtt.ClientOptions) error {
+	payload, err := export.deathPayload()
+	if err != nil {
+		return err
+	}
+	options.SetBinaryWill(export.sparkplugTopic("NDEATH", ""), payload, 1, false)
+	return nil
+}
+
+func (export *MqttExport) deathPayload() ([]byte, error) {
+	export.node.mutex.Lock()
+	defer export.node.mutex.Unlock()
+	return sparkplug.Payload{
+		Timestamp: now(),
+		Metrics:   []sparkplug.Metric{{Name: metricBdSeq, DataType: sparkplug.Int64, Value: export.node.bdSeq}},
+	}.Marshal()
+}
+
+// onSparkplugConnect 订阅 NCMD、DCMD 并发布 BIRTH 消息
+func (export *MqttExport) onSparkplugConnect(client mqtt.Client) {
+	for _, topic := range []string{export.sparkplugTopic("NCMD", ""), export.sparkplugTopic("DCMD", "+")} {
+		token := client.Subscribe(topic, 0, export.onSparkplugCommand)
+		if token.WaitTimeout(publishTimeout) && token.Error() != nil {
+			driverbox.Log().Error("subscribe sparkplug command topic error", zap.String("topic", topic), zap.Error(token.Error()))
+		}
+	}
+	if err := export.rebirth(); err != nil {
+		driverbox.Log().Error("publish sparkplug birth error", zap.Error(err))
+	}
+}
+
+// rebirth 发布 NBIRTH 及所有在线设备的 DBIRTH，序号从 0 开始
+func (export *MqttExport) rebirth() error {
+	node := export.node
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+	node.seq = 0
+	node.births = make(map[string]bool)
+	node.devices = make(map[string]bool)
+	for _, device := range driverbox.CoreCache().Devices() {
+		node.devices[device.ID] = true
+	}
+	err := export.publishSparkplug("NBIRTH", "", []sparkplug.Metric{
+		{Name: metricBdSeq, DataType: sparkplug.Int64, Value: node.bdSeq},
+		{Name: metricRebirth, DataType: sparkplug.Boolean, Value: false},
+		{Name: metricDeviceCount, DataType: sparkplug.Int64, Value: len(node.devices)},
+	})
+	if err != nil {
+		return err
+	}
+	for _, device := range driverbox.CoreCache().Devices() {
+		if d, ok := driverbox.Shadow().GetDevice(device.ID); ok && d.Online {
+			if err = export.deviceBirth(device.ID); err != nil {
+				driverbox.Log().Error("publish sparkplug DBIRTH error", zap.String("deviceId", device.ID), zap.Error(err))
+			}
+		}
+	}
+	return nil
+}
+
+// deviceBirth 发布 DBIRTH，指标定义来源于模型点位，调用方需持有锁
+func (export *MqttExport) deviceBirth(deviceID string) error {
+	device, ok := driverbox.CoreCache().GetDevice(deviceID)
+	if !ok {
+		return errors.New("unknown device")
+	}
+	points, _ := driverbox.CoreCache().GetPoints(device.ModelName)
+	metrics := make([]sparkplug.Metric, 0, len(points))
+	for _, point := range points {
+		metric := sparkplug.Metric{
+			Name:      point.Name(),
+			Timestamp: now(),
+			DataType:  dataType(point.ValueType()),
+		}
+		metric.Value, _ = driverbox.Shadow().GetDevicePoint(deviceID, point.Name())
+		if units := point.Units(); units != "" {
+			metric.Properties = map[string]string{propertyEngUnit: units}
+		}
+		metrics = append(metrics, metric)
+	}
+	if err := export.publishSparkplug("DBIRTH", deviceID, metrics); err != nil {
+		return err
+	}
+	export.node.births[deviceID] = true
+	return nil
+}
+
+// deviceDeath 发布 DDEATH，调用方需持有锁
+func (export *MqttExport) deviceDeath(deviceID string) error {
+	if !export.node.births[deviceID] {
+		return nil
+	}
+	delete(export.node.births, deviceID)
+	return export.publishSparkplug("DDEATH", deviceID, nil)
+}
+
+// deliverSparkplug 发布 DDATA，设备尚未发布 DBIRTH 时先发布 DBIRTH，离线设备不发布
+// 断网续传补发的数据标记为历史数据
+func (export *MqttExport) deliverSparkplug(deviceData plugin.DeviceData) error {
+	node := export.node
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+	if !node.births[deviceData.ID] {
+		if online, _ := driverbox.Shadow().IsOnline(deviceData.ID); !online {
+			driverbox.Log().Warn("device is offline, skip sparkplug DDATA", zap.String("deviceId", deviceData.ID))
+			return nil
+		}
+		if err := export.deviceBirth(deviceData.ID); err != nil {
+			return err
+		}
+		//DBIRTH 已包含设备的最新值
+		if !deviceData.Replayed {
+			return nil
+		}
+	}
+	metrics := make([]sparkplug.Metric, 0, len(deviceData.Values))
+	for _, value := range deviceData.Values {
+		var valueType config.ValueType
+		if point, ok := driverbox.CoreCache().GetPointByDevice(deviceData.ID, value.PointName); ok {
+			valueType = point.ValueType()
+		}
+		timestamp := now()
+		if value.Timestamp > 0 {
+			timestamp = uint64(value.Timestamp)
+		}
+		metrics = append(metrics, sparkplug.Metric{
+			Name:       value.PointName,
+			Timestamp:  timestamp,
+			DataType:   dataType(valueType),
+			Historical: deviceData.Replayed,
+			Value:      value.Value,
+		})
+	}
+	return export.publishSparkplug("DDATA", deviceData.ID, metrics)
+}
+
+// onSparkplugEvent 设备上线发布 DBIRTH，离线或删除发布 DDEATH，设备增删发布 NDATA
+func (export *MqttExport) onSparkplugEvent(eventCode event.EventCode, key string, eventValue interface{}) error {
+	node := export.node
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+	switch eventCode {
+	case event.DeviceOnline:
+		if online, _ := eventValue.(bool); online {
+			return export.deviceBirth(key)
+		}
+		return export.deviceDeath(key)
+	case event.DeviceDeleting:
+		delete(node.devices, key)
+		if err := export.deviceDeath(key); err != nil {
+			return err
+		}
+		return export.publishDeviceCount()
+	case event.DeviceAdded:
+		node.devices[key] = true
+		return export.publishDeviceCount()
+	}
+	return nil
+}
+
+// publishDeviceCount 通过 NDATA 发布节点设备数量，调用方需持有锁
+func (export *MqttExport) publishDeviceCount() error {
+	return export.publishSparkplug("NDATA", "", []sparkplug.Metric{{
+		Name:      "Node Info/Device Count",
+		Timestamp: now(),
+		DataType:  sparkplug.Int64,
+		Value:     len(export.node.devices),
+	}})
+}
+
+// onSparkplugCommand 处理 NCMD 重新发布 BIRTH，DCMD 写入设备点位
+func (export *MqttExport) onSparkplugCommand(client mqtt.Client, message mqtt.Message) {
+	payload, err := sparkplug.Unmarshal(message.Payload())
+	if err != nil {
+		driverbox.Log().Error("invalid sparkplug command", zap.String("topic", message.Topic()), zap.Error(err))
+		return
+	}
+	parts := strings.Split(message.Topic(), "/")
+	if len(parts) >= 3 && parts[2] == "NCMD" {
+		for _, m := range payload.Metrics {
+			if rebirth, _ := m.Value.(bool); m.Name == metricRebirth && rebirth {
+				go func() {
+					if err := export.rebirth(); err != nil {
+						driverbox.Log().Error("sparkplug rebirth error", zap.Error(err))
+					}
+				}()
+			}
+		}
+		return
+	}
+	if len(parts) < 5 {
+		return
+	}
+	deviceID := parts[4]
+	points := make([]plugin.PointData, 0, len(payload.Metrics))
+	for _, m := range payload.Metrics {
+		points = append(points, plugin.PointData{PointName: m.Name, Value: m.Value})
+	}
+	go func() {
+		if err := driverbox.WritePoints(deviceID, points); err != nil {
+			driverbox.Log().Error("execute sparkplug DCMD error", zap.String("deviceId", deviceID), zap.Error(err))
+		}
+	}()
+}
+
+// publishSparkplug 发布带序号的 Sparkplug 消息，调用方需持有锁
+func (export *MqttExport) publishSparkplug(messageType string, deviceID string, metrics []sparkplug.Metric) error {
+	payload, err := sparkplug.Payload{
+		Timestamp: now(),
+		Metrics:   metrics,
+		Seq:       export.node.seq,
+		HasSeq:    true,
+	}.Marshal()
+	if err != nil {
+		return err
+	}
+	export.node.seq = (export.node.seq + 1) % 256
+	token := export.client.Publish(export.sparkplugTopic(messageType, deviceID), 0, false, payload)
+	if !token.WaitTimeout(publishTimeout) {
+		return errors.New("mqtt publish timeout")
+	}
+	return token.Error()
+}
+
+// destroySparkplug 主动断开前发布 NDEATH
+func (export *MqttExport) destroySparkplug() {
+	payload, err := export.deathPayload()
+	if err != nil {
+		return
+	}
+	export.client.Publish(export.sparkplugTopic("NDEATH", ""), 1, false, payload).WaitTimeout(publishTimeout)
+}
+
+// dataType 点位类型对应的 Sparkplug 数据类型
+func dataType(valueType config.ValueType) sparkplug.DataType {
+	switch valueType {
+	case config.ValueType_Int:
+		return sparkplug.Int64
+	case config.ValueType_Float:
+		return sparkplug.Double
+	case config.ValueType_Bool:
+		return sparkplug.Boolean
+	case config.ValueType_Datetime:
+		return sparkplug.DateTime
+	case config.ValueType_Array, config.ValueType_Object:
+		return sparkplug.Text
+	default:
+		return sparkplug.String
+	}
+}
+
+func now() uint64 {
+	return uint64(time.Now().UnixMilli())
+}
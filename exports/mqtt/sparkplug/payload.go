@@ -0,0 +1,389 @@
+// Package sparkplug Eclipse Sparkplug B 载荷编解码
+//
+// 基于 protowire 实现 sparkplug_b.proto 中 Payload、Metric、PropertySet 的编解码，
+// 仅包含 driver-box 所需的字段：基础类型的 Metric 值及 String 类型的属性。
+package sparkplug
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// DataType Sparkplug B 数据类型
+type DataType uint32
+
+const (
+	Int8     DataType = 1
+	Int16    DataType = 2
+	Int32    DataType = 3
+	Int64    DataType = 4
+	UInt8    DataType = 5
+	UInt16   DataType = 6
+	UInt32   DataType = 7
+	UInt64   DataType = 8
+	Float    DataType = 9
+	Double   DataType = 10
+	Boolean  DataType = 11
+	String   DataType = 12
+	DateTime DataType = 13
+	Text     DataType = 14
+)
+
+// Payload 字段编号
+const (
+	payloadTimestamp = 1
+	payloadMetrics   = 2
+	payloadSeq       = 3
+)
+
+// Metric 字段编号
+const (
+	metricName         = 1
+	metricAlias        = 2
+	metricTimestamp    = 3
+	metricDatatype     = 4
+	metricIsHistorical = 5
+	metricIsNull       = 7
+	metricProperties   = 9
+	metricIntValue     = 10
+	metricLongValue    = 11
+	metricFloatValue   = 12
+	metricDoubleValue  = 13
+	metricBooleanValue = 14
+	metricStringValue  = 15
+)
+
+// PropertySet、PropertyValue 字段编号
+const (
+	propertySetKeys     = 1
+	propertySetValues   = 2
+	propertyType        = 1
+	propertyStringValue = 8
+)
+
+// Payload Sparkplug B 消息载荷
+type Payload struct {
+	Timestamp uint64
+	Metrics   []Metric
+	// Seq 消息序号，NDEATH 不包含序号
+	Seq    uint64
+	HasSeq bool
+}
+
+// Metric Sparkplug B 指标
+type Metric struct {
+	Name      string
+	Alias     uint64
+	Timestamp uint64
+	DataType  DataType
+	// Historical 是否为历史数据
+	Historical bool
+	// Value 指标值，nil 表示空值
+	Value interface{}
+	// Properties 指标属性，仅支持字符串类型，如：engUnit
+	Properties map[string]string
+}
+
+// Marshal 编码载荷
+func (p Payload) Marshal() ([]byte, error) {
+	var b []byte
+	b = protowire.AppendTag(b, payloadTimestamp, protowire.VarintType)
+	b = protowire.AppendVarint(b, p.Timestamp)
+	for _, m := range p.Metrics {
+		mb, err := m.marshal()
+		if err != nil {
+			return nil, fmt.Errorf("metric %s: %w", m.Name, err)
+		}
+		b = protowire.AppendTag(b, payloadMetrics, protowire.BytesType)
+		b = protowire.AppendBytes(b, mb)
+	}
+	if p.HasSeq {
+		b = protowire.AppendTag(b, payloadSeq, protowire.VarintType)
+		b = protowire.AppendVarint(b, p.Seq)
+	}
+	return b, nil
+}
+
+func (m Metric) marshal() ([]byte, error) {
+	var b []byte
+	if m.Name != "" {
+		b = protowire.AppendTag(b, metricName, protowire.BytesType)
+		b = protowire.AppendString(b, m.Name)
+	}
+	if m.Alias > 0 {
+		b = protowire.AppendTag(b, metricAlias, protowire.VarintType)
+		b = protowire.AppendVarint(b, m.Alias)
+	}
+	if m.Timestamp > 0 {
+		b = protowire.AppendTag(b, metricTimestamp, protowire.VarintType)
+		b = protowire.AppendVarint(b, m.Timestamp)
+	}
+	b = protowire.AppendTag(b, metricDatatype, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(m.DataType))
+	if m.Historical {
+		b = protowire.AppendTag(b, metricIsHistorical, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+	if len(m.Properties) > 0 {
+		b = protowire.AppendTag(b, metricProperties, protowire.BytesType)
+		b = protowire.AppendBytes(b, marshalProperties(m.Properties))
+	}
+	if m.Value == nil {
+		b = protowire.AppendTag(b, metricIsNull, protowire.VarintType)
+		return protowire.AppendVarint(b, 1), nil
+	}
+	switch m.DataType {
+	case Int8, Int16, Int32, UInt8, UInt16, UInt32:
+		v, err := toInt64(m.Value)
+		if err != nil {
+			return nil, err
+		}
+		b = protowire.AppendTag(b, metricIntValue, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(uint32(v)))
+	case Int64, UInt64, DateTime:
+		v, err := toInt64(m.Value)
+		if err != nil {
+			return nil, err
+		}
+		b = protowire.AppendTag(b, metricLongValue, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(v))
+	case Float:
+		v, err := toFloat64(m.Value)
+		if err != nil {
+			return nil, err
+		}
+		b = protowire.AppendTag(b, metricFloatValue, protowire.Fixed32Type)
+		b = protowire.AppendFixed32(b, math.Float32bits(float32(v)))
+	case Double:
+		v, err := toFloat64(m.Value)
+		if err != nil {
+			return nil, err
+		}
+		b = protowire.AppendTag(b, metricDoubleValue, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(v))
+	case Boolean:
+		v, ok := m.Value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("invalid boolean value: %v", m.Value)
+		}
+		b = protowire.AppendTag(b, metricBooleanValue, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeBool(v))
+	case String, Text:
+		b = protowire.AppendTag(b, metricStringValue, protowire.BytesType)
+		b = protowire.AppendString(b, toString(m.Value))
+	default:
+		return nil, fmt.Errorf("unsupported datatype: %d", m.DataType)
+	}
+	return b, nil
+}
+
+func marshalProperties(properties map[string]string) []byte {
+	// keys 与 values 按下标对应，需保证顺序一致
+	keys := make([]string, 0, len(properties))
+	for k := range properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b []byte
+	for _, k := range keys {
+		b = protowire.AppendTag(b, propertySetKeys, protowire.BytesType)
+		b = protowire.AppendString(b, k)
+	}
+	for _, k := range keys {
+		v := properties[k]
+		var pv []byte
+		pv = protowire.AppendTag(pv, propertyType, protowire.VarintType)
+		pv = protowire.AppendVarint(pv, uint64(String))
+		pv = protowire.AppendTag(pv, propertyStringValue, protowire.BytesType)
+		pv = protowire.AppendString(pv, v)
+		b = protowire.AppendTag(b, propertySetValues, protowire.BytesType)
+		b = protowire.AppendBytes(b, pv)
+	}
+	return b
+}
+
+// Unmarshal 解码载荷，忽略不支持的字段
+func Unmarshal(b []byte) (Payload, error) {
+	var p Payload
+	err := walk(b, func(num protowire.Number, typ protowire.Type, v uint64, bs []byte) error {
+		switch num {
+		case payloadTimestamp:
+			p.Timestamp = v
+		case payloadSeq:
+			p.Seq, p.HasSeq = v, true
+		case payloadMetrics:
+			m, err := unmarshalMetric(bs)
+			if err != nil {
+				return err
+			}
+			p.Metrics = append(p.Metrics, m)
+		}
+		return nil
+	})
+	return p, err
+}
+
+func unmarshalMetric(b []byte) (Metric, error) {
+	var m Metric
+	var isNull bool
+	err := walk(b, func(num protowire.Number, typ protowire.Type, v uint64, bs []byte) error {
+		switch num {
+		case metricName:
+			m.Name = string(bs)
+		case metricAlias:
+			m.Alias = v
+		case metricTimestamp:
+			m.Timestamp = v
+		case metricDatatype:
+			m.DataType = DataType(v)
+		case metricIsHistorical:
+			m.Historical = v != 0
+		case metricIsNull:
+			isNull = v != 0
+		case metricIntValue:
+			m.Value = int64(int32(uint32(v)))
+		case metricLongValue:
+			m.Value = int64(v)
+		case metricFloatValue:
+			m.Value = float64(math.Float32frombits(uint32(v)))
+		case metricDoubleValue:
+			m.Value = math.Float64frombits(v)
+		case metricBooleanValue:
+			m.Value = protowire.DecodeBool(v)
+		case metricStringValue:
+			m.Value = string(bs)
+		}
+		return nil
+	})
+	if isNull {
+		m.Value = nil
+	}
+	// 无符号类型按数据类型还原
+	if n, ok := m.Value.(int64); ok {
+		switch m.DataType {
+		case UInt8, UInt16, UInt32:
+			m.Value = int64(uint32(n))
+		case UInt64:
+			m.Value = uint64(n)
+		}
+	}
+	return m, err
+}
+
+// walk 遍历消息字段，变长与定长字段通过 v 返回，长度分隔字段通过 bs 返回
+func walk(b []byte, f func(num protowire.Number, typ protowire.Type, v uint64, bs []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		var v uint64
+		var bs []byte
+		switch typ {
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed32Type:
+			var v32 uint32
+			v32, n = protowire.ConsumeFixed32(b)
+			v = uint64(v32)
+		case protowire.Fixed64Type:
+			v, n = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			bs, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if err := f(num, typ, v, bs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toString 字符串类型的值，数组、对象等类型以 JSON 格式输出
+func toString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case []interface{}, map[string]interface{}:
+		if bs, err := json.Marshal(s); err == nil {
+			return string(bs)
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+func toInt64(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), nil
+	case int8:
+		return int64(n), nil
+	case int16:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case uint:
+		return int64(n), nil
+	case uint8:
+		return int64(n), nil
+	case uint16:
+		return int64(n), nil
+	case uint32:
+		return int64(n), nil
+	case uint64:
+		return int64(n), nil
+	case float32:
+		return int64(n), nil
+	case float64:
+		return int64(n), nil
+	case bool:
+		if n {
+			return 1, nil
+		}
+		return 0, nil
+	case time.Time:
+		return n.UnixMilli(), nil
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		if err != nil {
+			return 0, errors.New("invalid integer value")
+		}
+		return int64(f), nil
+	}
+	return 0, errors.New("invalid integer value")
+}
+
+func toFloat64(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float32:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		if err != nil {
+			return 0, errors.New("invalid float value")
+		}
+		return f, nil
+	}
+	i, err := toInt64(v)
+	if err != nil {
+		return 0, errors.New("invalid float value")
+	}
+	return float64(i), nil
+}
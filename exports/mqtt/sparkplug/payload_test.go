@@ -0,0 +1,39 @@
+package sparkplug
+
+import (
+	"testing"
+)
+
+func TestPayload_MarshalUnmarshal(t *testing.T) {
+	p := Payload{
+		Timestamp: 1700000000000,
+		Seq:       5,
+		HasSeq:    true,
+		Metrics: []Metric{
+			{Name: "temp", DataType: Double, Value: 25.5, Properties: map[string]string{"engUnit": "℃"}},
+			{Name: "count", DataType: Int64, Value: "42"},
+			{Name: "level", DataType: Int32, Value: -3},
+			{Name: "onOff", DataType: Boolean, Value: true},
+			{Name: "mode", DataType: String, Value: "cool"},
+			{Name: "ratio", DataType: Float, Value: float32(0.5)},
+			{Name: "empty", DataType: Int64},
+		},
+	}
+	b, err := p.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Timestamp != p.Timestamp || !got.HasSeq || got.Seq != 5 || len(got.Metrics) != len(p.Metrics) {
+		t.Fatalf("unexpected payload: %+v", got)
+	}
+	want := []interface{}{25.5, int64(42), int64(-3), true, "cool", 0.5, nil}
+	for i, m := range got.Metrics {
+		if m.Name != p.Metrics[i].Name || m.DataType != p.Metrics[i].DataType || m.Value != want[i] {
+			t.Errorf("metric[%d] = %+v, want value %v", i, m, want[i])
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package mqtt
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+)
+
+// TLSConfig MQTT TLS 证书配置
+type TLSConfig struct {
+	// CAFile CA 证书路径，为空时使用系统根证书
+	CAFile string `json:"caFile"`
+	// CertFile 客户端证书路径，双向认证时配置
+	CertFile string `json:"certFile"`
+	// KeyFile 客户端私钥路径，双向认证时配置
+	KeyFile string `json:"keyFile"`
+	// ServerName 校验的服务端证书名称，为空时使用 broker 地址
+	ServerName string `json:"serverName"`
+	// InsecureSkipVerify 跳过服务端证书校验，仅用于测试环境
+	InsecureSkipVerify bool `json:"insecureSkipVerify"`
+}
+
+func (c TLSConfig) build() (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if c.CAFile != "" {
+		ca, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("invalid ca file: " + c.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+func isTLS(scheme string) bool {
+	switch scheme {
+	case "ssl", "tls", "mqtts", "tcps":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package mqtt
+
+import (
+	"strings"
+
+	"github.com/ibuilding-x/driver-box/v2/driverbox"
+	"github.com/ibuilding-x/driver-box/v2/pkg/event"
+)
+
+// statusMessage 网关在线状态消息
+type statusMessage struct {
+	Online bool `json:"online"`
+}
+
+// eventMessage 设备事件消息
+type eventMessage struct {
+	Event     event.EventCode `json:"event"`
+	DeviceID  string          `json:"deviceId"`
+	Value     interface{}     `json:"value"`
+	Timestamp int64           `json:"timestamp"`
+}
+
+// telemetryTopic 设备遥测主题，优先级：DeviceTopics > ModelTopics > ExportTopic > TelemetryTopic
+func (export *MqttExport) telemetryTopic(deviceID string) string {
+	model := modelName(deviceID)
+	if tpl, ok := export.DeviceTopics[deviceID]; ok {
+		return export.topic(tpl, "", deviceID, model)
+	}
+	if tpl, ok := export.ModelTopics[model]; ok {
+		return export.topic(tpl, "", deviceID, model)
+	}
+	if export.ExportTopic != "" {
+		return export.ExportTopic
+	}
+	return export.topic(export.TelemetryTopic, defaultTelemetryTopic, deviceID, model)
+}
+
+// topic 渲染主题模板，模板为空时使用默认模板
+func (export *MqttExport) topic(tpl, defaultTpl, deviceID, modelName string) string {
+	if tpl == "" {
+		tpl = defaultTpl
+	}
+	return renderTopic(tpl, driverbox.GetMetadata().SerialNo, deviceID, modelName)
+}
+
+func renderTopic(tpl, serialNo, deviceID, modelName string) string {
+	return strings.NewReplacer(
+		"{serialNo}", serialNo,
+		"{deviceId}", deviceID,
+		"{modelName}", modelName,
+	).Replace(tpl)
+}
+
+func modelName(deviceID string) string {
+	if deviceID == "" {
+		return ""
+	}
+	device, ok := driverbox.CoreCache().GetDevice(deviceID)
+	if !ok {
+		return ""
+	}
+	return device.ModelName
+}
@@ -0,0 +1,10 @@
+package mqtt
+
+import "testing"
+
+func TestRenderTopic(t *testing.T) {
+	got := renderTopic(defaultTelemetryTopic, "gw01", "dev1", "meter")
+	if got != "driverbox/gw01/meter/dev1/telemetry" {
+		t.Fatalf("unexpected topic: %s", got)
+	}
+}
@@ -8,6 +8,10 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -126,6 +130,10 @@ type CoreCache interface {
 
 	// FlushAll 将所有插件的配置进行持久化刷新
 	FlushAll()
+
+	// WithSource 返回标记了变更来源的核心缓存，经由其产生的配置变更在版本历史中记录为该来源
+	// 参数: source - 变更来源，如 discover、mirror
+	WithSource(source string) CoreCache
 }
 
 var instance *cache
@@ -140,7 +148,13 @@ type cache struct {
 	models map[string]cacheModel
 	//连接换成
 	connections map[string]cacheConnection
-	mutex       *sync.RWMutex // 锁
+	//配置存储
+	store config.ConfigStore
+	//配置存储中各插件的版本号，用于检测外部修改
+	watchRevisions map[string]int64
+	//配置版本历史，配置存储支持时可用
+	history historyStore
+	mutex   *sync.RWMutex // 锁
 }
 
 func Get() CoreCache {
@@ -164,7 +178,16 @@ func InitCoreCache(plugins map[string]plugin.Plugin) (obj CoreCache, err error)
 			plugin: p,
 		}
 	}
-	err = instance.loadConfig(plugins)
+	if instance.store == nil {
+		if instance.store, err = newConfigStore(); err != nil {
+			return nil, err
+		}
+	}
+	//版本历史基于配置存储的版本号，存储不支持时不影响核心功能
+	if h, ok := instance.store.(historyStore); ok {
+		instance.history = h
+	}
+	err = instance.loadConfig()
 	if err != nil {
 		return nil, err
 	}
@@ -180,6 +203,11 @@ func InitCoreCache(plugins map[string]plugin.Plugin) (obj CoreCache, err error)
 			}
 		}
 	})
+	if err != nil {
+		return instance, err
+	}
+	//检测配置的外部修改并自动重载
+	_, err = crontab.Instance().AddFunc("5s", watchConfig)
 
 	return instance, err
 }
@@ -187,20 +215,22 @@ func InitCoreCache(plugins map[string]plugin.Plugin) (obj CoreCache, err error)
 func GetConfig(pluginName string) config.DeviceConfig {
 	instance.mutex.RLock()
 	defer instance.mutex.RUnlock()
-	return convertConfig(pluginName)
+	return instance.convertConfig(pluginName)
 }
-func convertConfig(pluginName string) config.DeviceConfig {
-	_, ok := instance.plugins[pluginName]
+
+// convertConfig 将核心缓存转换为插件配置，模型按名称、设备按ID排序
+func (c *cache) convertConfig(pluginName string) config.DeviceConfig {
+	_, ok := c.plugins[pluginName]
 	if !ok {
 		return config.DeviceConfig{}
 	}
-	models := make([]config.DeviceModel, 0, len(instance.models))
-	for _, model := range instance.models {
+	models := make([]config.DeviceModel, 0, len(c.models))
+	for _, model := range c.models {
 		if model.pluginName != pluginName {
 			continue
 		}
 		devices := make([]config.Device, 0)
-		for _, device := range instance.devices {
+		for _, device := range c.devices {
 			if device.ModelName == model.Name {
 				//补充校验逻辑，避免存在bug
 				if device.PluginName != pluginName {
@@ -209,13 +239,19 @@ func convertConfig(pluginName string) config.DeviceConfig {
 				devices = append(devices, device.Device)
 			}
 		}
+		sort.Slice(devices, func(i, j int) bool {
+			return devices[i].ID < devices[j].ID
+		})
 		models = append(models, config.DeviceModel{
 			Model:   model.Model,
 			Devices: devices,
 		})
 	}
+	sort.Slice(models, func(i, j int) bool {
+		return models[i].Name < models[j].Name
+	})
 	connections := make(map[string]interface{})
-	for key, connection := range instance.connections {
+	for key, connection := range c.connections {
 		if connection.pluginName == pluginName {
 			connections[key] = connection.connection
 		}
@@ -226,67 +262,43 @@ func convertConfig(pluginName string) config.DeviceConfig {
 		PluginName:   pluginName,
 	}
 }
-func (c *cache) loadConfig(plugins map[string]plugin.Plugin) error {
+func (c *cache) loadConfig() error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
-	driverPath := path.Join(config.ResourcePath, "driver")
-	// 自动创建配置目录
-	if err := createDir(driverPath); err != nil {
+	configs, invalid, err := c.readStore()
+	if err != nil {
 		return err
 	}
-
-	// 遍历配置目录，获取所有文件夹
-	dirs := getSubDirs(driverPath)
-	if len(dirs) <= 0 {
-		return nil
-	}
+	c.watchRevisions, _ = c.store.Revisions()
 
 	curTime := time.Now()
-	//config 协议唯一性
-	// 解析每个文件夹的配置
-	for i, _ := range dirs {
-		path := filepath.Join(driverPath, dirs[i], configFile)
-		cfg, err := parseConfigFromFile(path)
-		if err != nil {
-			if errors.Is(err, ErrConfigNotExist) {
-				continue
-			}
-			if errors.Is(err, ErrConfigEmpty) {
+	for name, cfg := range configs {
+		//跳过校验失败的模型、设备，其余配置照常加载
+		if errs, ok := invalid[name]; ok {
+			if cfg.DeviceConfig, ok = dropInvalid(cfg.DeviceConfig, errs); !ok {
+				logger.Logger.Error("config invalid, skip plugin", zap.String("plugin", name), zap.String("source", cfg.Source))
+				delete(configs, name)
 				continue
 			}
-			logger.Logger.Error("parse config from file error", zap.String("path", path), zap.Error(err))
-			return err
 		}
-
-		p, ok := plugins[cfg.PluginName]
-		if !ok {
-			return errors.New("plugin " + cfg.PluginName + " not found")
-		}
-		//相同插件不允许存在多个文件中
-		pl, ok := c.plugins[cfg.PluginName]
-		if !ok {
-			return errors.New("plugin " + cfg.PluginName + " unSupport!")
-		}
-		if pl.plugin != p {
-			return errors.New("invalid plugin " + cfg.PluginName + "!")
-		}
-		if len(pl.FilePath) > 0 && pl.FilePath != path {
-			return errors.New("plugin " + cfg.PluginName + " already exists in " + pl.FilePath)
-		}
-
 		//构建coreCache的缓存结构
-		pl.FilePath = path
+		pl := c.plugins[cfg.PluginName]
+		pl.revision = cfg.Revision
 		pl.fileModifyTime = curTime
 		pl.cacheModifyTime = curTime
 		c.plugins[cfg.PluginName] = pl
 
 		for _, model := range cfg.DeviceModels {
+			if m, ok := c.models[model.Name]; ok {
+				logger.Logger.Error("model exists, skip it", zap.String("model", model.Name), zap.String("protocol", m.pluginName), zap.String("plugin", cfg.PluginName))
+				continue
+			}
 			for _, device := range model.Devices {
 				if device.ID == "" {
 					logger.Logger.Error("config error , device id is empty", zap.Any("device", device))
 					continue
 				}
-				_, ok = c.devices[device.ID]
+				_, ok := c.devices[device.ID]
 				if ok {
 					logger.Logger.Error("device exists！", zap.Any("device", device))
 					continue
@@ -297,25 +309,9 @@ func (c *cache) loadConfig(plugins map[string]plugin.Plugin) error {
 					device,
 				}
 			}
-			points := make(map[string]*config.Point)
-			for k, _ := range model.DevicePoints {
-				point := &model.DevicePoints[k]
-				checkPoint(&model.Model, point)
-				points[point.Name()] = point
-			}
 			//释放内存
 			model.Devices = nil
-			m, ok := c.models[model.Name]
-			if ok {
-				logger.Logger.Error("model exists！", zap.Any("model", m), zap.String("protocol", m.pluginName))
-				return errors.New("model " + model.Name + " already exists")
-			}
-			c.models[model.Name] = cacheModel{
-				Model:      model.Model,
-				pluginName: cfg.PluginName,
-				points:     points,
-			}
-
+			c.models[model.Name] = newCacheModel(cfg.PluginName, model.Model)
 		}
 		for key, connection := range cfg.Connections {
 			c.connections[key] = cacheConnection{
@@ -326,6 +322,88 @@ func (c *cache) loadConfig(plugins map[string]plugin.Plugin) error {
 	}
 	return nil
 }
+
+// readStore 读取并校验配置存储中的全部插件配置，key 为插件名称
+// 校验失败的插件输出全部错误，并返回各插件的校验错误，由调用方决定跳过的范围
+func (c *cache) readStore() (map[string]config.StoredConfig, map[string]config.ValidationErrors, error) {
+	configs, err := c.store.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	invalid := make(map[string]config.ValidationErrors)
+	for name, cfg := range configs {
+		pl, ok := c.plugins[name]
+		if !ok {
+			return nil, nil, errors.New("plugin " + name + " not found")
+		}
+		if errs := config.ValidateDeviceConfig(cfg.DeviceConfig, pointValidator(pl.plugin)).WithFile(cfg.Source); len(errs) > 0 {
+			for _, e := range errs {
+				logger.Logger.Error("config validate error", zap.String("file", e.File), zap.String("path", e.Path), zap.String("message", e.Message))
+			}
+			invalid[name] = errs
+		}
+	}
+	return configs, invalid, nil
+}
+
+// invalidPath 校验错误路径中的模型、设备下标
+var invalidPath = regexp.MustCompile(`^\$\.deviceModels\[(\d+)\](?:\.devices\[(\d+)\])?`)
+
+// dropInvalid 剔除校验失败的模型及设备，存在模型之外的错误时返回 false，整个插件配置不可用
+func dropInvalid(cfg config.DeviceConfig, errs config.ValidationErrors) (config.DeviceConfig, bool) {
+	models := make(map[int]bool)
+	devices := make(map[[2]int]bool)
+	for _, e := range errs {
+		m := invalidPath.FindStringSubmatch(e.Path)
+		if m == nil {
+			return cfg, false
+		}
+		i, _ := strconv.Atoi(m[1])
+		if m[2] == "" {
+			models[i] = true
+			continue
+		}
+		j, _ := strconv.Atoi(m[2])
+		devices[[2]int{i, j}] = true
+	}
+	result := cfg
+	result.DeviceModels = make([]config.DeviceModel, 0, len(cfg.DeviceModels))
+	for i, model := range cfg.DeviceModels {
+		if models[i] {
+			logger.Logger.Error("config invalid, skip model", zap.String("plugin", cfg.PluginName), zap.String("model", model.Name))
+			continue
+		}
+		model.Devices = make([]config.Device, 0, len(cfg.DeviceModels[i].Devices))
+		for j, device := range cfg.DeviceModels[i].Devices {
+			if devices[[2]int{i, j}] {
+				logger.Logger.Error("config invalid, skip device", zap.String("plugin", cfg.PluginName), zap.String("device", device.ID))
+				continue
+			}
+			model.Devices = append(model.Devices, device)
+		}
+		result.DeviceModels = append(result.DeviceModels, model)
+	}
+	return result, true
+}
+
+func newCacheModel(pluginName string, model config.Model) cacheModel {
+	points := make(map[string]*config.Point)
+	for k := range model.DevicePoints {
+		point := &model.DevicePoints[k]
+		checkPoint(&model, point)
+		points[point.Name()] = point
+	}
+	return cacheModel{
+		Model:      model,
+		pluginName: pluginName,
+		points:     points,
+	}
+}
+
+func driverDir() string {
+	return path.Join(config.ResourcePath, "driver")
+}
+
 func parseConfigFromFile(path string) (config.DeviceConfig, error) {
 	if !fileutil.FileExists(path) {
 		return config.DeviceConfig{}, ErrConfigNotExist
@@ -342,11 +420,47 @@ func parseConfigFromFile(path string) (config.DeviceConfig, error) {
 	// json 解析
 	var c config.DeviceConfig
 	if err = json.Unmarshal(bytes, &c); err != nil {
-		return config.DeviceConfig{}, err
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return config.DeviceConfig{}, config.ValidationError{
+				File:    path,
+				Path:    "$." + typeErr.Field,
+				Message: fmt.Sprintf("must be %s, got %s", typeErr.Type, typeErr.Value),
+			}
+		}
+		return config.DeviceConfig{}, fmt.Errorf("%s: %w", path, err)
 	}
 	return c, nil
 }
 
+// pointValidator 获取插件的点位扩展字段校验函数，插件未实现时返回 nil
+// 插件不支持写入校验时，同时拒绝点位的 writePolicy 配置
+func pointValidator(p plugin.Plugin) config.PointValidator {
+	v, ok := p.(plugin.PointValidator)
+	if SupportWritePolicy(p) {
+		if ok {
+			return v.ValidatePoint
+		}
+		return nil
+	}
+	return func(point config.Point) config.ValidationErrors {
+		var errs config.ValidationErrors
+		if point.WritePolicy() != config.WritePolicy_None {
+			errs = append(errs, config.ValidationError{Path: "writePolicy", Message: "is not supported by this plugin"})
+		}
+		if ok {
+			errs = append(errs, v.ValidatePoint(point)...)
+		}
+		return errs
+	}
+}
+
+// SupportWritePolicy 插件是否支持点位的 writePolicy 配置
+func SupportWritePolicy(p plugin.Plugin) bool {
+	v, ok := p.(plugin.WriteVerifier)
+	return ok && v.SupportWritePolicy()
+}
+
 // createDir 创建目录
 func createDir(path string) error {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
@@ -370,30 +484,11 @@ func getSubDirs(driverPath string) []string {
 	return dirs
 }
 
-// 检查点位配置合法性
+// 检查点位配置完整性，配置合法性由 config.ValidateDeviceConfig 校验
 func checkPoint(model *config.Model, point *config.Point) {
-	if point.Name() == "" {
-		logger.Logger.Error("config error , point name is empty", zap.Any("point", point), zap.String("modelName", model.Name))
-	}
 	if point.Description() == "" {
 		logger.Logger.Warn("config error , point description is empty", zap.Any("point", point), zap.String("model", model.Name))
 	}
-	valueType := point.ValueType()
-	if valueType != config.ValueType_Float && valueType != config.ValueType_Int && valueType != config.ValueType_String {
-		logger.Logger.Error("point valueType config error , valid config is: int float string", zap.Any("point", point), zap.String("model", model.Name))
-	}
-	reportModel := point.ReportMode()
-	readWrite := point.ReadWrite()
-	if readWrite != config.ReadWrite_RW && readWrite != config.ReadWrite_R && readWrite != config.ReadWrite_W {
-		logger.Logger.Error("point readWrite config error , valid config is: R W RW", zap.Any("point", point), zap.String("model", model.Name))
-	}
-	if reportModel != config.ReportMode_Real && reportModel != config.ReportMode_Change {
-		logger.Logger.Error("point reportMode config error , valid config is: realTime change period", zap.Any("point", point), zap.String("model", model.Name))
-	}
-	//存在精度换算时，点位类型要求float
-	if point.Scale() != 0 && point.Scale() != 1 && valueType != config.ValueType_Float {
-		logger.Logger.Error("point scale config error , valid config is: float", zap.Any("point", point), zap.String("model", model.Name))
-	}
 }
 func (c *cache) GetModel(modelName string) (config.Model, bool) {
 	c.mutex.RLock()
@@ -404,7 +499,7 @@ func (c *cache) GetModel(modelName string) (config.Model, bool) {
 	}
 	return config.Model{}, false
 }
-func (c *cache) DeleteModel(modelName string) error {
+func (c *cache) deleteModel(modelName string, source string) error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	model, ok := c.models[modelName]
@@ -419,6 +514,7 @@ func (c *cache) DeleteModel(modelName string) error {
 	}
 	delete(c.models, modelName)
 	c.flushable(model.pluginName)
+	c.record(model.pluginName, "deleteModel", modelName, source)
 	return nil
 }
 
@@ -517,8 +613,8 @@ func (c *cache) GetAllRunningPluginKey() []string {
 	return keys
 }
 
-// UpdateDeviceProperty 更新设备属性并持久化
-func (c *cache) UpdateDeviceProperty(id string, key string, value string) error {
+// updateDeviceProperty 更新设备属性并持久化
+func (c *cache) updateDeviceProperty(id string, key string, value string, source string) error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	dev, ok := c.devices[id]
@@ -529,20 +625,22 @@ func (c *cache) UpdateDeviceProperty(id string, key string, value string) error
 		dev.Properties = make(map[string]string)
 	}
 	dev.Properties[key] = value
+	c.devices[id] = dev
 	c.flushable(dev.PluginName)
+	c.record(dev.PluginName, "updateDeviceProperty", id, source)
 	return nil
 }
 
-// DeleteDevice 删除设备
-func (c *cache) DeleteDevice(id string) {
-	e := c.BatchRemoveDevice([]string{id})
+// deleteDevice 删除设备
+func (c *cache) deleteDevice(id string, source string) {
+	e := c.batchRemoveDevice([]string{id}, source)
 	if e != nil {
 		logger.Logger.Error("remove device error", zap.String("id", id))
 	}
 }
 
-// UpdateDeviceDesc 更新设备描述
-func (c *cache) UpdateDeviceDesc(id string, desc string) error {
+// updateDeviceDesc 更新设备描述
+func (c *cache) updateDeviceDesc(id string, desc string, source string) error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	dev, ok := c.devices[id]
@@ -550,7 +648,9 @@ func (c *cache) UpdateDeviceDesc(id string, desc string) error {
 		return errors.New("device " + id + " not found")
 	}
 	dev.Description = desc
+	c.devices[id] = dev
 	c.flushable(dev.PluginName)
+	c.record(dev.PluginName, "updateDeviceDesc", id, source)
 	return nil
 }
 
@@ -571,14 +671,23 @@ func Reset() {
 	instance.devices = make(map[string]cacheDevice)
 	instance.models = make(map[string]cacheModel)
 	instance.connections = make(map[string]cacheConnection)
+	instance.watchRevisions = nil
+	//自定义配置存储由调用方管理
+	if instance.store != nil && instance.store != customStore {
+		if err := instance.store.Close(); err != nil {
+			logger.Logger.Error("close config store error", zap.Error(err))
+		}
+	}
+	instance.store = nil
+	instance.history = nil
 }
 
-// AddOrUpdateDevice 添加或更新设备
+// addOrUpdateDevice 添加或更新设备
 // 更新内容列表
 // * 核心缓存设备
 // * 设备影子
 // * 持久化文件
-func (c *cache) AddOrUpdateDevice(dev config.Device) error {
+func (c *cache) addOrUpdateDevice(dev config.Device, source string) error {
 	if len(dev.ModelName) == 0 {
 		return errors.New("device modelName is empty")
 	}
@@ -591,6 +700,13 @@ func (c *cache) AddOrUpdateDevice(dev config.Device) error {
 	}
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
+	errs := config.ValidateDevice(dev, nil)
+	if _, ok := c.connections[dev.ConnectionKey]; dev.ConnectionKey != "" && !ok {
+		errs = append(errs, config.ValidationError{Path: "connectionKey", Message: fmt.Sprintf("unknown connection %q", dev.ConnectionKey)})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
 	logger.Logger.Info("core cache add device", zap.Any("device", dev))
 
 	//未匹配到模型
@@ -625,11 +741,12 @@ func (c *cache) AddOrUpdateDevice(dev config.Device) error {
 		shadow.Shadow().AddDevice(dev.ID, dev.ModelName)
 	}
 	c.flushable(model.pluginName)
+	c.record(model.pluginName, "addOrUpdateDevice", dev.ID, source)
 	return nil
 }
 
-// AddConnection 新增连接
-func (c *cache) AddConnection(plugin string, key string, conn any) error {
+// addConnection 新增连接
+func (c *cache) addConnection(plugin string, key string, conn any, source string) error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	_, ok := c.plugins[plugin]
@@ -645,6 +762,7 @@ func (c *cache) AddConnection(plugin string, key string, conn any) error {
 		pluginName: plugin,
 	}
 	c.flushable(plugin)
+	c.record(plugin, "addConnection", key, source)
 	return nil
 }
 
@@ -658,7 +776,7 @@ func (c *cache) GetConnection(key string) (string, any) {
 	return "", nil
 }
 
-func (c *cache) DeleteConnection(key string) error {
+func (c *cache) deleteConnection(key string, source string) error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	conn, ok := c.connections[key]
@@ -673,17 +791,21 @@ func (c *cache) DeleteConnection(key string) error {
 	}
 	delete(c.connections, key)
 	c.flushable(conn.pluginName)
+	c.record(conn.pluginName, "deleteConnection", key, source)
 	return nil
 }
 
-// AddModel 新增模型
-func (c *cache) AddModel(pluginName string, model config.Model) error {
+// addModel 新增模型
+func (c *cache) addModel(pluginName string, model config.Model, source string) error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
-	_, exists := c.plugins[pluginName]
+	p, exists := c.plugins[pluginName]
 	if !exists {
 		return errors.New("plugin " + pluginName + " not exists")
 	}
+	if err := config.ValidateModel(model, pointValidator(p.plugin)).Err(); err != nil {
+		return err
+	}
 	old, ok := c.models[model.Name]
 	if ok {
 		if old.pluginName != pluginName {
@@ -701,11 +823,16 @@ func (c *cache) AddModel(pluginName string, model config.Model) error {
 		points:     points,
 	}
 	c.flushable(pluginName)
+	c.record(pluginName, "addModel", model.Name, source)
 	return nil
 }
 
-// BatchRemoveDevice 批量删除设备
-func (c *cache) BatchRemoveDevice(ids []string) error {
+// batchRemoveDevice 批量删除设备
+func (c *cache) batchRemoveDevice(ids []string, source string) error {
+	//先通知设备删除，再更新缓存。Export 同步处理事件时可能回查缓存，不可持有锁
+	for _, id := range ids {
+		export.TriggerEvents(event.DeviceDeleting, id, nil)
+	}
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	plugins := make(map[string]string)
@@ -713,7 +840,6 @@ func (c *cache) BatchRemoveDevice(ids []string) error {
 		if dev, ok := c.devices[id]; ok {
 			plugins[dev.PluginName] = dev.PluginName
 		}
-		export.TriggerEvents(event.DeviceDeleting, id, nil)
 		delete(c.devices, id)
 	}
 	nowUnix := time.Now()
@@ -721,6 +847,7 @@ func (c *cache) BatchRemoveDevice(ids []string) error {
 		cfg := c.plugins[p]
 		cfg.cacheModifyTime = nowUnix
 		c.plugins[p] = cfg
+		c.record(p, "batchRemoveDevice", strings.Join(ids, ","), source)
 	}
 	// 删除设备影子
 	_ = shadow.Shadow().DeleteDevice(ids...)
@@ -730,47 +857,58 @@ func (c *cache) BatchRemoveDevice(ids []string) error {
 func (c *cache) Flush(pluginName string) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
+	c.flush(pluginName, change{action: "flush", source: defaultSource})
+}
+
+// flush 将插件配置写入配置存储，需持有锁；配置存储支持版本历史时同时记录本次变更
+func (c *cache) flush(pluginName string, ch change) {
 	p := c.plugins[pluginName]
-	cfg := convertConfig(pluginName)
+	cfg := c.convertConfig(pluginName)
 
 	//闲置插件
-	if len(cfg.Connections) == 0 && len(cfg.Connections) == 0 {
+	if len(cfg.Connections) == 0 {
 		//无接入配置，需删除
-		if len(p.FilePath) > 0 {
-			err := os.Remove(p.FilePath)
+		if p.revision != 0 {
+			var err error
+			if c.history != nil {
+				err = c.history.deleteChange(pluginName, ch)
+			} else {
+				err = c.store.Delete(pluginName)
+			}
 			if err != nil {
-				logger.Logger.Error("remove file error", zap.String("file", p.FilePath), zap.Error(err))
+				logger.Logger.Error("delete config error", zap.String("plugin", pluginName), zap.Error(err))
+				return
 			}
 			//避免重复删除
-			p.FilePath = ""
+			delete(c.watchRevisions, pluginName)
+			p.revision = 0
 			p.fileModifyTime = time.Now()
 			c.plugins[pluginName] = p
 		}
 		return
 	}
 
-	if p.FilePath == "" {
-		p.FilePath = path.Join(config.ResourcePath, "driver", pluginName, configFile)
+	var revision int64
+	var err error
+	if c.history != nil {
+		revision, err = c.history.saveChange(cfg, ch)
+	} else {
+		revision, err = c.store.Save(cfg)
 	}
-	bytes, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
-		logger.Logger.Error("marshal config error", zap.Error(err))
-		return
-	}
-	// 确保目录存在
-	dir := path.Dir(p.FilePath)
-	if err := createDir(dir); err != nil {
-		logger.Logger.Error("create directory error", zap.String("dir", dir), zap.Error(err))
+		logger.Logger.Error("save config error", zap.String("plugin", pluginName), zap.Error(err))
 		return
 	}
-	err = os.WriteFile(p.FilePath, bytes, 0644)
-	if err != nil {
-		logger.Logger.Error("write config file error", zap.Error(err))
-		return
+	//记录版本号，避免被识别为外部修改
+	p.revision = revision
+	if c.watchRevisions == nil {
+		c.watchRevisions = make(map[string]int64)
 	}
+	c.watchRevisions[pluginName] = revision
 	p.fileModifyTime = time.Now()
 	c.plugins[pluginName] = p
 }
+
 func (c *cache) FlushAll() {
 	c.mutex.RLock()
 	keys := make([]string, 0, len(c.plugins))
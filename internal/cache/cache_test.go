@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
+	"github.com/ibuilding-x/driver-box/v2/internal/logger"
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+	"go.uber.org/zap"
+)
+
+func TestDropInvalid(t *testing.T) {
+	logger.Logger = zap.NewNop()
+	cfg := config.DeviceConfig{
+		PluginName:  "modbus",
+		Connections: map[string]interface{}{"line1": map[string]interface{}{}},
+		DeviceModels: []config.DeviceModel{
+			{
+				Model:   config.Model{Name: "m1", DevicePoints: []config.Point{{"name": "p1", "valueType": "double", "readWrite": "R"}}},
+				Devices: []config.Device{{ID: "d1", ConnectionKey: "line1"}},
+			},
+			{
+				Model: config.Model{Name: "m2", DevicePoints: []config.Point{{"name": "p1", "valueType": "int", "readWrite": "R"}}},
+				Devices: []config.Device{
+					{ID: "d2", ConnectionKey: "line1"},
+					{ID: "d3", ConnectionKey: "line2"},
+				},
+			},
+		},
+	}
+
+	//模型 m1 的点位类型错误、设备 d3 的连接不存在，其余配置保留
+	errs := config.ValidateDeviceConfig(cfg, nil)
+	result, ok := dropInvalid(cfg, errs)
+	if !ok || len(result.DeviceModels) != 1 || result.DeviceModels[0].Name != "m2" {
+		t.Fatalf("result = %+v, ok = %v, errs = %v", result, ok, errs)
+	}
+	if devices := result.DeviceModels[0].Devices; len(devices) != 1 || devices[0].ID != "d2" {
+		t.Fatalf("devices = %+v", devices)
+	}
+	if len(cfg.DeviceModels) != 2 || len(cfg.DeviceModels[1].Devices) != 2 {
+		t.Fatal("original config should not be modified")
+	}
+
+	//模型之外的错误使整个插件配置不可用
+	cfg.PluginName = ""
+	if _, ok = dropInvalid(cfg, config.ValidateDeviceConfig(cfg, nil)); ok {
+		t.Fatal("config without protocolName should be skipped")
+	}
+}
+
+type verifyPlugin struct {
+	plugin.Plugin
+}
+
+func (verifyPlugin) SupportWritePolicy() bool {
+	return true
+}
+
+func TestPointValidator(t *testing.T) {
+	point := config.Point{"name": "p1", "valueType": "int", "readWrite": "RW", "writePolicy": "verify"}
+	//未实现写入校验的插件不允许配置 writePolicy
+	if errs := pointValidator(nil)(point); len(errs) != 1 || errs[0].Path != "writePolicy" {
+		t.Fatalf("errs = %v", errs)
+	}
+	if errs := pointValidator(nil)(config.Point{"name": "p1", "writePolicy": "none"}); len(errs) != 0 {
+		t.Fatalf("errs = %v", errs)
+	}
+	if v := pointValidator(verifyPlugin{}); v != nil {
+		t.Fatal("plugin supports writePolicy should not be validated")
+	}
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"errors"
+
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+)
+
+// 默认变更来源
+const defaultSource = "core"
+
+var (
+	// ErrRevisionNotExist 配置版本不存在
+	ErrRevisionNotExist = errors.New("config revision not exist")
+	// errHistoryDisabled 配置存储不支持版本历史
+	errHistoryDisabled = errors.New("config history is disabled, sqlite config store is required")
+)
+
+// Revision 配置版本，核心缓存的每次配置变更生成一个版本
+type Revision struct {
+	// 版本号，即配置存储的全局版本号
+	ID         int64  `json:"id"`
+	PluginName string `json:"pluginName"`
+	// 变更操作，如 addOrUpdateDevice、deleteModel、reload、rollback
+	Action string `json:"action"`
+	// 变更对象，如设备ID、模型名称
+	Target string `json:"target,omitempty"`
+	// 变更来源，如 discover、mirror、rest
+	Source string `json:"source"`
+	// 变更时间，毫秒时间戳
+	Time int64 `json:"time"`
+}
+
+// change 配置变更的操作、对象及来源
+type change struct {
+	action string
+	target string
+	source string
+}
+
+// historyStore 支持版本历史的配置存储，变更后的完整配置与版本号在同一事务内保存
+type historyStore interface {
+	config.ConfigStore
+	// saveChange 保存插件配置并记录变更，配置未实际变化时不生成版本
+	saveChange(c config.DeviceConfig, ch change) (int64, error)
+	// deleteChange 删除插件配置并记录变更
+	deleteChange(pluginName string, ch change) error
+	// list 查询版本列表，按版本号倒序排列，pluginName 为空时查询全部插件
+	list(pluginName string, limit int) ([]Revision, error)
+	// configs 查询指定版本时各插件的完整配置，key 为插件名称
+	configs(revision int64) (map[string]config.DeviceConfig, error)
+}
+
+// Revisions 查询配置版本列表，按版本号倒序排列
+// pluginName 为空时查询全部插件，limit 小于等于 0 时不限制数量
+func Revisions(pluginName string, limit int) ([]Revision, error) {
+	if instance.history == nil {
+		return nil, errHistoryDisabled
+	}
+	return instance.history.list(pluginName, limit)
+}
+
+// Diff 比对两个版本之间各插件的模型、设备、连接变更，key 为插件名称，无变化的插件不返回
+func Diff(from, to int64) (map[string]*ConfigDelta, error) {
+	if instance.history == nil {
+		return nil, errHistoryDisabled
+	}
+	fromConfigs, err := instance.history.configs(from)
+	if err != nil {
+		return nil, err
+	}
+	toConfigs, err := instance.history.configs(to)
+	if err != nil {
+		return nil, err
+	}
+	return diffConfigs(fromConfigs, toConfigs), nil
+}
+
+// diffConfigs 比对两组插件配置，缺失的插件视为空配置
+func diffConfigs(fromConfigs, toConfigs map[string]config.DeviceConfig) map[string]*ConfigDelta {
+	names := make(map[string]bool)
+	for name := range fromConfigs {
+		names[name] = true
+	}
+	for name := range toConfigs {
+		names[name] = true
+	}
+	deltas := make(map[string]*ConfigDelta)
+	for name := range names {
+		delta := diffDeviceConfig(fromConfigs[name], toConfigs[name])
+		if delta.Empty() {
+			continue
+		}
+		//版本比对不涉及连接重启
+		delta.RestartConnections = nil
+		deltas[name] = delta
+	}
+	return deltas
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ibuilding-x/driver-box/v2/internal/logger"
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+	"go.uber.org/zap"
+)
+
+func TestHistory(t *testing.T) {
+	logger.Logger = zap.NewNop()
+	t.Setenv(config.ENV_CONFIG_HISTORY_MAX_COUNT, "3")
+	dir := t.TempDir()
+	s, err := newSQLiteStore(filepath.Join(dir, "config.db"), filepath.Join(dir, "driver"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	device := func(ids ...string) config.DeviceConfig {
+		cfg := config.DeviceConfig{
+			PluginName:   "modbus",
+			DeviceModels: []config.DeviceModel{{Model: config.Model{Name: "m1"}}},
+			Connections:  map[string]interface{}{"line1": map[string]interface{}{"address": "/dev/ttyS1"}},
+		}
+		for _, id := range ids {
+			cfg.DeviceModels[0].Devices = append(cfg.DeviceModels[0].Devices, config.Device{ID: id, ConnectionKey: "line1"})
+		}
+		return cfg
+	}
+	save := func(cfg config.DeviceConfig, action, target, source string) int64 {
+		revision, err := s.saveChange(cfg, change{action: action, target: target, source: source})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return revision
+	}
+	save(device("d1"), "addOrUpdateDevice", "d1", "rest:admin")
+	if err = s.deleteChange("bacnet", change{action: "deleteConnection", target: "bacnet", source: defaultSource}); err != nil {
+		t.Fatal(err)
+	}
+	//配置未变化时不生成版本
+	if revision := save(device("d1"), "addOrUpdateDevice", "d1", defaultSource); revision != 1 {
+		t.Fatalf("unchanged config revision = %d", revision)
+	}
+	save(device("d1", "d2"), "addOrUpdateDevice", "d2", "discover")
+	save(device("d2"), "deleteDevice", "d1", defaultSource)
+	save(device("d2", "d3"), "addOrUpdateDevice", "d3", defaultSource)
+	//版本号即配置存储的全局版本号
+	if revision := save(device("d3"), "deleteDevice", "d2", defaultSource); revision != 6 || s.Revision() != 6 {
+		t.Fatalf("revision = %d, store revision = %d", revision, s.Revision())
+	}
+	if configs, _ := s.Load(); configs["modbus"].Revision != 6 {
+		t.Fatalf("configs = %+v", configs)
+	}
+
+	revisions, err := s.list("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//超出数量后清理过期版本，保留各插件的基线版本
+	if len(revisions) != 5 || revisions[0].ID != 6 || revisions[0].Source != defaultSource || revisions[4].PluginName != "bacnet" {
+		t.Fatalf("revisions = %+v", revisions)
+	}
+	if _, err = s.configs(1); err != ErrRevisionNotExist {
+		t.Fatalf("configs(1) error = %v", err)
+	}
+
+	from, _ := s.configs(4)
+	to, _ := s.configs(6)
+	deltas := diffConfigs(from, to)
+	if d := deltas["modbus"]; len(deltas) != 1 || len(d.AddedDevices) != 1 || d.AddedDevices[0] != "d3" ||
+		len(d.RemovedDevices) != 1 || d.RemovedDevices[0] != "d2" || d.RestartConnections != nil {
+		t.Fatalf("deltas = %+v", deltas)
+	}
+	if len(to["bacnet"].DeviceModels) != 0 || to["bacnet"].PluginName != "bacnet" {
+		t.Fatalf("bacnet config = %+v", to["bacnet"])
+	}
+}
@@ -9,8 +9,8 @@ import (
 
 type cachePlugin struct {
 	plugin plugin.Plugin
-	// 配置文件路径
-	FilePath string `json:"-" validate:"-"`
+	// 配置存储中的版本号，为 0 表示未存储
+	revision int64
 
 	fileModifyTime  time.Time
 	cacheModifyTime time.Time
@@ -0,0 +1,442 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
+	"github.com/ibuilding-x/driver-box/v2/internal/export"
+	"github.com/ibuilding-x/driver-box/v2/internal/logger"
+	"github.com/ibuilding-x/driver-box/v2/internal/shadow"
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+	"github.com/ibuilding-x/driver-box/v2/pkg/event"
+	"go.uber.org/zap"
+)
+
+// reloadMutex 保证同一时刻只有一个配置重载任务
+var reloadMutex sync.Mutex
+
+// ConfigDelta 单个插件的配置变更
+type ConfigDelta struct {
+	AddedModels        []string `json:"addedModels,omitempty"`
+	UpdatedModels      []string `json:"updatedModels,omitempty"`
+	RemovedModels      []string `json:"removedModels,omitempty"`
+	AddedDevices       []string `json:"addedDevices,omitempty"`
+	UpdatedDevices     []string `json:"updatedDevices,omitempty"`
+	RemovedDevices     []string `json:"removedDevices,omitempty"`
+	AddedConnections   []string `json:"addedConnections,omitempty"`
+	UpdatedConnections []string `json:"updatedConnections,omitempty"`
+	RemovedConnections []string `json:"removedConnections,omitempty"`
+	// 受变更影响需要重启的连接
+	RestartConnections []string `json:"restartConnections,omitempty"`
+
+	stored config.StoredConfig
+}
+
+// Empty 是否无任何变更
+func (d *ConfigDelta) Empty() bool {
+	return len(d.AddedModels)+len(d.UpdatedModels)+len(d.RemovedModels)+
+		len(d.AddedDevices)+len(d.UpdatedDevices)+len(d.RemovedDevices)+
+		len(d.AddedConnections)+len(d.UpdatedConnections)+len(d.RemovedConnections) == 0
+}
+
+// Reload 重新加载配置存储中发生变化的插件配置，如被编辑的 config.json
+// 与核心缓存比对后仅应用增量：增删设备影子并触发 DeviceAdded、DeviceDeleting 事件，
+// 只重启受影响的插件连接，未变化的插件不受影响。返回各插件的配置变更，key 为插件名称
+// source 为变更来源，记录于配置版本历史
+func Reload(source string) (map[string]*ConfigDelta, error) {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+	return reload("reload", "", source)
+}
+
+// Rollback 将配置回滚至指定版本，回滚后的配置写入配置存储并经由重载流程生效，同时生成新的版本
+func Rollback(revision int64, source string) (map[string]*ConfigDelta, error) {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+	if instance.history == nil {
+		return nil, errHistoryDisabled
+	}
+	configs, err := instance.history.configs(revision)
+	if err != nil {
+		return nil, err
+	}
+	target := strconv.FormatInt(revision, 10)
+	if err = instance.saveConfigs(configs, change{action: "rollback", target: target, source: source}); err != nil {
+		return nil, err
+	}
+	return reload("rollback", target, source)
+}
+
+// saveConfigs 将与核心缓存不一致的插件配置写入配置存储并记录变更，该版本时尚无记录的插件保持不变
+func (c *cache) saveConfigs(configs map[string]config.DeviceConfig, ch change) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := time.Now()
+	for name, pl := range c.plugins {
+		cfg, ok := configs[name]
+		if !ok {
+			continue
+		}
+		if diffDeviceConfig(c.convertConfig(name), cfg).Empty() {
+			continue
+		}
+		var err error
+		if len(cfg.Connections) == 0 {
+			err = c.history.deleteChange(name, ch)
+		} else {
+			_, err = c.history.saveChange(cfg, ch)
+		}
+		if err != nil {
+			return err
+		}
+		//缓存中未保存的修改被覆盖，避免定时任务再次写入
+		pl.fileModifyTime = now
+		c.plugins[name] = pl
+	}
+	return nil
+}
+
+// reload 重载配置并以指定的操作记录版本，需持有 reloadMutex
+func reload(action, target, source string) (map[string]*ConfigDelta, error) {
+	deltas, err := instance.diffStore()
+	if err != nil {
+		return nil, err
+	}
+	if len(deltas) == 0 {
+		return deltas, nil
+	}
+
+	//先通知设备删除，再更新缓存
+	for _, delta := range deltas {
+		for _, id := range delta.RemovedDevices {
+			export.TriggerEvents(event.DeviceDeleting, id, nil)
+		}
+	}
+	plugins := instance.applyDeltas(deltas, action, target, source)
+
+	for _, delta := range deltas {
+		if len(delta.RemovedDevices) > 0 {
+			_ = shadow.Shadow().DeleteDevice(delta.RemovedDevices...)
+		}
+	}
+	for name, delta := range deltas {
+		for _, id := range delta.AddedDevices {
+			dev, _ := instance.GetDevice(id)
+			if !shadow.Shadow().HasDevice(id) {
+				shadow.Shadow().AddDevice(id, dev.ModelName)
+			}
+		}
+		restartConnections(name, plugins[name], delta.RestartConnections)
+		for _, id := range delta.AddedDevices {
+			export.TriggerEvents(event.DeviceAdded, id, nil)
+		}
+		logger.Logger.Info("reload config success", zap.String("plugin", name), zap.Any("delta", delta))
+	}
+	return deltas, nil
+}
+
+// restartConnections 重启受影响的连接，插件未实现 plugin.ConnectionReloader 时重启整个插件
+func restartConnections(name string, p plugin.Plugin, keys []string) {
+	if p == nil || len(keys) == 0 {
+		return
+	}
+	cfg := GetConfig(name)
+	if reloader, ok := p.(plugin.ConnectionReloader); ok {
+		if err := reloader.ReloadConnections(cfg, keys); err != nil {
+			logger.Logger.Error("reload connections error", zap.String("plugin", name), zap.Strings("connections", keys), zap.Error(err))
+		}
+		return
+	}
+	if err := p.Destroy(); err != nil {
+		logger.Logger.Error("stop plugin error", zap.String("plugin", name), zap.Error(err))
+	}
+	p.Initialize(cfg)
+}
+
+// diffStore 读取配置存储中发生变化的插件配置，并与核心缓存比对生成增量
+func (c *cache) diffStore() (map[string]*ConfigDelta, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	configs, invalid, err := c.readStore()
+	//重新读取后同步版本号，避免读取失败时重复加载
+	if revisions, e := c.store.Revisions(); e == nil {
+		c.watchRevisions = revisions
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	changed := make(map[string]config.StoredConfig)
+	for name, pl := range c.plugins {
+		//校验失败的插件保留当前运行配置，其余插件照常重载
+		if _, ok := invalid[name]; ok {
+			logger.Logger.Error("config invalid, keep current config", zap.String("plugin", name))
+			continue
+		}
+		cfg, ok := configs[name]
+		if !ok {
+			//插件配置被删除
+			if pl.revision != 0 {
+				changed[name] = config.StoredConfig{DeviceConfig: config.DeviceConfig{PluginName: name}}
+			}
+			continue
+		}
+		if cfg.Revision != pl.revision {
+			if pl.cacheModifyTime.After(pl.fileModifyTime) {
+				logger.Logger.Warn("config changed, discard unsaved changes", zap.String("plugin", name), zap.String("source", cfg.Source))
+			}
+			changed[name] = cfg
+		}
+	}
+	if len(changed) == 0 {
+		return map[string]*ConfigDelta{}, nil
+	}
+	if err = c.checkConflicts(changed); err != nil {
+		return nil, err
+	}
+
+	deltas := make(map[string]*ConfigDelta)
+	for name, cfg := range changed {
+		delta := c.diffConfig(name, cfg.DeviceConfig)
+		delta.stored = cfg
+		if !delta.Empty() {
+			deltas[name] = delta
+			continue
+		}
+		//配置内容未实质变化时仅同步版本号
+		pl := c.plugins[name]
+		pl.revision = cfg.Revision
+		c.plugins[name] = pl
+	}
+	return deltas, nil
+}
+
+// checkConflicts 校验变更后的模型、设备是否与其他插件冲突
+func (c *cache) checkConflicts(changed map[string]config.StoredConfig) error {
+	models := make(map[string]string)
+	devices := make(map[string]string)
+	for name, model := range c.models {
+		if _, ok := changed[model.pluginName]; !ok {
+			models[name] = model.pluginName
+		}
+	}
+	for id, dev := range c.devices {
+		if _, ok := changed[dev.PluginName]; !ok {
+			devices[id] = dev.PluginName
+		}
+	}
+	for name, f := range changed {
+		for _, model := range f.DeviceModels {
+			if p, ok := models[model.Name]; ok && p != name {
+				return errors.New("model " + model.Name + " already exists in " + p)
+			}
+			models[model.Name] = name
+			for _, dev := range model.Devices {
+				if p, ok := devices[dev.ID]; ok && p != name {
+					return errors.New("device " + dev.ID + " already exists in " + p)
+				}
+				devices[dev.ID] = name
+			}
+		}
+	}
+	return nil
+}
+
+// diffConfig 比对插件的新配置与核心缓存
+func (c *cache) diffConfig(name string, cfg config.DeviceConfig) *ConfigDelta {
+	return diffDeviceConfig(c.convertConfig(name), cfg)
+}
+
+// diffDeviceConfig 比对插件的两份配置，模型变更时关联设备所在的连接均需重启
+func diffDeviceConfig(oldConfig, newConfig config.DeviceConfig) *ConfigDelta {
+	delta := &ConfigDelta{}
+	restart := make(map[string]bool)
+
+	oldModels, oldDevices := flattenConfig(oldConfig)
+	models, devices := flattenConfig(newConfig)
+
+	changedModels := make(map[string]bool)
+	for modelName, model := range models {
+		old, ok := oldModels[modelName]
+		if !ok {
+			delta.AddedModels = append(delta.AddedModels, modelName)
+		} else if !jsonEqual(old, model) {
+			delta.UpdatedModels = append(delta.UpdatedModels, modelName)
+			changedModels[modelName] = true
+		}
+	}
+	for modelName := range oldModels {
+		if _, ok := models[modelName]; !ok {
+			delta.RemovedModels = append(delta.RemovedModels, modelName)
+		}
+	}
+
+	for id, dev := range devices {
+		old, ok := oldDevices[id]
+		switch {
+		case !ok || old.ModelName != dev.ModelName:
+			//模型变更视为删除后重新添加
+			if ok {
+				delta.RemovedDevices = append(delta.RemovedDevices, id)
+				restart[old.ConnectionKey] = true
+			}
+			delta.AddedDevices = append(delta.AddedDevices, id)
+			restart[dev.ConnectionKey] = true
+		case !jsonEqual(old, dev):
+			delta.UpdatedDevices = append(delta.UpdatedDevices, id)
+			restart[old.ConnectionKey] = true
+			restart[dev.ConnectionKey] = true
+		case changedModels[dev.ModelName]:
+			restart[dev.ConnectionKey] = true
+		}
+	}
+	for id, dev := range oldDevices {
+		if _, ok := devices[id]; !ok {
+			delta.RemovedDevices = append(delta.RemovedDevices, id)
+			restart[dev.ConnectionKey] = true
+		}
+	}
+
+	for key, conn := range newConfig.Connections {
+		old, ok := oldConfig.Connections[key]
+		if !ok {
+			delta.AddedConnections = append(delta.AddedConnections, key)
+			restart[key] = true
+		} else if !jsonEqual(old, conn) {
+			delta.UpdatedConnections = append(delta.UpdatedConnections, key)
+			restart[key] = true
+		}
+	}
+	for key := range oldConfig.Connections {
+		if _, ok := newConfig.Connections[key]; !ok {
+			delta.RemovedConnections = append(delta.RemovedConnections, key)
+			restart[key] = true
+		}
+	}
+
+	for key := range restart {
+		if key != "" {
+			delta.RestartConnections = append(delta.RestartConnections, key)
+		}
+	}
+	for _, keys := range [][]string{delta.AddedModels, delta.UpdatedModels, delta.RemovedModels,
+		delta.AddedDevices, delta.UpdatedDevices, delta.RemovedDevices,
+		delta.AddedConnections, delta.UpdatedConnections, delta.RemovedConnections, delta.RestartConnections} {
+		sort.Strings(keys)
+	}
+	return delta
+}
+
+// flattenConfig 展开插件配置中的模型与设备，设备补充模型名称
+func flattenConfig(cfg config.DeviceConfig) (map[string]config.Model, map[string]config.Device) {
+	models := make(map[string]config.Model)
+	devices := make(map[string]config.Device)
+	for _, model := range cfg.DeviceModels {
+		for _, dev := range model.Devices {
+			dev.ModelName = model.Name
+			devices[dev.ID] = dev
+		}
+		models[model.Name] = model.Model
+	}
+	return models, devices
+}
+
+// applyDeltas 将增量写入核心缓存并记录版本，返回变更插件的实例
+func (c *cache) applyDeltas(deltas map[string]*ConfigDelta, action, target, source string) map[string]plugin.Plugin {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := time.Now()
+	plugins := make(map[string]plugin.Plugin)
+	for name, delta := range deltas {
+		cfg := delta.stored.DeviceConfig
+		//仅删除归属当前插件的配置，避免误删迁移至其他插件的设备
+		for _, id := range delta.RemovedDevices {
+			if dev, ok := c.devices[id]; ok && dev.PluginName == name {
+				delete(c.devices, id)
+			}
+		}
+		for _, modelName := range delta.RemovedModels {
+			if model, ok := c.models[modelName]; ok && model.pluginName == name {
+				delete(c.models, modelName)
+			}
+		}
+		for _, key := range delta.RemovedConnections {
+			if conn, ok := c.connections[key]; ok && conn.pluginName == name {
+				delete(c.connections, key)
+			}
+		}
+		for _, model := range cfg.DeviceModels {
+			for _, dev := range model.Devices {
+				dev.ModelName = model.Name
+				dev.PluginName = name
+				c.devices[dev.ID] = cacheDevice{dev}
+			}
+			model.Devices = nil
+			c.models[model.Name] = newCacheModel(name, model.Model)
+		}
+		for key, conn := range cfg.Connections {
+			c.connections[key] = cacheConnection{
+				connection: conn,
+				pluginName: name,
+			}
+		}
+
+		pl := c.plugins[name]
+		pl.revision = delta.stored.Revision
+		pl.fileModifyTime = now
+		pl.cacheModifyTime = now
+		c.plugins[name] = pl
+		plugins[name] = pl.plugin
+		c.record(name, action, target, source)
+	}
+	return plugins
+}
+
+// configChanged 检查配置存储是否被外部修改，如配置文件被编辑
+func (c *cache) configChanged() bool {
+	revisions, err := c.store.Revisions()
+	if err != nil {
+		logger.Logger.Error("query config revisions error", zap.Error(err))
+		return false
+	}
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if len(revisions) != len(c.watchRevisions) {
+		return true
+	}
+	for name, revision := range revisions {
+		if r, ok := c.watchRevisions[name]; !ok || r != revision {
+			return true
+		}
+	}
+	return false
+}
+
+// watchConfig 定时检测配置变化并自动重载
+func watchConfig() {
+	if instance.store == nil || !instance.configChanged() {
+		return
+	}
+	logger.Logger.Info("config changed, begin reload")
+	if _, err := Reload("watcher"); err != nil {
+		logger.Logger.Error("reload config error", zap.Error(err))
+	}
+}
+
+func jsonEqual(a, b any) bool {
+	ba, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bb, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(ba, bb)
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ibuilding-x/driver-box/v2/internal/logger"
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+	"go.uber.org/zap"
+)
+
+func TestDiffConfig(t *testing.T) {
+	logger.Logger = zap.NewNop()
+	c := &cache{
+		plugins: map[string]cachePlugin{"modbus": {}, "bacnet": {}},
+		models: map[string]cacheModel{
+			"m1": newCacheModel("modbus", config.Model{Name: "m1", DevicePoints: []config.Point{{"name": "p1"}}}),
+			"m2": newCacheModel("modbus", config.Model{Name: "m2"}),
+			"b1": newCacheModel("bacnet", config.Model{Name: "b1"}),
+		},
+		devices: map[string]cacheDevice{
+			"d1": {config.Device{ID: "d1", ModelName: "m1", ConnectionKey: "line1", PluginName: "modbus"}},
+			"d2": {config.Device{ID: "d2", ModelName: "m1", ConnectionKey: "line2", PluginName: "modbus"}},
+			"d3": {config.Device{ID: "d3", ModelName: "m2", ConnectionKey: "line2", PluginName: "modbus"}},
+			"b":  {config.Device{ID: "b", ModelName: "b1", ConnectionKey: "bacnet", PluginName: "bacnet"}},
+		},
+		connections: map[string]cacheConnection{
+			"line1":  {pluginName: "modbus", connection: map[string]interface{}{"address": "/dev/ttyS1"}},
+			"line2":  {pluginName: "modbus", connection: map[string]interface{}{"address": "/dev/ttyS2"}},
+			"bacnet": {pluginName: "bacnet", connection: map[string]interface{}{}},
+		},
+	}
+
+	//修改 line1 的连接参数、删除 d3、新增 d4，m1 与 d2 保持不变
+	delta := c.diffConfig("modbus", config.DeviceConfig{
+		PluginName: "modbus",
+		DeviceModels: []config.DeviceModel{{
+			Model: config.Model{Name: "m1", DevicePoints: []config.Point{{"name": "p1"}}},
+			Devices: []config.Device{
+				{ID: "d1", ConnectionKey: "line1"},
+				{ID: "d2", ConnectionKey: "line2"},
+				{ID: "d4", ConnectionKey: "line1"},
+			},
+		}},
+		Connections: map[string]interface{}{
+			"line1": map[string]interface{}{"address": "/dev/ttyS3"},
+			"line2": map[string]interface{}{"address": "/dev/ttyS2"},
+		},
+	})
+
+	want := &ConfigDelta{
+		RemovedModels:      []string{"m2"},
+		AddedDevices:       []string{"d4"},
+		RemovedDevices:     []string{"d3"},
+		UpdatedConnections: []string{"line1"},
+		RestartConnections: []string{"line1", "line2"},
+	}
+	if !reflect.DeepEqual(delta, want) {
+		t.Errorf("delta = %+v, want %+v", delta, want)
+	}
+
+	if delta = c.diffConfig("bacnet", config.DeviceConfig{
+		PluginName: "bacnet",
+		DeviceModels: []config.DeviceModel{{
+			Model:   config.Model{Name: "b1"},
+			Devices: []config.Device{{ID: "b", ConnectionKey: "bacnet"}},
+		}},
+		Connections: map[string]interface{}{"bacnet": map[string]interface{}{}},
+	}); !delta.Empty() || len(delta.RestartConnections) > 0 {
+		t.Errorf("unchanged config delta = %+v", delta)
+	}
+}
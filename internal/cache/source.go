@@ -0,0 +1,101 @@
+package cache
+
+import "github.com/ibuilding-x/driver-box/v2/pkg/config"
+
+// sourceCache 标记变更来源的核心缓存，经由其产生的配置变更在版本历史中记录为该来源
+type sourceCache struct {
+	*cache
+	source string
+}
+
+func (c *cache) WithSource(source string) CoreCache {
+	return &sourceCache{cache: c, source: source}
+}
+
+// record 记录插件配置的变更版本，需在持有锁时调用
+// 配置存储支持版本历史时立即写入存储，版本号与配置在同一事务内生成；否则由定时任务写入
+func (c *cache) record(pluginName, action, target, source string) {
+	if c.history == nil {
+		return
+	}
+	if source == "" {
+		source = defaultSource
+	}
+	c.flush(pluginName, change{action: action, target: target, source: source})
+}
+
+func (c *cache) DeleteModel(modelName string) error {
+	return c.deleteModel(modelName, defaultSource)
+}
+
+func (c *cache) UpdateDeviceProperty(id string, key string, value string) error {
+	return c.updateDeviceProperty(id, key, value, defaultSource)
+}
+
+func (c *cache) DeleteDevice(id string) {
+	c.deleteDevice(id, defaultSource)
+}
+
+func (c *cache) UpdateDeviceDesc(id string, desc string) error {
+	return c.updateDeviceDesc(id, desc, defaultSource)
+}
+
+func (c *cache) AddConnection(plugin string, key string, conn any) error {
+	return c.addConnection(plugin, key, conn, defaultSource)
+}
+
+func (c *cache) DeleteConnection(key string) error {
+	return c.deleteConnection(key, defaultSource)
+}
+
+func (c *cache) AddModel(pluginName string, model config.Model) error {
+	return c.addModel(pluginName, model, defaultSource)
+}
+
+func (c *cache) AddOrUpdateDevice(dev config.Device) error {
+	return c.addOrUpdateDevice(dev, defaultSource)
+}
+
+func (c *cache) BatchRemoveDevice(ids []string) error {
+	return c.batchRemoveDevice(ids, defaultSource)
+}
+
+func (c *sourceCache) WithSource(source string) CoreCache {
+	return c.cache.WithSource(source)
+}
+
+func (c *sourceCache) DeleteModel(modelName string) error {
+	return c.deleteModel(modelName, c.source)
+}
+
+func (c *sourceCache) UpdateDeviceProperty(id string, key string, value string) error {
+	return c.updateDeviceProperty(id, key, value, c.source)
+}
+
+func (c *sourceCache) DeleteDevice(id string) {
+	c.deleteDevice(id, c.source)
+}
+
+func (c *sourceCache) UpdateDeviceDesc(id string, desc string) error {
+	return c.updateDeviceDesc(id, desc, c.source)
+}
+
+func (c *sourceCache) AddConnection(plugin string, key string, conn any) error {
+	return c.addConnection(plugin, key, conn, c.source)
+}
+
+func (c *sourceCache) DeleteConnection(key string) error {
+	return c.deleteConnection(key, c.source)
+}
+
+func (c *sourceCache) AddModel(pluginName string, model config.Model) error {
+	return c.addModel(pluginName, model, c.source)
+}
+
+func (c *sourceCache) AddOrUpdateDevice(dev config.Device) error {
+	return c.addOrUpdateDevice(dev, c.source)
+}
+
+func (c *sourceCache) BatchRemoveDevice(ids []string) error {
+	return c.batchRemoveDevice(ids, c.source)
+}
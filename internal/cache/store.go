@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+)
+
+// customStore 自定义配置存储，为空时根据环境变量创建内置存储
+var customStore config.ConfigStore
+
+// SetStore 设置自定义配置存储，需在初始化核心缓存前调用
+func SetStore(store config.ConfigStore) {
+	customStore = store
+}
+
+// newConfigStore 根据环境变量 DRIVERBOX_CONFIG_STORE 创建配置存储
+func newConfigStore() (config.ConfigStore, error) {
+	if customStore != nil {
+		return customStore, nil
+	}
+	switch os.Getenv(config.ENV_CONFIG_STORE) {
+	case "", "file":
+		return newFileStore(driverDir()), nil
+	case "sqlite":
+		path := os.Getenv(config.ENV_CONFIG_STORE_PATH)
+		if path == "" {
+			path = filepath.Join(config.ResourcePath, "config.db")
+		}
+		return newSQLiteStore(path, driverDir())
+	default:
+		return nil, errors.New("unsupported config store: " + os.Getenv(config.ENV_CONFIG_STORE))
+	}
+}
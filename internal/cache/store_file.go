@@ -0,0 +1,165 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ibuilding-x/driver-box/v2/internal/logger"
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+	"go.uber.org/zap"
+)
+
+// fileStore 基于 driver 目录下 config.json 的配置存储，每个插件对应一个配置文件
+// 插件版本号为配置文件的修改时间，全局版本号为进程内的保存次数
+type fileStore struct {
+	dir   string
+	mutex sync.Mutex
+	// 插件名称与配置文件路径的映射
+	paths    map[string]string
+	revision int64
+}
+
+func newFileStore(dir string) *fileStore {
+	return &fileStore{
+		dir:   dir,
+		paths: make(map[string]string),
+	}
+}
+
+func (s *fileStore) Load() (map[string]config.StoredConfig, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	// 自动创建配置目录
+	if err := createDir(s.dir); err != nil {
+		return nil, err
+	}
+	configs := make(map[string]config.StoredConfig)
+	paths := make(map[string]string)
+	// 遍历配置目录，解析每个文件夹的配置
+	for _, path := range s.files() {
+		cfg, err := parseConfigFromFile(path)
+		if err != nil {
+			if errors.Is(err, ErrConfigNotExist) {
+				continue
+			}
+			if errors.Is(err, ErrConfigEmpty) {
+				continue
+			}
+			logger.Logger.Error("parse config from file error", zap.String("path", path), zap.Error(err))
+			return nil, err
+		}
+		//相同插件不允许存在多个文件中
+		if p, ok := paths[cfg.PluginName]; ok {
+			return nil, errors.New("plugin " + cfg.PluginName + " already exists in " + p)
+		}
+		paths[cfg.PluginName] = path
+		configs[cfg.PluginName] = config.StoredConfig{
+			DeviceConfig: cfg,
+			Source:       path,
+			Revision:     fileRevision(path),
+		}
+	}
+	s.paths = paths
+	return configs, nil
+}
+
+func (s *fileStore) Revisions() (map[string]int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	plugins := make(map[string]string, len(s.paths))
+	for name, path := range s.paths {
+		plugins[path] = name
+	}
+	revisions := make(map[string]int64)
+	for _, path := range s.files() {
+		revision := fileRevision(path)
+		if revision == 0 {
+			continue
+		}
+		name, ok := plugins[path]
+		if !ok {
+			//新增的配置文件需解析插件名称，解析失败时以文件路径标识
+			name = path
+			if cfg, err := parseConfigFromFile(path); err == nil {
+				name = cfg.PluginName
+			}
+		}
+		revisions[name] = revision
+	}
+	return revisions, nil
+}
+
+func (s *fileStore) Save(c config.DeviceConfig) (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	path, ok := s.paths[c.PluginName]
+	if !ok {
+		path = filepath.Join(s.dir, c.PluginName, configFile)
+	}
+	bytes, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	// 确保目录存在
+	if err = createDir(filepath.Dir(path)); err != nil {
+		return 0, err
+	}
+	//先写入临时文件再重命名，避免写入中断导致配置文件损坏
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, bytes, 0644); err != nil {
+		return 0, err
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return 0, err
+	}
+	s.paths[c.PluginName] = path
+	s.revision++
+	return fileRevision(path), nil
+}
+
+func (s *fileStore) Delete(pluginName string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	path, ok := s.paths[pluginName]
+	if !ok {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	delete(s.paths, pluginName)
+	s.revision++
+	return nil
+}
+
+func (s *fileStore) Revision() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.revision
+}
+
+func (s *fileStore) Close() error {
+	return nil
+}
+
+// files 获取 driver 目录下所有的配置文件路径
+func (s *fileStore) files() []string {
+	files := make([]string, 0)
+	for _, dir := range getSubDirs(s.dir) {
+		files = append(files, filepath.Join(s.dir, dir, configFile))
+	}
+	return files
+}
+
+// fileRevision 以文件修改时间作为版本号，文件不存在时返回 0
+func fileRevision(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return 0
+	}
+	return info.ModTime().UnixNano()
+}
@@ -0,0 +1,428 @@
+package cache
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	_ "github.com/glebarez/sqlite"
+	"github.com/ibuilding-x/driver-box/v2/internal/logger"
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+	"go.uber.org/zap"
+)
+
+const configSchemaSQL = `
+CREATE TABLE IF NOT EXISTS config_revision( -- 配置版本记录
+    revision INTEGER PRIMARY KEY NOT NULL, -- 全局版本号
+    plugin_name varchar(255) NOT NULL, -- 变更的插件名称
+    action varchar(64) NOT NULL, -- 变更操作
+    target varchar(255), -- 变更对象，如设备ID、模型名称
+    source varchar(255), -- 变更来源
+    create_time INTEGER NOT NULL, -- 变更时间，毫秒时间戳
+    content TEXT NOT NULL -- 变更后插件的完整配置，JSON格式
+    );
+CREATE INDEX IF NOT EXISTS idx_config_revision_plugin ON config_revision(plugin_name,revision);
+CREATE TABLE IF NOT EXISTS config_plugin( -- 插件配置
+    plugin_name varchar(255) PRIMARY KEY NOT NULL, -- 插件名称
+    revision INTEGER NOT NULL -- 插件配置最近一次变更的版本号
+    );
+CREATE TABLE IF NOT EXISTS config_model( -- 设备模型
+    name varchar(255) PRIMARY KEY NOT NULL, -- 模型名称
+    plugin_name varchar(255) NOT NULL, -- 插件名称
+    seq INTEGER NOT NULL, -- 模型在配置中的顺序
+    content TEXT NOT NULL -- 模型定义，JSON格式
+    );
+CREATE TABLE IF NOT EXISTS config_device( -- 设备
+    id varchar(255) PRIMARY KEY NOT NULL, -- 设备ID
+    plugin_name varchar(255) NOT NULL, -- 插件名称
+    model_name varchar(255) NOT NULL, -- 模型名称
+    seq INTEGER NOT NULL, -- 设备在模型中的顺序
+    content TEXT NOT NULL -- 设备定义，JSON格式
+    );
+CREATE TABLE IF NOT EXISTS config_connection( -- 连接
+    conn_key varchar(255) PRIMARY KEY NOT NULL, -- 连接标识
+    plugin_name varchar(255) NOT NULL, -- 插件名称
+    content TEXT NOT NULL -- 连接配置，JSON格式
+    );
+`
+
+// sqliteStore 基于 sqlite 的配置存储，插件配置的每次保存均在同一事务内完成，并递增全局版本号
+// 每个版本同时保存变更后插件的完整配置，作为配置版本历史
+type sqliteStore struct {
+	db   *sql.DB
+	path string
+	// 最多保留的版本数量
+	maxCount int
+}
+
+// newSQLiteStore 打开 sqlite 配置存储，数据库为空时从 importDir 目录导入 config.json 配置文件
+func newSQLiteStore(path string, importDir string) (*sqliteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	//sqlite 单连接写入，避免 database is locked
+	db.SetMaxOpenConns(1)
+	if _, err = db.Exec(configSchemaSQL); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	s := &sqliteStore{
+		db:       db,
+		path:     path,
+		maxCount: 1000,
+	}
+	if v, e := strconv.Atoi(os.Getenv(config.ENV_CONFIG_HISTORY_MAX_COUNT)); e == nil && v > 0 {
+		s.maxCount = v
+	}
+	if s.Revision() == 0 {
+		if err = s.importFiles(importDir); err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// importFiles 首次启动时导入 config.json 配置文件，全部导入成功后才提交
+func (s *sqliteStore) importFiles(dir string) error {
+	configs, err := newFileStore(dir).Load()
+	if err != nil {
+		return err
+	}
+	if len(configs) == 0 {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for name, cfg := range configs {
+		if _, err = s.saveConfig(tx, cfg.DeviceConfig, change{action: "import", target: cfg.Source, source: "startup"}); err != nil {
+			return err
+		}
+		logger.Logger.Info("import config file to sqlite store", zap.String("plugin", name), zap.String("file", cfg.Source))
+	}
+	return tx.Commit()
+}
+
+func (s *sqliteStore) Load() (map[string]config.StoredConfig, error) {
+	revisions, err := s.Revisions()
+	if err != nil {
+		return nil, err
+	}
+	configs := make(map[string]config.StoredConfig, len(revisions))
+	for name, revision := range revisions {
+		cfg, err := queryConfig(s.db, name)
+		if err != nil {
+			return nil, err
+		}
+		configs[name] = config.StoredConfig{
+			DeviceConfig: cfg,
+			Source:       s.path,
+			Revision:     revision,
+		}
+	}
+	return configs, nil
+}
+
+func (s *sqliteStore) Revisions() (map[string]int64, error) {
+	rows, err := s.db.Query("SELECT plugin_name,revision FROM config_plugin")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	revisions := make(map[string]int64)
+	for rows.Next() {
+		var name string
+		var revision int64
+		if err = rows.Scan(&name, &revision); err != nil {
+			return nil, err
+		}
+		revisions[name] = revision
+	}
+	return revisions, rows.Err()
+}
+
+func (s *sqliteStore) Save(c config.DeviceConfig) (int64, error) {
+	return s.saveChange(c, change{action: "save", source: defaultSource})
+}
+
+func (s *sqliteStore) saveChange(c config.DeviceConfig, ch change) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	revision, err := s.saveConfig(tx, c, ch)
+	if err != nil {
+		return 0, err
+	}
+	return revision, tx.Commit()
+}
+
+func (s *sqliteStore) Delete(pluginName string) error {
+	return s.deleteChange(pluginName, change{action: "delete", source: defaultSource})
+}
+
+func (s *sqliteStore) deleteChange(pluginName string, ch change) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	//删除后的版本内容为空配置，回滚至该版本时删除插件配置
+	if _, err = s.nextRevision(tx, config.DeviceConfig{PluginName: pluginName}, ch); err != nil {
+		return err
+	}
+	if err = deleteConfig(tx, pluginName); err != nil {
+		return err
+	}
+	if _, err = tx.Exec("DELETE FROM config_plugin WHERE plugin_name=?", pluginName); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *sqliteStore) Revision() int64 {
+	var revision int64
+	if err := s.db.QueryRow("SELECT COALESCE(MAX(revision),0) FROM config_revision").Scan(&revision); err != nil {
+		logger.Logger.Error("query config revision error", zap.Error(err))
+	}
+	return revision
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
+
+// queryConfig 读取插件的完整配置
+func queryConfig(db *sql.DB, pluginName string) (config.DeviceConfig, error) {
+	cfg := config.DeviceConfig{
+		PluginName:  pluginName,
+		Connections: make(map[string]interface{}),
+	}
+	rows, err := db.Query("SELECT content FROM config_model WHERE plugin_name=? ORDER BY seq", pluginName)
+	if err != nil {
+		return cfg, err
+	}
+	models := make(map[string]int)
+	for rows.Next() {
+		var content string
+		var model config.DeviceModel
+		if err = rows.Scan(&content); err == nil {
+			err = json.Unmarshal([]byte(content), &model.Model)
+		}
+		if err != nil {
+			_ = rows.Close()
+			return cfg, err
+		}
+		model.Devices = make([]config.Device, 0)
+		models[model.Name] = len(cfg.DeviceModels)
+		cfg.DeviceModels = append(cfg.DeviceModels, model)
+	}
+	_ = rows.Close()
+
+	rows, err = db.Query("SELECT model_name,content FROM config_device WHERE plugin_name=? ORDER BY seq", pluginName)
+	if err != nil {
+		return cfg, err
+	}
+	for rows.Next() {
+		var modelName, content string
+		var device config.Device
+		if err = rows.Scan(&modelName, &content); err == nil {
+			err = json.Unmarshal([]byte(content), &device)
+		}
+		if err != nil {
+			_ = rows.Close()
+			return cfg, err
+		}
+		if i, ok := models[modelName]; ok {
+			cfg.DeviceModels[i].Devices = append(cfg.DeviceModels[i].Devices, device)
+		}
+	}
+	_ = rows.Close()
+
+	rows, err = db.Query("SELECT conn_key,content FROM config_connection WHERE plugin_name=?", pluginName)
+	if err != nil {
+		return cfg, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key, content string
+		var connection interface{}
+		if err = rows.Scan(&key, &content); err == nil {
+			err = json.Unmarshal([]byte(content), &connection)
+		}
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Connections[key] = connection
+	}
+	return cfg, rows.Err()
+}
+
+// saveConfig 覆盖保存插件的完整配置，返回新的版本号；配置与最近一个版本一致时不生成版本
+func (s *sqliteStore) saveConfig(tx *sql.Tx, c config.DeviceConfig, ch change) (int64, error) {
+	revision, latest, err := latestRevision(tx, c.PluginName)
+	if err != nil {
+		return 0, err
+	}
+	if latest != nil && diffDeviceConfig(*latest, c).Empty() {
+		return revision, nil
+	}
+	revision, err = s.nextRevision(tx, c, ch)
+	if err != nil {
+		return 0, err
+	}
+	if err = deleteConfig(tx, c.PluginName); err != nil {
+		return 0, err
+	}
+	for i, model := range c.DeviceModels {
+		content, err := json.Marshal(model.Model)
+		if err != nil {
+			return 0, err
+		}
+		if _, err = tx.Exec("INSERT INTO config_model(name,plugin_name,seq,content) VALUES(?,?,?,?)",
+			model.Name, c.PluginName, i, string(content)); err != nil {
+			return 0, err
+		}
+		for j, device := range model.Devices {
+			content, err = json.Marshal(device)
+			if err != nil {
+				return 0, err
+			}
+			if _, err = tx.Exec("INSERT INTO config_device(id,plugin_name,model_name,seq,content) VALUES(?,?,?,?,?)",
+				device.ID, c.PluginName, model.Name, j, string(content)); err != nil {
+				return 0, err
+			}
+		}
+	}
+	for key, connection := range c.Connections {
+		content, err := json.Marshal(connection)
+		if err != nil {
+			return 0, err
+		}
+		if _, err = tx.Exec("INSERT INTO config_connection(conn_key,plugin_name,content) VALUES(?,?,?)",
+			key, c.PluginName, string(content)); err != nil {
+			return 0, err
+		}
+	}
+	_, err = tx.Exec("INSERT INTO config_plugin(plugin_name,revision) VALUES(?,?) ON CONFLICT(plugin_name) DO UPDATE SET revision=excluded.revision",
+		c.PluginName, revision)
+	return revision, err
+}
+
+func deleteConfig(tx *sql.Tx, pluginName string) error {
+	for _, table := range []string{"config_model", "config_device", "config_connection"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE plugin_name=?", pluginName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// latestRevision 查询插件配置当前的版本号及内容，插件配置不存在时内容为空
+func latestRevision(tx *sql.Tx, pluginName string) (int64, *config.DeviceConfig, error) {
+	var revision int64
+	var content string
+	err := tx.QueryRow("SELECT r.revision,r.content FROM config_plugin p JOIN config_revision r ON r.revision=p.revision WHERE p.plugin_name=?", pluginName).Scan(&revision, &content)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil, nil
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	var cfg config.DeviceConfig
+	if err = json.Unmarshal([]byte(content), &cfg); err != nil {
+		return 0, nil, err
+	}
+	return revision, &cfg, nil
+}
+
+// nextRevision 生成新的全局版本号，记录变更及变更后插件的完整配置，超出保留数量时清理过期版本
+func (s *sqliteStore) nextRevision(tx *sql.Tx, c config.DeviceConfig, ch change) (int64, error) {
+	content, err := json.Marshal(c)
+	if err != nil {
+		return 0, err
+	}
+	var revision int64
+	if err = tx.QueryRow("SELECT COALESCE(MAX(revision),0)+1 FROM config_revision").Scan(&revision); err != nil {
+		return 0, err
+	}
+	if _, err = tx.Exec("INSERT INTO config_revision(revision,plugin_name,action,target,source,create_time,content) VALUES(?,?,?,?,?,?,?)",
+		revision, c.PluginName, ch.action, ch.target, ch.source, time.Now().UnixMilli(), string(content)); err != nil {
+		return 0, err
+	}
+	//保留各插件在清理点之前的最后一个版本作为基线
+	if before := revision - int64(s.maxCount) + 1; before > 1 {
+		_, err = tx.Exec("DELETE FROM config_revision WHERE revision < ? AND revision NOT IN (SELECT MAX(revision) FROM config_revision WHERE revision < ? GROUP BY plugin_name)", before, before)
+	}
+	return revision, err
+}
+
+func (s *sqliteStore) list(pluginName string, limit int) ([]Revision, error) {
+	query := "SELECT revision,plugin_name,action,target,source,create_time FROM config_revision"
+	args := make([]interface{}, 0)
+	if pluginName != "" {
+		query += " WHERE plugin_name=?"
+		args = append(args, pluginName)
+	}
+	query += " ORDER BY revision DESC"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	revisions := make([]Revision, 0)
+	for rows.Next() {
+		var r Revision
+		var target, source sql.NullString
+		if err = rows.Scan(&r.ID, &r.PluginName, &r.Action, &target, &source, &r.Time); err != nil {
+			return nil, err
+		}
+		r.Target, r.Source = target.String, source.String
+		revisions = append(revisions, r)
+	}
+	return revisions, rows.Err()
+}
+
+func (s *sqliteStore) configs(revision int64) (map[string]config.DeviceConfig, error) {
+	var exists int
+	if err := s.db.QueryRow("SELECT COUNT(1) FROM config_revision WHERE revision=?", revision).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if exists == 0 {
+		return nil, ErrRevisionNotExist
+	}
+	rows, err := s.db.Query("SELECT plugin_name,content FROM config_revision WHERE revision IN (SELECT MAX(revision) FROM config_revision WHERE revision <= ? GROUP BY plugin_name)", revision)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	configs := make(map[string]config.DeviceConfig)
+	for rows.Next() {
+		var name, content string
+		var cfg config.DeviceConfig
+		if err = rows.Scan(&name, &content); err == nil {
+			err = json.Unmarshal([]byte(content), &cfg)
+		}
+		if err != nil {
+			return nil, err
+		}
+		configs[name] = cfg
+	}
+	return configs, rows.Err()
+}
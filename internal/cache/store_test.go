@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ibuilding-x/driver-box/v2/internal/logger"
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+	"go.uber.org/zap"
+)
+
+func TestSQLiteStore(t *testing.T) {
+	logger.Logger = zap.NewNop()
+	dir := t.TempDir()
+	driverPath := filepath.Join(dir, "driver")
+	cfg := config.DeviceConfig{
+		PluginName: "modbus",
+		DeviceModels: []config.DeviceModel{{
+			Model: config.Model{Name: "m1", DevicePoints: []config.Point{{"name": "p1", "valueType": "int"}}},
+			Devices: []config.Device{
+				{ID: "d1", ConnectionKey: "line1", Properties: map[string]string{"unitID": "1"}},
+				{ID: "d2", ConnectionKey: "line1", Properties: map[string]string{"unitID": "2"}},
+			},
+		}},
+		Connections: map[string]interface{}{"line1": map[string]interface{}{"address": "/dev/ttyS1"}},
+	}
+	if _, err := newFileStore(driverPath).Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	//首次启动导入配置文件
+	s, err := newSQLiteStore(filepath.Join(dir, "config.db"), driverPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	configs, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := configs["modbus"]; got.Revision != 1 || !reflect.DeepEqual(got.DeviceConfig, cfg) {
+		t.Fatalf("imported config = %+v", got)
+	}
+
+	cfg.DeviceModels[0].Devices = cfg.DeviceModels[0].Devices[:1]
+	if revision, err := s.Save(cfg); err != nil || revision != 2 {
+		t.Fatalf("save revision = %d, %v", revision, err)
+	}
+	if err = s.Delete("bacnet"); err != nil || s.Revision() != 3 {
+		t.Fatalf("revision = %d, %v", s.Revision(), err)
+	}
+	configs, _ = s.Load()
+	if got := configs["modbus"]; got.Revision != 2 || len(got.DeviceModels[0].Devices) != 1 {
+		t.Fatalf("saved config = %+v", got)
+	}
+
+	//已有数据时不重复导入
+	_ = os.Remove(filepath.Join(driverPath, "modbus", configFile))
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s, err = newSQLiteStore(filepath.Join(dir, "config.db"), driverPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if revisions, _ := s.Revisions(); revisions["modbus"] != 2 {
+		t.Errorf("revisions = %v", revisions)
+	}
+}
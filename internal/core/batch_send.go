@@ -46,7 +46,10 @@ func SendBatchWrite(deviceId string, points []plugin.PointData) (err error) {
 	}
 	// 发送数据
 	if err = connector.Send(res); err != nil {
-		_ = shadow.Shadow().MayBeOffline(deviceId)
+		// 写入校验失败时设备已应答回读，不视为可能离线
+		if !errors.Is(err, plugin.WriteNotConfirmed) {
+			_ = shadow.Shadow().MayBeOffline(deviceId)
+		}
 		return err
 	}
 	//点位写成功后，立即触发读取操作以及时更新影子状态
@@ -97,6 +100,8 @@ func SendBatchRead(deviceId string, points []plugin.PointData) (err error) {
 
 // 尝试读取期望点位值
 func tryReadNewValues(deviceId string, points []plugin.PointData) {
+	p, _ := cache.GetRunningPluginByDevice(deviceId)
+	verified := cache.SupportWritePolicy(p)
 	readPoints := make([]plugin.PointData, 0)
 	for _, p := range points {
 		point, ok := cache.Get().GetPointByDevice(deviceId, p.PointName)
@@ -106,6 +111,10 @@ func tryReadNewValues(deviceId string, points []plugin.PointData) {
 		if point.ReadWrite() != config.ReadWrite_R && point.ReadWrite() != config.ReadWrite_RW {
 			continue
 		}
+		//支持写入校验的插件已在写操作中同步完成回读校验
+		if verified && point.WritePolicy() != config.WritePolicy_None {
+			continue
+		}
 		readPoints = append(readPoints, p)
 	}
 	if len(readPoints) == 0 {
@@ -10,6 +10,7 @@ import (
 	"github.com/ibuilding-x/driver-box/v2/pkg/config"
 	"github.com/ibuilding-x/driver-box/v2/pkg/convutil"
 	"github.com/ibuilding-x/driver-box/v2/pkg/library"
+	"github.com/ibuilding-x/driver-box/v2/pkg/transform"
 	"go.uber.org/zap"
 )
 
@@ -55,6 +56,13 @@ func deviceDriverProcess(deviceId string, mode plugin.EncodeMode, pointData ...p
 					return nil, err
 				}
 			}
+			//逆向执行点位值转换链
+			if transforms := point.Transforms(); len(transforms) > 0 {
+				value, err = transform.Inverse(transforms, value)
+				if err != nil {
+					return nil, fmt.Errorf("point %s: %w", p.PointName, err)
+				}
+			}
 			pointData[i].Value = value
 		}
 	}
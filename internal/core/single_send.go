@@ -108,7 +108,10 @@ func singleWrite(deviceId string, pointData plugin.PointData) error {
 	}
 	// 发送数据
 	if err = conn.Send(res); err != nil {
-		_ = shadow.Shadow().MayBeOffline(deviceId)
+		// 写入校验失败时设备已应答回读，不视为可能离线
+		if !errors.Is(err, plugin.WriteNotConfirmed) {
+			_ = shadow.Shadow().MayBeOffline(deviceId)
+		}
 		return err
 	}
 	return err
@@ -0,0 +1,74 @@
+package base
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/ibuilding-x/driver-box/v2/internal/cache"
+)
+
+var revisionRequiredErr = errors.New("revision is required")
+
+// 配置热加载，仅应用配置文件的增量变更
+func configReload(r *http.Request) (any, error) {
+	return cache.Reload(requestSource(r))
+}
+
+// 查询配置版本列表
+// curl http://127.0.0.1:8081/api/v1/config/revisions?plugin=modbus&limit=20
+func configRevisions(r *http.Request) (any, error) {
+	limit := 100
+	if s := r.URL.Query().Get("limit"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, err
+		}
+		limit = v
+	}
+	return cache.Revisions(r.URL.Query().Get("plugin"), limit)
+}
+
+// 比对两个配置版本的模型、设备、连接变更
+// curl http://127.0.0.1:8081/api/v1/config/diff?from=1&to=5
+func configDiff(r *http.Request) (any, error) {
+	from, err := strconv.ParseInt(r.URL.Query().Get("from"), 10, 64)
+	if err != nil {
+		return nil, revisionRequiredErr
+	}
+	to, err := strconv.ParseInt(r.URL.Query().Get("to"), 10, 64)
+	if err != nil {
+		return nil, revisionRequiredErr
+	}
+	return cache.Diff(from, to)
+}
+
+// 回滚至指定配置版本
+// curl -X POST -H "X-Author: admin" -d '{"revision":5}' http://127.0.0.1:8081/api/v1/config/rollback
+func configRollback(r *http.Request) (any, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+	var req struct {
+		Revision int64 `json:"revision"`
+	}
+	if err = json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+	if req.Revision <= 0 {
+		return nil, revisionRequiredErr
+	}
+	return cache.Rollback(req.Revision, requestSource(r))
+}
+
+// requestSource 配置变更来源，优先取请求头 X-Author 标识的操作人，否则取客户端地址
+func requestSource(r *http.Request) string {
+	if author := r.Header.Get("X-Author"); author != "" {
+		return "rest:" + author
+	}
+	return "rest:" + r.RemoteAddr
+}
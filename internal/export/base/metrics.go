@@ -0,0 +1,43 @@
+package base
+
+import (
+	"time"
+
+	export0 "github.com/ibuilding-x/driver-box/v2/internal/export"
+	shadow0 "github.com/ibuilding-x/driver-box/v2/internal/shadow"
+	"github.com/ibuilding-x/driver-box/v2/pkg/metrics"
+)
+
+func init() {
+	metrics.RegisterCollector(collectMetrics)
+}
+
+// collectMetrics 采集设备影子及断网续传队列的瞬时指标
+func collectMetrics() {
+	metrics.DeviceOnline.Reset()
+	metrics.DeviceDisconnectTimes.Reset()
+	metrics.PointUpdateAge.Reset()
+	now := time.Now()
+	for _, device := range shadow0.Shadow().GetDevices() {
+		online := 0.0
+		if device.Online {
+			online = 1
+		}
+		metrics.DeviceOnline.Set(online, device.ID, device.ModelName)
+		metrics.DeviceDisconnectTimes.Set(float64(device.DisconnectTimes), device.ID)
+		for name, point := range device.Points {
+			//从未更新过的点位不输出
+			if point.UpdatedAt.IsZero() {
+				continue
+			}
+			metrics.PointUpdateAge.Set(now.Sub(point.UpdatedAt).Seconds(), device.ID, name)
+		}
+	}
+
+	metrics.ExportQueueDepth.Reset()
+	metrics.ExportQueueDropped.Reset()
+	for _, stats := range export0.GetQueueStats() {
+		metrics.ExportQueueDepth.Set(float64(stats.Depth), stats.Name)
+		metrics.ExportQueueDropped.Set(float64(stats.Dropped), stats.Name)
+	}
+}
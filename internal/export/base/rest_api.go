@@ -12,13 +12,16 @@ import (
 	"github.com/ibuilding-x/driver-box/v2/driverbox/shadow"
 	"github.com/ibuilding-x/driver-box/v2/internal/cache"
 	"github.com/ibuilding-x/driver-box/v2/internal/core"
+	export0 "github.com/ibuilding-x/driver-box/v2/internal/export"
 	"github.com/ibuilding-x/driver-box/v2/internal/export/base/restful"
 	"github.com/ibuilding-x/driver-box/v2/internal/export/base/restful/request"
 	"github.com/ibuilding-x/driver-box/v2/internal/export/base/restful/route"
 	"github.com/ibuilding-x/driver-box/v2/internal/logger"
 	shadow0 "github.com/ibuilding-x/driver-box/v2/internal/shadow"
 	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+	"github.com/ibuilding-x/driver-box/v2/pkg/convutil"
 	"github.com/ibuilding-x/driver-box/v2/pkg/library"
+	"github.com/ibuilding-x/driver-box/v2/pkg/metrics"
 	"go.uber.org/zap"
 )
 
@@ -39,6 +42,20 @@ func (export *Export) registerApi() {
 	restful.HandleFunc(http.MethodGet, route.DeviceList, deviceList)
 	restful.HandleFunc(http.MethodGet, route.DeviceGet, deviceGet)
 
+	//配置热加载与版本管理
+	restful.HandleFunc(http.MethodPost, route.ConfigReload, configReload)
+	restful.HandleFunc(http.MethodGet, route.ConfigRevisions, configRevisions)
+	restful.HandleFunc(http.MethodGet, route.ConfigDiff, configDiff)
+	restful.HandleFunc(http.MethodPost, route.ConfigRollback, configRollback)
+
+	//Export断网续传队列状态
+	restful.HandleFunc(http.MethodGet, route.V1Prefix+"export/queue", func(_ *http.Request) (any, error) {
+		return export0.GetQueueStats(), nil
+	})
+
+	//Prometheus 指标
+	restful.HttpRouter.Handler(http.MethodGet, route.Metrics, metrics.Handler())
+
 	//资源库服务
 	restful.HandleFunc(http.MethodGet, route.V1Prefix+"library/model/get", libraryModelGet)
 
@@ -107,6 +124,7 @@ func getAllDevices(_ *http.Request) (any, error) {
 	type Point struct {
 		shadow.DevicePoint
 		UpdatedAt string `json:"updatedAt"`
+		SourceAt  string `json:"sourceAt"`
 		WriteAt   string `json:"writeAt"`
 	}
 	type Device struct {
@@ -119,9 +137,11 @@ func getAllDevices(_ *http.Request) (any, error) {
 		//获取设备所有点位
 		points := make(map[string]Point)
 		for k, v := range device.Points {
+			v.Value, v.WriteValue = formatValue(v.Value), formatValue(v.WriteValue)
 			points[k] = Point{
 				DevicePoint: v,
 				UpdatedAt:   v.UpdatedAt.Format(time.DateTime),
+				SourceAt:    v.SourceAt.Format(time.DateTime),
 				WriteAt:     v.WriteAt.Format(time.DateTime),
 			}
 		}
@@ -135,6 +155,14 @@ func getAllDevices(_ *http.Request) (any, error) {
 	return list, nil
 }
 
+// formatValue 日期时间类型的点位值与其他时间字段保持一致的格式
+func formatValue(value interface{}) interface{} {
+	if t, ok := value.(time.Time); ok {
+		return t.Format(time.DateTime)
+	}
+	return value
+}
+
 // Device 设备相关操作
 func deviceShadow(r *http.Request) (any, error) {
 	// 获取查询参数
@@ -210,9 +238,27 @@ func queryDevicePoint(sn string, point string) (any, error) {
 }
 
 // updateDevice 更新设备影子数据
+// 人工设置的点位值默认标记为替代值
 func updateDevice(data request.UpdateDeviceReq) error {
 	for i, _ := range data {
-		err := shadow0.Shadow().SetDevicePoint(data[i].ID, data[i].Name, data[i].Value)
+		quality := data[i].Quality
+		if quality == "" {
+			quality = plugin.QualitySubstituted
+		}
+		//按点位类型转换，确保与采集的数据类型一致
+		value := data[i].Value
+		if point, ok := cache.Get().GetPointByDevice(data[i].ID, data[i].Name); ok {
+			v, err := convutil.PointValue(value, point.ValueType())
+			if err != nil {
+				return err
+			}
+			value = v
+		}
+		err := shadow0.Shadow().SetDevicePointData(data[i].ID, plugin.PointData{
+			PointName: data[i].Name,
+			Value:     value,
+			Quality:   quality,
+		})
 		if err != nil {
 			return err
 		}
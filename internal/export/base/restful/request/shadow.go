@@ -1,5 +1,7 @@
 package request
 
+import "github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
+
 type UpdateDeviceReq []UpdateDeviceData
 
 // UpdateDeviceData 更新设备点位请求数据
@@ -7,4 +9,6 @@ type UpdateDeviceData struct {
 	ID    string `json:"id"`
 	Name  string `json:"name"`
 	Value any    `json:"value"`
+	// Quality 数据质量，默认为 substituted
+	Quality plugin.Quality `json:"quality"`
 }
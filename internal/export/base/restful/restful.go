@@ -3,6 +3,7 @@ package restful
 import (
 	"encoding/json"
 	"net/http"
+	"sync"
 
 	"github.com/ibuilding-x/driver-box/v2/internal/export/base/restful/response"
 	"github.com/ibuilding-x/driver-box/v2/internal/logger"
@@ -15,15 +16,27 @@ var HttpRouter = httprouter.New()
 // Handler 处理函数
 type Handler func(*http.Request) (any, error)
 
-// HandleFunc 注册处理函数
+// handlers 已注册的处理函数，key 为 {method} {pattern}
+var handlers sync.Map
+
+// HandleFunc 注册处理函数，重复注册时替换原处理函数，便于插件重启时以新实例再次注册
 func HandleFunc(method, pattern string, handler Handler) {
+	key := method + " " + pattern
+	if _, loaded := handlers.Swap(key, handler); loaded {
+		logger.Logger.Info("replace api", zap.String("method", method), zap.String("pattern", pattern))
+	}
+	//路由仅注册一次，请求时取最新的处理函数；路由器重建后需重新注册
+	if h, _, _ := HttpRouter.Lookup(method, pattern); h != nil {
+		return
+	}
 	logger.Logger.Info("register api", zap.String("method", method), zap.String("pattern", pattern))
 	HttpRouter.HandlerFunc(method, pattern, func(writer http.ResponseWriter, request *http.Request) {
+		handler, _ := handlers.Load(key)
 		// 定义响应数据结构
 		var data response.Common
 
 		// 处理请求
-		result, err := handler(request)
+		result, err := handler.(Handler)(request)
 		if err != nil {
 			// 定义错误信息
 			data.ErrorMsg = err.Error()
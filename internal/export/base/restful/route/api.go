@@ -18,3 +18,18 @@ const DeviceGet = V1Prefix + "device/get"
 
 // 删除设备
 const DeviceDelete = V1Prefix + "device/delete"
+
+// Prometheus 指标
+const Metrics = "/metrics"
+
+// 重新加载驱动配置文件
+const ConfigReload = V1Prefix + "config/reload"
+
+// 查询配置版本列表
+const ConfigRevisions = V1Prefix + "config/revisions"
+
+// 比对两个配置版本
+const ConfigDiff = V1Prefix + "config/diff"
+
+// 回滚至指定配置版本
+const ConfigRollback = V1Prefix + "config/rollback"
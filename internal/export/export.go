@@ -9,7 +9,20 @@ import (
 
 var Exports []export.Export
 
+// deletingHandlers 设备删除前的内部清理回调
+var deletingHandlers []func(id string)
+
+// OnDeviceDeleting 注册设备删除前的内部清理回调，先于各 Export 执行
+func OnDeviceDeleting(handler func(id string)) {
+	deletingHandlers = append(deletingHandlers, handler)
+}
+
 func TriggerEvents(eventCode event.EventCode, key string, value interface{}) {
+	if eventCode == event.DeviceDeleting {
+		for _, handler := range deletingHandlers {
+			handler(key)
+		}
+	}
 	for _, e := range Exports {
 		if !e.IsReady() {
 			logger.Logger.Debug("export not ready")
@@ -0,0 +1,352 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ibuilding-x/driver-box/v2/driverbox/export"
+	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
+	"github.com/ibuilding-x/driver-box/v2/internal/logger"
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+	"github.com/ibuilding-x/driver-box/v2/pkg/diskqueue"
+	"github.com/ibuilding-x/driver-box/v2/pkg/metrics"
+	"go.uber.org/zap"
+)
+
+const (
+	// 队列默认最大缓存条数
+	defaultQueueMaxSize = 10000
+	// 补发失败后的重试间隔
+	replayInterval = 3 * time.Second
+)
+
+// QueueStats 断网续传队列状态
+type QueueStats struct {
+	Name string `json:"name"`
+	// Depth 待补发的数据条数
+	Depth int `json:"depth"`
+	// Dropped 因队列已满丢弃的数据条数
+	Dropped int64 `json:"dropped"`
+	// Corrupted 因记录损坏跳过的数据条数
+	Corrupted int64 `json:"corrupted"`
+}
+
+// queuedData 缓存的设备数据，Types 记录各点位值的类型，补发时据此还原，避免经 JSON 后数值均变为 float64
+type queuedData struct {
+	plugin.DeviceData
+	Types []string `json:"types,omitempty"`
+}
+
+// forwarder 为 ReliableExport 提供磁盘缓存及顺序补发
+type forwarder struct {
+	export export.ReliableExport
+	queue  *diskqueue.Queue
+	// 保证写入数据与移除已导出队首数据的一致性，导出期间不持有
+	mutex sync.Mutex
+	// 存在因 Export 未就绪或导出失败而积压的数据，仅由补发任务读写
+	backlog bool
+	notify  chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+var (
+	forwarders      = make(map[export.Export]*forwarder)
+	forwardersMutex sync.RWMutex
+)
+
+// OpenQueues 为所有 ReliableExport 打开缓存队列并启动补发任务
+func OpenQueues() {
+	dir := os.Getenv(config.EXPORT_QUEUE_PATH)
+	if dir == "" {
+		dir = filepath.Join(config.ResourcePath, "export_queue")
+	}
+	maxSize := defaultQueueMaxSize
+	if v, err := strconv.Atoi(os.Getenv(config.EXPORT_QUEUE_MAX_SIZE)); err == nil && v > 0 {
+		maxSize = v
+	}
+	forwardersMutex.Lock()
+	defer forwardersMutex.Unlock()
+	for _, e := range Exports {
+		re, ok := e.(export.ReliableExport)
+		if !ok {
+			continue
+		}
+		if _, exists := forwarders[e]; exists {
+			continue
+		}
+		queue, err := diskqueue.Open(filepath.Join(dir, re.QueueName()), maxSize)
+		if err != nil {
+			logger.Logger.Error("open export queue error", zap.String("name", re.QueueName()), zap.Error(err))
+			continue
+		}
+		f := &forwarder{
+			export:  re,
+			queue:   queue,
+			backlog: queue.Len() > 0,
+			notify:  make(chan struct{}, 1),
+			stop:    make(chan struct{}),
+			done:    make(chan struct{}),
+		}
+		forwarders[e] = f
+		go f.replay()
+	}
+}
+
+// CloseQueues 停止补发任务并关闭缓存队列
+func CloseQueues() {
+	forwardersMutex.Lock()
+	defer forwardersMutex.Unlock()
+	for e, f := range forwarders {
+		close(f.stop)
+		<-f.done
+		if err := f.queue.Close(); err != nil {
+			logger.Logger.Error("close export queue error", zap.String("name", f.export.QueueName()), zap.Error(err))
+		}
+		delete(forwarders, e)
+	}
+}
+
+// Dispatch 将设备数据分发至所有 Export
+// ReliableExport 的数据写入缓存队列，由补发任务按顺序导出，上游响应缓慢时不阻塞分发
+// 乱序补录数据仅导出至 HistoricalReceiver
+func Dispatch(deviceData plugin.DeviceData) {
+	for _, e := range Exports {
+		if deviceData.ExportType == plugin.HistoricalExport {
+			if _, ok := e.(export.HistoricalReceiver); ok && e.IsReady() {
+				start := time.Now()
+				e.ExportTo(deviceData)
+				metrics.ExportDuration.Since(start, exportName(e))
+			}
+			continue
+		}
+		forwardersMutex.RLock()
+		f, ok := forwarders[e]
+		forwardersMutex.RUnlock()
+		if ok {
+			start := time.Now()
+			f.deliver(deviceData)
+			metrics.ExportDuration.Since(start, f.export.QueueName())
+			continue
+		}
+		if e.IsReady() {
+			start := time.Now()
+			e.ExportTo(deviceData)
+			metrics.ExportDuration.Since(start, exportName(e))
+		}
+	}
+}
+
+// exportName Export 的指标名称，取自其类型名
+func exportName(e export.Export) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", e), "*")
+}
+
+// GetQueueStats 查询所有断网续传队列状态
+func GetQueueStats() []QueueStats {
+	forwardersMutex.RLock()
+	defer forwardersMutex.RUnlock()
+	stats := make([]QueueStats, 0, len(forwarders))
+	for _, f := range forwarders {
+		stats = append(stats, QueueStats{
+			Name:      f.export.QueueName(),
+			Depth:     f.queue.Len(),
+			Dropped:   f.queue.Dropped(),
+			Corrupted: f.queue.Corrupted(),
+		})
+	}
+	return stats
+}
+
+// deliver 将设备数据写入缓存队列并通知补发任务
+func (f *forwarder) deliver(deviceData plugin.DeviceData) {
+	//记录入队时间作为数据源时间，补发时保留数据的原始时间
+	values := make([]plugin.PointData, len(deviceData.Values))
+	for i, point := range deviceData.Values {
+		if point.Timestamp <= 0 {
+			point.Timestamp = time.Now().UnixMilli()
+		}
+		values[i] = point
+	}
+	deviceData.Values = values
+	bs, err := encodeQueued(deviceData)
+	if err != nil {
+		logger.Logger.Error("marshal device data error", zap.String("name", f.export.QueueName()), zap.Error(err))
+		return
+	}
+	f.mutex.Lock()
+	err = f.queue.Push(bs)
+	f.mutex.Unlock()
+	if err != nil {
+		logger.Logger.Error("push export queue error", zap.String("name", f.export.QueueName()), zap.Error(err))
+		return
+	}
+	select {
+	case f.notify <- struct{}{}:
+	default:
+	}
+}
+
+// replay 按顺序补发缓存数据，补发失败时等待重试
+func (f *forwarder) replay() {
+	defer close(f.done)
+	ticker := time.NewTicker(replayInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-f.notify:
+		case <-ticker.C:
+		}
+		for f.replayOne() {
+			select {
+			case <-f.stop:
+				return
+			default:
+			}
+		}
+	}
+}
+
+// replayOne 导出队首数据，返回是否可继续导出
+func (f *forwarder) replayOne() bool {
+	if !f.export.IsReady() {
+		f.backlog = true
+		return false
+	}
+	f.mutex.Lock()
+	bs, err := f.queue.Peek()
+	dropped := f.queue.Dropped()
+	f.mutex.Unlock()
+	if err != nil {
+		if err == diskqueue.ErrEmpty {
+			f.backlog = false
+		} else {
+			logger.Logger.Error("peek export queue error", zap.String("name", f.export.QueueName()), zap.Error(err))
+		}
+		return false
+	}
+	deviceData, err := decodeQueued(bs)
+	if err == nil {
+		// 积压期间缓存的数据标记为补发
+		deviceData.Replayed = f.backlog
+		if err = f.export.Deliver(deviceData); err != nil {
+			logger.Logger.Warn("export data error, retry later", zap.String("name", f.export.QueueName()), zap.Error(err))
+			f.backlog = true
+			return false
+		}
+	} else {
+		logger.Logger.Error("unmarshal queued data error, discard", zap.String("name", f.export.QueueName()), zap.Error(err))
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.queue.Dropped() != dropped {
+		// 导出期间队列已满，队首数据已被丢弃
+		return true
+	}
+	if err = f.queue.Pop(); err != nil {
+		logger.Logger.Error("pop export queue error", zap.String("name", f.export.QueueName()), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// encodeQueued 序列化设备数据并记录基础类型点位值的类型
+func encodeQueued(deviceData plugin.DeviceData) ([]byte, error) {
+	data := queuedData{DeviceData: deviceData, Types: make([]string, len(deviceData.Values))}
+	for i, point := range deviceData.Values {
+		// 自定义序列化的类型无法按基础类型还原
+		if _, ok := point.Value.(json.Marshaler); ok || point.Value == nil {
+			continue
+		}
+		switch kind := reflect.TypeOf(point.Value).Kind(); kind {
+		case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			data.Types[i] = kind.String()
+		}
+	}
+	return json.Marshal(data)
+}
+
+// decodeQueued 反序列化设备数据并还原点位值类型，兼容未记录类型的旧数据
+func decodeQueued(bs []byte) (plugin.DeviceData, error) {
+	var data queuedData
+	if err := json.Unmarshal(bs, &data); err != nil {
+		return data.DeviceData, err
+	}
+	if len(data.Types) != len(data.Values) {
+		return data.DeviceData, nil
+	}
+	// 整数按原始文本解析，避免超出 float64 精度
+	var raw struct {
+		Values []struct {
+			Value json.RawMessage `json:"value"`
+		} `json:"values"`
+	}
+	if err := json.Unmarshal(bs, &raw); err != nil {
+		return data.DeviceData, err
+	}
+	for i, t := range data.Types {
+		if t == "" {
+			continue
+		}
+		if v, err := restoreValue(t, data.Values[i].Value, string(raw.Values[i].Value)); err == nil {
+			data.Values[i].Value = v
+		}
+	}
+	return data.DeviceData, nil
+}
+
+func restoreValue(kind string, value interface{}, raw string) (interface{}, error) {
+	switch kind {
+	case "float32":
+		f, ok := value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s: %s", kind, raw)
+		}
+		return float32(f), nil
+	case "int", "int8", "int16", "int32", "int64":
+		i, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		switch kind {
+		case "int":
+			return int(i), nil
+		case "int8":
+			return int8(i), nil
+		case "int16":
+			return int16(i), nil
+		case "int32":
+			return int32(i), nil
+		}
+		return i, nil
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		u, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		switch kind {
+		case "uint":
+			return uint(u), nil
+		case "uint8":
+			return uint8(u), nil
+		case "uint16":
+			return uint16(u), nil
+		case "uint32":
+			return uint32(u), nil
+		}
+		return u, nil
+	}
+	// bool、string、float64 经 JSON 后类型不变
+	return value, nil
+}
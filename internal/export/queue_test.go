@@ -0,0 +1,73 @@
+package export
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/ibuilding-x/driver-box/v2/driverbox/export"
+	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
+	"github.com/ibuilding-x/driver-box/v2/pkg/event"
+)
+
+func TestQueuedData(t *testing.T) {
+	values := []interface{}{int64(math.MaxInt64), uint16(65535), int32(-7), float32(1.5), 2.25, true, "on", nil, map[string]interface{}{"a": "b"}}
+	deviceData := plugin.DeviceData{ID: "d1"}
+	for _, v := range values {
+		deviceData.Values = append(deviceData.Values, plugin.PointData{PointName: "p", Value: v})
+	}
+	bs, err := encodeQueued(deviceData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decodeQueued(bs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range values[:8] {
+		if got.Values[i].Value != v {
+			t.Errorf("value %d = %#v, want %#v", i, got.Values[i].Value, v)
+		}
+	}
+
+	//兼容未记录类型的旧数据
+	bs, _ = json.Marshal(plugin.DeviceData{ID: "d1", Values: []plugin.PointData{{PointName: "p", Value: 1}}})
+	if got, err = decodeQueued(bs); err != nil || got.Values[0].Value != float64(1) {
+		t.Fatalf("got %v, err %v", got, err)
+	}
+}
+
+type testExport struct {
+	received []plugin.ExportType
+}
+
+func (e *testExport) Init() error { return nil }
+func (e *testExport) ExportTo(deviceData plugin.DeviceData) {
+	e.received = append(e.received, deviceData.ExportType)
+}
+func (e *testExport) OnEvent(event.EventCode, string, interface{}) error { return nil }
+func (e *testExport) IsReady() bool                                      { return true }
+func (e *testExport) Destroy() error                                     { return nil }
+
+type testHistoryExport struct {
+	testExport
+}
+
+func (e *testHistoryExport) ReceiveHistorical() {}
+
+func TestDispatchHistorical(t *testing.T) {
+	realtime, history := &testExport{}, &testHistoryExport{}
+	exports := Exports
+	Exports = []export.Export{realtime, history}
+	defer func() {
+		Exports = exports
+	}()
+	Dispatch(plugin.DeviceData{ID: "d1", ExportType: plugin.RealTimeExport})
+	Dispatch(plugin.DeviceData{ID: "d1", ExportType: plugin.HistoricalExport})
+	if len(realtime.received) != 1 || realtime.received[0] != plugin.RealTimeExport {
+		t.Fatalf("realtime export received %v", realtime.received)
+	}
+	if len(history.received) != 2 || history.received[1] != plugin.HistoricalExport {
+		t.Fatalf("history export received %v", history.received)
+	}
+}
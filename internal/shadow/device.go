@@ -4,7 +4,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
 	"github.com/ibuilding-x/driver-box/v2/driverbox/shadow"
+	"github.com/ibuilding-x/driver-box/v2/pkg/convutil"
 )
 
 // device 设备内部结构
@@ -38,25 +40,61 @@ func newDevicePoint(name string) *shadow.DevicePoint {
 	}
 }
 
-func (d *device) setPointValue(name string, value interface{}) {
+// setPointValue 更新点位值，返回更新时间
+func (d *device) setPointValue(name string, value interface{}) time.Time {
+	updatedAt, _ := d.setPointData(plugin.PointData{PointName: name, Value: value})
+	return updatedAt
+}
+
+// setPointData 更新点位值及数据质量，返回更新时间
+// 数据质量异常且值为 nil 时仅更新数据质量，乱序数据不做更新，此时 updated 为 false
+func (d *device) setPointData(data plugin.PointData) (updatedAt time.Time, updated bool) {
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 
-	// 更新设备最后更新时间
-	updatedAt := time.Now()
-	d.updatedAt = updatedAt
-
-	// 重置设备断开连接次数
-	d.disconnectTimes = 0
+	quality := data.Quality
+	if quality == "" {
+		quality = plugin.QualityGood
+	}
 
 	// 初始化设备点位
+	name := data.PointName
 	if d.points[name] == nil {
 		d.points[name] = newDevicePoint(name)
 	}
 
+	// 乱序数据不覆盖更新的影子值
+	var sourceAt time.Time
+	if data.Timestamp > 0 {
+		sourceAt = time.UnixMilli(data.Timestamp)
+		if sourceAt.Before(d.points[name].SourceAt) {
+			return d.points[name].UpdatedAt, false
+		}
+	}
+
+	// 仅更新数据质量
+	if data.Value == nil && !quality.IsGood() {
+		d.points[name].Quality = quality
+		return d.points[name].UpdatedAt, false
+	}
+
+	// 更新设备最后更新时间
+	updatedAt = time.Now()
+	d.updatedAt = updatedAt
+
+	// 重置设备断开连接次数
+	d.disconnectTimes = 0
+
 	// 更新设备点位值
-	d.points[name].Value = value
+	d.points[name].Value = data.Value
 	d.points[name].UpdatedAt = updatedAt
+	if sourceAt.IsZero() {
+		sourceAt = updatedAt
+	}
+	d.points[name].SourceAt = sourceAt
+	d.points[name].Stale = false
+	d.points[name].Quality = quality
+	return updatedAt, true
 }
 
 func (d *device) getPointValue(name string) (interface{}, bool) {
@@ -78,7 +116,8 @@ func (d *device) getPointValue(name string) (interface{}, bool) {
 	return nil, false
 }
 
-func (d *device) setWritePointValue(name string, value interface{}) {
+// setWritePointValue 更新点位写入值，返回写入时间
+func (d *device) setWritePointValue(name string, value interface{}) time.Time {
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 
@@ -88,8 +127,10 @@ func (d *device) setWritePointValue(name string, value interface{}) {
 	}
 
 	// 更新设备点位值
+	writeAt := time.Now()
 	d.points[name].WriteValue = value
-	d.points[name].WriteAt = time.Now()
+	d.points[name].WriteAt = writeAt
+	return writeAt
 }
 
 func (d *device) getWritePointValue(name string) (interface{}, bool) {
@@ -165,6 +206,27 @@ func (d *device) setOnline(online bool) bool {
 	return true
 }
 
+// setOffline 设置设备离线，并将点位数据质量置为通讯失败
+// 返回值为 true 表示状态变化，false 表示状态未变化
+func (d *device) setOffline() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if !d.online {
+		return false
+	}
+	d.online = false
+	d.setQuality(plugin.QualityCommFailure)
+	return true
+}
+
+// setQuality 设置所有点位的数据质量，调用方需持有锁
+func (d *device) setQuality(quality plugin.Quality) {
+	for _, point := range d.points {
+		point.Quality = quality
+	}
+}
+
 // maybeOffline 设备可能离线
 // 返回值为 true 表示本次设定满足离线条件，设备可能离线
 func (d *device) maybeOffline() bool {
@@ -180,6 +242,7 @@ func (d *device) maybeOffline() bool {
 	d.disconnectTimes++
 	if time.Since(d.updatedAt).Seconds() > 60 && d.disconnectTimes >= 3 {
 		d.online = false
+		d.setQuality(plugin.QualityCommFailure)
 		return true
 	}
 
@@ -199,6 +262,7 @@ func (d *device) refreshStatus() (old bool, new bool) {
 	// TTL 判定
 	if time.Since(d.updatedAt) > d.ttl {
 		d.online = false
+		d.setQuality(plugin.QualityStale)
 		return true, false
 	}
 
@@ -211,6 +275,46 @@ func toPublic(dp *shadow.DevicePoint) shadow.DevicePoint {
 		Value:      dp.Value,
 		WriteValue: dp.WriteValue,
 		UpdatedAt:  dp.UpdatedAt,
+		SourceAt:   dp.SourceAt,
 		WriteAt:    dp.WriteAt,
+		Stale:      dp.Stale,
+		Quality:    dp.Quality,
+	}
+}
+
+// restore 恢复持久化的设备状态，恢复的点位标记为过时，数据质量保持持久化时的状态
+// 点位值按持久化的值类型还原，以便与设备的最新数据比较是否变化
+func (d *device) restore(pd *persistDevice) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.online = pd.Online
+	d.updatedAt = pd.UpdatedAt
+	for _, p := range pd.Points {
+		point := p.DevicePoint
+		if p.ValueType != "" && point.Value != nil {
+			if value, err := convutil.PointValue(point.Value, p.ValueType); err == nil {
+				point.Value = value
+			}
+		}
+		point.Stale = true
+		d.points[point.Name] = &point
+	}
+}
+
+// toPersist 转换为持久化数据
+func (d *device) toPersist() persistDevice {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	points := make([]persistPoint, 0, len(d.points))
+	for _, point := range d.points {
+		points = append(points, persistPoint{DevicePoint: toPublic(point)})
+	}
+	return persistDevice{
+		ID:        d.id,
+		Online:    d.online,
+		UpdatedAt: d.updatedAt,
+		Points:    points,
 	}
 }
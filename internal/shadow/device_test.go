@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"testing"
 	"time"
+
+	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
 )
 
 var testDataTypeMap = map[string]any{
@@ -115,3 +117,49 @@ func TestDevice(t *testing.T) {
 		fmt.Println("-------------------------------------------------------")
 	})
 }
+
+func TestDeviceQuality(t *testing.T) {
+	dev := newDevice("device", "model", 10*time.Second)
+	dev.setOnline(true)
+	dev.setPointValue("point", 1.0)
+	if p, _ := dev.getPoint("point"); p.Quality != plugin.QualityGood {
+		t.Errorf("quality = %s, want good", p.Quality)
+	}
+
+	// 仅更新数据质量，保留原有值
+	if _, updated := dev.setPointData(plugin.PointData{PointName: "point", Quality: plugin.QualityBad}); updated {
+		t.Error("value should not be updated")
+	}
+	if p, _ := dev.getPoint("point"); p.Quality != plugin.QualityBad || p.Value != 1.0 {
+		t.Errorf("unexpected point: %+v", p)
+	}
+
+	// 设备离线后数据质量置为通讯失败
+	dev.setOffline()
+	if p, _ := dev.getPoint("point"); p.Quality != plugin.QualityCommFailure {
+		t.Errorf("quality = %s, want commFailure", p.Quality)
+	}
+}
+
+func TestDeviceSourceTimestamp(t *testing.T) {
+	dev := newDevice("device", "model", 10*time.Second)
+	now := time.Now()
+	dev.setPointData(plugin.PointData{PointName: "point", Value: 2.0, Timestamp: now.UnixMilli()})
+	if p, _ := dev.getPoint("point"); p.SourceAt.UnixMilli() != now.UnixMilli() {
+		t.Errorf("sourceAt = %v, want %v", p.SourceAt, now)
+	}
+
+	// 早于当前数据源时间的数据不生效
+	if _, updated := dev.setPointData(plugin.PointData{PointName: "point", Value: 1.0, Timestamp: now.Add(-time.Second).UnixMilli()}); updated {
+		t.Error("out-of-order value should not be applied")
+	}
+	if p, _ := dev.getPoint("point"); p.Value != 2.0 {
+		t.Errorf("value = %v, want 2", p.Value)
+	}
+
+	// 未携带时间戳时以接收时间为准
+	dev.setPointData(plugin.PointData{PointName: "point", Value: 3.0})
+	if p, _ := dev.getPoint("point"); p.Value != 3.0 || p.SourceAt.Before(now) {
+		t.Errorf("unexpected point: %+v", p)
+	}
+}
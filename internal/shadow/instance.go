@@ -2,15 +2,19 @@ package shadow
 
 import (
 	"errors"
+	"os"
 
+	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
 	"github.com/ibuilding-x/driver-box/v2/driverbox/shadow"
 	"github.com/ibuilding-x/driver-box/v2/internal/export"
 	"github.com/ibuilding-x/driver-box/v2/internal/logger"
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
 	"github.com/ibuilding-x/driver-box/v2/pkg/crontab"
 	"github.com/ibuilding-x/driver-box/v2/pkg/event"
 	"go.uber.org/zap"
 
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -21,8 +25,19 @@ var once = &sync.Once{}
 type deviceShadow struct {
 	devices map[string]*device
 	mutex   *sync.RWMutex
+	// 持久化，未启用时为 nil
+	persist *persister
+	// 周期快照任务
+	snapshotTask *crontab.Future
+	// 串行生成快照，保证快照与轮转出的预写日志一一对应
+	snapshotMutex sync.Mutex
+	// 点位值类型查询函数，类型为 ValueTypeFunc，持久化时记录点位值类型
+	valueType atomic.Value
 }
 
+// ValueTypeFunc 查询点位值类型，点位不存在时返回空
+type ValueTypeFunc func(deviceId, pointName string) config.ValueType
+
 func Shadow() shadow.DeviceShadow {
 	once.Do(func() {
 		ds := &deviceShadow{
@@ -41,9 +56,62 @@ func Shadow() shadow.DeviceShadow {
 }
 
 func Reset() {
+	if ds, ok := instance.(*deviceShadow); ok {
+		ds.closePersist()
+	}
 	once = &sync.Once{}
 }
 
+// Restore 启用设备影子持久化，并恢复已添加设备的历史数据
+// valueType 用于记录点位值类型，恢复时据此还原点位值；未配置持久化路径时不做处理
+func Restore(valueType ValueTypeFunc) error {
+	dir := os.Getenv(config.ENV_SHADOW_PERSIST_PATH)
+	if dir == "" {
+		return nil
+	}
+	ds := Shadow().(*deviceShadow)
+	if ds.persist != nil {
+		return nil
+	}
+	p, err := openPersister(dir)
+	if err != nil {
+		return err
+	}
+	devices, err := p.load()
+	if err != nil {
+		_ = p.close()
+		return err
+	}
+
+	if valueType != nil {
+		ds.valueType.Store(valueType)
+	}
+	ds.mutex.Lock()
+	for id, pd := range devices {
+		if ds.devices[id] != nil {
+			ds.devices[id].restore(pd)
+		}
+	}
+	ds.persist = p
+	ds.mutex.Unlock()
+	logger.Logger.Info("restore device shadow", zap.String("path", dir), zap.Int("devices", len(devices)))
+
+	// 立即生成快照，剔除已不存在的设备
+	ds.snapshot()
+	interval := os.Getenv(config.ENV_SHADOW_SNAPSHOT_INTERVAL)
+	if interval == "" {
+		interval = "60s"
+	}
+	task, err := crontab.Instance().AddFunc(interval, ds.snapshot)
+	if err != nil {
+		return err
+	}
+	ds.mutex.Lock()
+	ds.snapshotTask = task
+	ds.mutex.Unlock()
+	return nil
+}
+
 func (d *deviceShadow) AddDevice(id string, modelName string, ttl ...time.Duration) {
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
@@ -101,12 +169,23 @@ func (d *deviceShadow) DeleteDevice(id ...string) (err error) {
 }
 
 func (d *deviceShadow) SetDevicePoint(id, pointName string, value interface{}) (err error) {
+	return d.SetDevicePointData(id, plugin.PointData{PointName: pointName, Value: value})
+}
+
+func (d *deviceShadow) SetDevicePointData(id string, data plugin.PointData) (err error) {
+	// 值类型查询依赖核心缓存，需在加锁前完成，避免与核心缓存互相等待
+	valueType := d.pointValueType(id, data.PointName)
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 
 	if d.devices[id] != nil {
 		// 更新点位值
-		d.devices[id].setPointValue(pointName, value)
+		updatedAt, updated := d.devices[id].setPointData(data)
+		if !updated {
+			return
+		}
+		d.log(walRecord{Op: walOpPoint, ID: id, Point: data.PointName, Value: data.Value, Time: updatedAt,
+			SourceAt: data.Timestamp, Quality: data.Quality, ValueType: valueType})
 		// 更新设备状态
 		if d.devices[id].setOnline(true) {
 			d.handlerCallback(id, true)
@@ -196,7 +275,7 @@ func (d *deviceShadow) SetOffline(id string) (err error) {
 	defer d.mutex.Unlock()
 
 	if d.devices[id] != nil {
-		d.devices[id].setOnline(false)
+		d.devices[id].setOffline()
 		d.handlerCallback(id, false)
 		return
 	}
@@ -237,7 +316,8 @@ func (d *deviceShadow) SetWritePointValue(id string, pointName string, value int
 	defer d.mutex.Unlock()
 
 	if d.devices[id] != nil {
-		d.devices[id].setWritePointValue(pointName, value)
+		writeAt := d.devices[id].setWritePointValue(pointName, value)
+		d.log(walRecord{Op: walOpWrite, ID: id, Point: pointName, Value: value, Time: writeAt})
 		return
 	}
 
@@ -258,6 +338,7 @@ func (d *deviceShadow) GetWritePointValue(id string, pointName string) (value in
 }
 
 func (d *deviceShadow) handlerCallback(id string, online bool) {
+	d.log(walRecord{Op: walOpOnline, ID: id, Online: online, Time: time.Now()})
 	if online {
 		logger.Logger.Info("device online", zap.String("deviceId", id))
 	} else {
@@ -279,3 +360,76 @@ func (d *deviceShadow) checkOffline() {
 		}
 	}
 }
+
+// log 记录预写日志
+func (d *deviceShadow) log(record walRecord) {
+	if d.persist == nil {
+		return
+	}
+	if err := d.persist.append(record); err != nil {
+		logger.Logger.Error("write shadow wal error", zap.String("deviceId", record.ID), zap.Error(err))
+	}
+}
+
+// pointValueType 查询点位值类型，未启用持久化时不查询
+func (d *deviceShadow) pointValueType(id, pointName string) config.ValueType {
+	valueType, _ := d.valueType.Load().(ValueTypeFunc)
+	if valueType == nil {
+		return ""
+	}
+	return valueType(id, pointName)
+}
+
+// snapshot 生成设备影子快照
+// 持有锁期间仅复制设备数据并轮转预写日志，序列化及文件读写在锁外进行，不阻塞点位读写
+func (d *deviceShadow) snapshot() {
+	d.snapshotMutex.Lock()
+	defer d.snapshotMutex.Unlock()
+
+	d.mutex.Lock()
+	p := d.persist
+	if p == nil {
+		d.mutex.Unlock()
+		return
+	}
+	devices := make([]persistDevice, 0, len(d.devices))
+	for _, dev := range d.devices {
+		devices = append(devices, dev.toPersist())
+	}
+	err := p.rotate()
+	d.mutex.Unlock()
+	if err != nil {
+		logger.Logger.Error("rotate device shadow wal error", zap.Error(err))
+		return
+	}
+
+	for i := range devices {
+		for j := range devices[i].Points {
+			devices[i].Points[j].ValueType = d.pointValueType(devices[i].ID, devices[i].Points[j].Name)
+		}
+	}
+	if err = p.snapshot(devices); err != nil {
+		logger.Logger.Error("snapshot device shadow error", zap.Error(err))
+	}
+}
+
+// closePersist 停止周期快照，生成最终快照并关闭持久化
+func (d *deviceShadow) closePersist() {
+	d.mutex.Lock()
+	task := d.snapshotTask
+	d.snapshotTask = nil
+	d.mutex.Unlock()
+	if task != nil {
+		task.Disable()
+	}
+	d.snapshot()
+
+	d.snapshotMutex.Lock()
+	defer d.snapshotMutex.Unlock()
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.persist != nil {
+		_ = d.persist.close()
+		d.persist = nil
+	}
+}
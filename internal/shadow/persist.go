@@ -0,0 +1,271 @@
+package shadow
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
+	"github.com/ibuilding-x/driver-box/v2/driverbox/shadow"
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+)
+
+const (
+	snapshotFile = "snapshot.json"
+	walFile      = "wal.log"
+	// 生成快照期间轮转出的预写日志，快照完成后删除
+	rotatedWalFile = "wal.log.1"
+
+	// WAL 操作类型
+	walOpPoint  = "point"
+	walOpWrite  = "write"
+	walOpOnline = "online"
+)
+
+// persistDevice 设备影子持久化数据
+type persistDevice struct {
+	ID        string         `json:"id"`
+	Online    bool           `json:"online"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	Points    []persistPoint `json:"points"`
+}
+
+// persistPoint 点位持久化数据，记录点位值类型，恢复时按值类型还原反序列化后的点位值
+type persistPoint struct {
+	shadow.DevicePoint
+	ValueType config.ValueType `json:"valueType,omitempty"`
+}
+
+// walRecord 预写日志记录
+type walRecord struct {
+	Op     string      `json:"op"`
+	ID     string      `json:"id"`
+	Point  string      `json:"point,omitempty"`
+	Value  interface{} `json:"value"`
+	Online bool        `json:"online,omitempty"`
+	Time   time.Time   `json:"time"`
+	// SourceAt 点位值的数据源时间，Unix 毫秒
+	SourceAt  int64            `json:"sourceAt,omitempty"`
+	Quality   plugin.Quality   `json:"quality,omitempty"`
+	ValueType config.ValueType `json:"valueType,omitempty"`
+}
+
+// persister 设备影子持久化，由周期快照及快照之后的预写日志组成
+type persister struct {
+	dir   string
+	mutex sync.Mutex
+	wal   *os.File
+}
+
+func openPersister(dir string) (*persister, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	wal, err := os.OpenFile(filepath.Join(dir, walFile), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &persister{dir: dir, wal: wal}, nil
+}
+
+// load 读取快照并重放预写日志
+func (p *persister) load() (map[string]*persistDevice, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	devices := make(map[string]*persistDevice)
+	bs, err := os.ReadFile(filepath.Join(p.dir, snapshotFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if len(bs) > 0 {
+		var list []persistDevice
+		if err = json.Unmarshal(bs, &list); err != nil {
+			return nil, err
+		}
+		for i := range list {
+			devices[list[i].ID] = &list[i]
+		}
+	}
+
+	//上次快照未完成时，轮转出的预写日志早于当前预写日志
+	rotated, err := os.Open(filepath.Join(p.dir, rotatedWalFile))
+	if err == nil {
+		err = replayWal(devices, rotated)
+		_ = rotated.Close()
+		if err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if _, err = p.wal.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	return devices, replayWal(devices, p.wal)
+}
+
+// replayWal 逐条重放预写日志
+func replayWal(devices map[string]*persistDevice, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var record walRecord
+		//进程异常退出可能导致末尾记录不完整，忽略即可
+		if json.Unmarshal(scanner.Bytes(), &record) != nil {
+			continue
+		}
+		replay(devices, record)
+	}
+	return scanner.Err()
+}
+
+// replay 将预写日志应用至设备数据
+func replay(devices map[string]*persistDevice, record walRecord) {
+	dev, ok := devices[record.ID]
+	if !ok {
+		dev = &persistDevice{ID: record.ID}
+		devices[record.ID] = dev
+	}
+	if record.Op == walOpOnline {
+		dev.Online = record.Online
+		return
+	}
+	index := -1
+	for i := range dev.Points {
+		if dev.Points[i].Name == record.Point {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		dev.Points = append(dev.Points, persistPoint{DevicePoint: shadow.DevicePoint{Name: record.Point}})
+		index = len(dev.Points) - 1
+	}
+	switch record.Op {
+	case walOpPoint:
+		dev.Points[index].Value = record.Value
+		dev.Points[index].Quality = record.Quality
+		dev.Points[index].ValueType = record.ValueType
+		dev.Points[index].UpdatedAt = record.Time
+		dev.Points[index].SourceAt = record.Time
+		if record.SourceAt > 0 {
+			dev.Points[index].SourceAt = time.UnixMilli(record.SourceAt)
+		}
+		dev.UpdatedAt = record.Time
+	case walOpWrite:
+		dev.Points[index].WriteValue = record.Value
+		dev.Points[index].WriteAt = record.Time
+	}
+}
+
+// append 追加预写日志
+func (p *persister) append(record walRecord) error {
+	bs, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.wal == nil {
+		return os.ErrClosed
+	}
+	_, err = p.wal.Write(append(bs, '\n'))
+	return err
+}
+
+// rotate 轮转预写日志，之后的记录写入新的预写日志
+// 调用方需保证轮转时的设备数据与轮转前的预写日志一致，随后调用 snapshot 生成快照
+func (p *persister) rotate() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.wal == nil {
+		return os.ErrClosed
+	}
+	rotated := filepath.Join(p.dir, rotatedWalFile)
+	if _, err := os.Stat(rotated); err == nil {
+		//上次快照未完成，轮转出的预写日志仍需保留，追加当前记录后清空
+		if err = appendFile(rotated, p.wal); err != nil {
+			return err
+		}
+		return p.wal.Truncate(0)
+	}
+	if err := os.Rename(filepath.Join(p.dir, walFile), rotated); err != nil {
+		return err
+	}
+	wal, err := os.OpenFile(filepath.Join(p.dir, walFile), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	_ = p.wal.Close()
+	p.wal = wal
+	return nil
+}
+
+// snapshot 生成快照并删除轮转出的预写日志，不持有 mutex，不阻塞预写日志的写入
+func (p *persister) snapshot(devices []persistDevice) error {
+	bs, err := json.Marshal(devices)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(p.dir, snapshotFile+".tmp")
+	if err = writeFileSync(tmp, bs); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, filepath.Join(p.dir, snapshotFile)); err != nil {
+		return err
+	}
+	if err = os.Remove(filepath.Join(p.dir, rotatedWalFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (p *persister) close() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.wal == nil {
+		return nil
+	}
+	err := p.wal.Close()
+	p.wal = nil
+	return err
+}
+
+// appendFile 将 src 的全部内容追加至文件 name
+func appendFile(name string, src *os.File) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = src.Seek(0, 0); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if _, err = io.Copy(f, src); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeFileSync(name string, data []byte) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,136 @@
+package shadow
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+)
+
+func TestPersister(t *testing.T) {
+	dir := t.TempDir()
+	p, err := openPersister(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updatedAt := time.Now().Add(-time.Minute).Round(time.Millisecond)
+	dev := newDevice("device", "model", time.Hour)
+	dev.online = true
+	dev.points["p1"] = newDevicePoint("p1")
+	dev.points["p1"].Value = 1.0
+	dev.points["p1"].UpdatedAt = updatedAt
+	if err = p.snapshot([]persistDevice{dev.toPersist()}); err != nil {
+		t.Fatal(err)
+	}
+
+	writeAt := updatedAt.Add(time.Second)
+	records := []walRecord{
+		{Op: walOpPoint, ID: "device", Point: "p1", Value: int64(2), Time: updatedAt, ValueType: config.ValueType_Int},
+		{Op: walOpWrite, ID: "device", Point: "p2", Value: "on", Time: writeAt},
+		{Op: walOpOnline, ID: "device", Online: false, Time: writeAt},
+	}
+	for _, record := range records {
+		if err = p.append(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = p.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err = openPersister(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.close()
+	devices, err := p.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := newDevice("device", "model", time.Hour)
+	restored.restore(devices["device"])
+	if restored.online {
+		t.Error("device should be offline")
+	}
+	//反序列化后的数值按值类型还原，数据质量保持不变，仅标记为过时
+	p1, _ := restored.getPoint("p1")
+	if p1.Value != int64(2) || !p1.UpdatedAt.Equal(updatedAt) || !p1.Stale || !p1.Quality.IsGood() {
+		t.Errorf("unexpected p1: %+v", p1)
+	}
+	p2, _ := restored.getPoint("p2")
+	if p2.WriteValue != "on" || !p2.WriteAt.Equal(writeAt) {
+		t.Errorf("unexpected p2: %+v", p2)
+	}
+
+	// 收到新数据后不再标记为过时
+	restored.setPointValue("p1", 3.0)
+	if p1, _ = restored.getPoint("p1"); p1.Stale {
+		t.Error("p1 should not be stale")
+	}
+}
+
+func TestPersisterRotate(t *testing.T) {
+	dir := t.TempDir()
+	p, err := openPersister(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	_ = p.append(walRecord{Op: walOpPoint, ID: "device", Point: "p1", Value: 1.0, Time: now, Quality: plugin.QualityBad})
+	if err = p.rotate(); err != nil {
+		t.Fatal(err)
+	}
+	_ = p.append(walRecord{Op: walOpPoint, ID: "device", Point: "p2", Value: 2.0, Time: now})
+	//快照未完成时再次轮转，轮转出的预写日志保留全部记录
+	if err = p.rotate(); err != nil {
+		t.Fatal(err)
+	}
+	_ = p.append(walRecord{Op: walOpPoint, ID: "device", Point: "p3", Value: 3.0, Time: now})
+	devices, err := p.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if points := devices["device"].Points; len(points) != 3 || points[0].Quality != plugin.QualityBad {
+		t.Fatalf("points = %+v", points)
+	}
+
+	//快照完成后删除轮转出的预写日志
+	if err = p.snapshot([]persistDevice{{ID: "device"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, rotatedWalFile)); !os.IsNotExist(err) {
+		t.Fatalf("rotated wal should be removed, err = %v", err)
+	}
+	_ = p.close()
+	p, _ = openPersister(dir)
+	defer p.close()
+	if devices, err = p.load(); err != nil || len(devices["device"].Points) != 1 || devices["device"].Points[0].Name != "p3" {
+		t.Fatalf("devices = %+v, err = %v", devices, err)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	t.Setenv(config.ENV_SHADOW_PERSIST_PATH, t.TempDir())
+	t.Cleanup(Reset)
+	valueType := func(deviceId, pointName string) config.ValueType {
+		return config.ValueType_Int
+	}
+	for i := 0; i < 2; i++ {
+		Reset()
+		Shadow().AddDevice("restore-d1", "model")
+		if err := Restore(valueType); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			_ = Shadow().SetDevicePointData("restore-d1", plugin.PointData{PointName: "p1", Value: int64(5)})
+		}
+	}
+	//恢复后的点位值及数据质量与重启前一致，最新数据不会被误判为变化
+	p1, err := Shadow().GetDevicePointDetails("restore-d1", "p1")
+	if err != nil || p1.Value != int64(5) || !p1.Quality.IsGood() || !p1.Stale {
+		t.Fatalf("p1 = %+v, err = %v", p1, err)
+	}
+}
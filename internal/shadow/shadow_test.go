@@ -1,10 +1,19 @@
 package shadow
 
 import (
+	"os"
 	"testing"
 	"time"
+
+	"github.com/ibuilding-x/driver-box/v2/internal/logger"
+	"go.uber.org/zap"
 )
 
+func TestMain(m *testing.M) {
+	logger.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
 func TestShadow(t *testing.T) {
 	shadow := Shadow()
 
@@ -109,12 +118,6 @@ func TestShadow(t *testing.T) {
 		}
 	})
 
-	t.Run("TestSetOnlineChangeCallback", func(t *testing.T) {
-		shadow.SetOnlineChangeCallback(func(device string, online bool) {
-			t.Log("online change callback", device, online)
-		})
-	})
-
 	t.Run("TestSetWritePointValue", func(t *testing.T) {
 		for k, v := range testDataTypeMap {
 			if err := shadow.SetWritePointValue("device", k, v); err != nil {
@@ -27,6 +27,18 @@ const (
 	//是否虚拟设备模式: true:是,false:否
 	ENV_LUA_PRINT_ENABLED = "DRIVERBOX_LUA_PRINT_ENABLE"
 
+	//设备配置存储方式：file（默认）、sqlite
+	ENV_CONFIG_STORE = "DRIVERBOX_CONFIG_STORE"
+	//sqlite 配置存储的数据库文件路径，默认值：{资源目录}/config.db
+	ENV_CONFIG_STORE_PATH = "DRIVERBOX_CONFIG_STORE_PATH"
+	//配置版本历史最多保留的版本数量，默认值：1000
+	ENV_CONFIG_HISTORY_MAX_COUNT = "DRIVERBOX_CONFIG_HISTORY_MAX_COUNT"
+
+	//设备影子持久化存放路径，为空时不启用持久化
+	ENV_SHADOW_PERSIST_PATH = "DRIVERBOX_SHADOW_PERSIST_PATH"
+	//设备影子快照生成频率，默认值：60s
+	ENV_SHADOW_SNAPSHOT_INTERVAL = "DRIVERBOX_SHADOW_SNAPSHOT_INTERVAL"
+
 	//镜像设备功能是否可用
 	ENV_EXPORT_DISCOVER_ENABLED = "EXPORT_DISCOVER_ENABLED"
 
@@ -41,6 +53,23 @@ const (
 	EXPORT_HISTORY_SNAPSHOT_FLUSH_INTERVAL = "EXPORT_HISTORY_SNAPSHOT_FLUSH_INTERVAL"
 	//实时数据写入频率，默认值：5s
 	EXPORT_HISTORY_REAL_TIME_FLUSH_INTERVAL = "EXPORT_HISTORY_REAL_TIME_FLUSH_INTERVAL"
+
+	//场景联动执行记录存放路径
+	EXPORT_LINKEDGE_RECORD_PATH = "EXPORT_LINKEDGE_RECORD_PATH"
+	//场景联动执行记录保存时长，单位（天），默认值：7
+	EXPORT_LINKEDGE_RECORD_RESERVED_DAYS = "EXPORT_LINKEDGE_RECORD_RESERVED_DAYS"
+	//单个场景联动最多保留的执行记录条数，默认值：1000
+	EXPORT_LINKEDGE_RECORD_MAX_COUNT = "EXPORT_LINKEDGE_RECORD_MAX_COUNT"
+
+	//Export断网续传队列存放路径
+	EXPORT_QUEUE_PATH = "EXPORT_QUEUE_PATH"
+	//Export断网续传队列最大缓存条数，默认值：10000
+	EXPORT_QUEUE_MAX_SIZE = "EXPORT_QUEUE_MAX_SIZE"
+
+	//告警历史存放路径
+	EXPORT_ALARM_DATA_PATH = "EXPORT_ALARM_DATA_PATH"
+	//已清除告警保存时长，单位（天），默认值：30
+	EXPORT_ALARM_RESERVED_DAYS = "EXPORT_ALARM_RESERVED_DAYS"
 )
 
 // 资源文件目录
@@ -1,6 +1,9 @@
 package config
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"strconv"
+)
 
 type PointEnum struct {
 	//枚举名称
@@ -20,6 +23,9 @@ type ReadWrite string
 // 点位数据类型
 type ValueType string
 
+// 点位写入校验策略
+type WritePolicy string
+
 var (
 	//实时上报,读到数据即触发
 	ReportMode_Real ReportMode = "realTime"
@@ -37,6 +43,20 @@ var (
 	ValueType_Float ValueType = "float"
 	//点位类型：字符串
 	ValueType_String ValueType = "string"
+	//点位类型：布尔
+	ValueType_Bool ValueType = "bool"
+	//点位类型：数组
+	ValueType_Array ValueType = "array"
+	//点位类型：对象
+	ValueType_Object ValueType = "object"
+	//点位类型：日期时间
+	ValueType_Datetime ValueType = "datetime"
+	//写入后不校验
+	WritePolicy_None WritePolicy = "none"
+	//写入后回读校验，不一致时写操作失败
+	WritePolicy_Verify WritePolicy = "verify"
+	//写入后回读校验，不一致时重新写入
+	WritePolicy_VerifyAndRetry WritePolicy = "verifyAndRetry"
 )
 
 type Point map[string]interface{} // 点位 Map，可转换为标准点位数据
@@ -44,17 +64,15 @@ type Point map[string]interface{} // 点位 Map，可转换为标准点位数据
 // Name 获取点位名称
 // 返回点位的名称，该名称是点位的唯一标识符
 func (pm Point) Name() string {
-	return pm["name"].(string)
+	name, _ := pm["name"].(string)
+	return name
 }
 
 // ReadWrite 获取点位读写模式
 // 返回点位的读写权限设置，如只读、只写或读写
 func (pm Point) ReadWrite() ReadWrite {
-	valueType, ok := pm.FieldValue("readWrite")
-	if !ok {
-		return ""
-	}
-	return ReadWrite(valueType.(string))
+	readWrite, _ := pm["readWrite"].(string)
+	return ReadWrite(readWrite)
 }
 
 // FieldValue 根据键名获取点位字段值
@@ -68,7 +86,8 @@ func (pm Point) FieldValue(key string) (v interface{}, exists bool) {
 // Description 获取点位描述信息
 // 返回点位的详细描述文本，用于说明点位的用途和含义
 func (pm Point) Description() string {
-	return pm["description"].(string)
+	description, _ := pm["description"].(string)
+	return description
 }
 
 // Enums 获取点位枚举值列表
@@ -86,42 +105,85 @@ func (p Point) Enums() []PointEnum {
 	return enums
 }
 
+// PointTransform 点位值转换步骤
+type PointTransform struct {
+	//转换类型：linear、clamp、map、bit、unit、invert、piecewise
+	Type string `json:"type"`
+	//线性转换 y=a*x+b
+	A float64 `json:"a,omitempty"`
+	B float64 `json:"b,omitempty"`
+	//限幅范围
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+	//映射表，key为原始值，value为转换后的值
+	Map map[string]interface{} `json:"map,omitempty"`
+	//映射表中不存在时的默认值
+	Default interface{} `json:"default,omitempty"`
+	//位提取的起始位及位数，位数默认为1
+	Offset int `json:"offset,omitempty"`
+	Length int `json:"length,omitempty"`
+	//单位转换的原始单位及目标单位
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+	//分段线性插值表，每项为[原始值, 转换值]，按原始值升序排列
+	Table [][2]float64 `json:"table,omitempty"`
+}
+
+// Transforms 获取点位值转换链
+// 读取时按顺序执行，写入时逆序执行逆转换，未设置则返回空数组
+func (p Point) Transforms() []PointTransform {
+	transforms := make([]PointTransform, 0)
+	v, ok := p.FieldValue("transforms")
+	if !ok {
+		return transforms
+	}
+	b, err := json.Marshal(v)
+	if err == nil {
+		json.Unmarshal(b, &transforms)
+	}
+	return transforms
+}
+
 // ValueType 获取点位数据类型
 // 返回点位的数据类型，如整型、浮点型、布尔型等
 func (pm Point) ValueType() ValueType {
-	valueType, ok := pm["valueType"]
-	if !ok {
-		return ""
-	}
-	return ValueType(valueType.(string))
+	valueType, _ := pm["valueType"].(string)
+	return ValueType(valueType)
 }
 
 // ReportMode 获取点位上报模式
 // 返回点位的数据上报模式，如实时上报、变化上报等
 // 如果配置中未指定，则默认为实时上报模式
 func (pm Point) ReportMode() ReportMode {
-	reportMode, ok := pm.FieldValue("reportMode")
+	reportMode, ok := pm["reportMode"].(string)
 	if !ok {
 		return ReportMode_Real
 	}
-	return ReportMode(reportMode.(string))
+	return ReportMode(reportMode)
+}
+
+// WritePolicy 获取点位写入校验策略
+// 由支持写入校验的插件在写操作中同步执行，未设置时不校验
+func (pm Point) WritePolicy() WritePolicy {
+	policy, ok := pm["writePolicy"].(string)
+	if !ok || policy == "" {
+		return WritePolicy_None
+	}
+	return WritePolicy(policy)
 }
 
 // Scale 获取点位缩放比例
 // 返回点位数值的缩放系数，用于数值转换，默认为0（无缩放）
 func (pm Point) Scale() float64 {
-	scale, ok := pm["scale"]
-	if !ok {
-		return 0
-	}
-	return scale.(float64)
+	scale, _ := pm.floatField("scale")
+	return scale
 }
 
 // Decimals 获取点位小数位数
 // 返回点位数值保留的小数位数
 // 对于浮点数类型，默认保留2位小数；对于其他类型，默认为0位小数
 func (pm Point) Decimals() int {
-	decimals, ok := pm["decimals"]
+	decimals, ok := pm.floatField("decimals")
 	if !ok {
 		//浮点数，且未指定decimals，默认未2
 		if pm.ValueType() == ValueType_Float {
@@ -130,20 +192,110 @@ func (pm Point) Decimals() int {
 			return 0
 		}
 	}
-	switch decimals.(type) {
-	case float64:
-		return int(decimals.(float64))
-	default:
-		return decimals.(int)
-	}
+	return int(decimals)
 }
 
 // Units 获取点位单位
 // 返回点位数值的单位标识，如℃、kW、m³等
 func (pm Point) Units() string {
-	defaultValue, ok := pm.FieldValue("units")
+	units, _ := pm["units"].(string)
+	return units
+}
+
+// Min 获取点位量程下限
+// 返回下限值及是否配置，超出量程的点位值数据质量为 outOfRange
+func (pm Point) Min() (float64, bool) {
+	return pm.floatField("min")
+}
+
+// Max 获取点位量程上限
+// 返回上限值及是否配置，超出量程的点位值数据质量为 outOfRange
+func (pm Point) Max() (float64, bool) {
+	return pm.floatField("max")
+}
+
+// Deadband 获取点位上报绝对死区
+// 变化上报模式下，点位值与上次上报值之差超过死区才触发上报，默认为0
+func (pm Point) Deadband() float64 {
+	deadband, _ := pm.floatField("deadband")
+	return deadband
+}
+
+// DeadbandPercent 获取点位上报百分比死区
+// 变化上报模式下，点位值相对上次上报值的变化幅度（%）超过死区才触发上报，默认为0
+func (pm Point) DeadbandPercent() float64 {
+	deadband, _ := pm.floatField("deadbandPercent")
+	return deadband
+}
+
+// MinReportInterval 获取点位最小上报间隔，单位：秒
+// 距上次上报不足该时长时不触发上报，默认为0（不限制）
+func (pm Point) MinReportInterval() float64 {
+	interval, _ := pm.floatField("minReportInterval")
+	return interval
+}
+
+// MaxReportInterval 获取点位最大静默间隔，单位：秒
+// 距上次上报超过该时长时，即使点位值未变化也触发上报，默认为0（不限制）
+func (pm Point) MaxReportInterval() float64 {
+	interval, _ := pm.floatField("maxReportInterval")
+	return interval
+}
+
+// AlarmHigh 获取点位高限告警阈值
+// 返回高限阈值及是否配置
+func (pm Point) AlarmHigh() (float64, bool) {
+	return pm.floatField("alarmHigh")
+}
+
+// AlarmLow 获取点位低限告警阈值
+// 返回低限阈值及是否配置
+func (pm Point) AlarmLow() (float64, bool) {
+	return pm.floatField("alarmLow")
+}
+
+// AlarmDeadband 获取点位告警死区
+// 点位值需回落超出死区范围才会清除告警，避免告警在阈值附近反复抖动，默认为0
+func (pm Point) AlarmDeadband() float64 {
+	deadband, _ := pm.floatField("alarmDeadband")
+	return deadband
+}
+
+// AlarmDelay 获取点位告警延时，单位：秒
+// 越限状态需持续该时长才会产生告警，默认为0（立即告警）
+func (pm Point) AlarmDelay() float64 {
+	delay, _ := pm.floatField("alarmDelay")
+	return delay
+}
+
+// AlarmSeverity 获取点位告警等级，未配置时返回空字符串
+func (pm Point) AlarmSeverity() string {
+	severity, ok := pm["alarmSeverity"].(string)
 	if !ok {
 		return ""
 	}
-	return defaultValue.(string)
+	return severity
+}
+
+// floatField 获取数值类型字段，兼容 json 解析的 float64 及代码设置的整型、字符串
+func (pm Point) floatField(key string) (float64, bool) {
+	v, ok := pm[key]
+	if !ok {
+		return 0, false
+	}
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
 }
@@ -0,0 +1,32 @@
+package config
+
+// ConfigStore 设备配置存储接口，核心缓存通过该接口加载及持久化各插件的模型、设备、连接配置
+// 内置实现：file（res/driver/*/config.json，默认）、sqlite
+type ConfigStore interface {
+	// Load 读取全部插件的配置，key 为插件名称
+	Load() (map[string]StoredConfig, error)
+
+	// Revisions 查询各插件配置的当前版本号，key 为插件名称，用于检测配置的外部修改
+	Revisions() (map[string]int64, error)
+
+	// Save 原子保存插件的完整配置（模型、设备、连接），返回保存后该插件的版本号
+	Save(c DeviceConfig) (revision int64, err error)
+
+	// Delete 删除插件的全部配置
+	Delete(pluginName string) error
+
+	// Revision 配置存储的全局版本号，每次保存或删除后递增
+	Revision() int64
+
+	// Close 关闭存储
+	Close() error
+}
+
+// StoredConfig 配置存储中的插件配置
+type StoredConfig struct {
+	DeviceConfig
+	// 配置来源，用于错误提示，如配置文件路径
+	Source string
+	// 插件配置的版本号
+	Revision int64
+}
@@ -0,0 +1,320 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ValidationError 配置校验错误
+type ValidationError struct {
+	// 配置文件路径，非文件来源的配置为空
+	File string `json:"file,omitempty"`
+	// 出错字段的 JSON 路径，如 $.deviceModels[0].devicePoints[1].valueType
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	if e.File == "" {
+		return e.Path + ": " + e.Message
+	}
+	return e.File + ": " + e.Path + ": " + e.Message
+}
+
+// ValidationErrors 配置校验错误列表
+type ValidationErrors []ValidationError
+
+func (es ValidationErrors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Err 不存在错误时返回 nil，避免 nil 切片转换为非 nil 的 error
+func (es ValidationErrors) Err() error {
+	if len(es) == 0 {
+		return nil
+	}
+	return es
+}
+
+// WithFile 为所有错误设置配置文件路径
+func (es ValidationErrors) WithFile(file string) ValidationErrors {
+	for i := range es {
+		es[i].File = file
+	}
+	return es
+}
+
+// prefix 为所有错误的 JSON 路径添加前缀
+func (es ValidationErrors) prefix(path string) ValidationErrors {
+	for i := range es {
+		if es[i].Path == "" {
+			es[i].Path = path
+		} else {
+			es[i].Path = path + "." + es[i].Path
+		}
+	}
+	return es
+}
+
+func (es *ValidationErrors) add(path, format string, args ...interface{}) {
+	*es = append(*es, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// PointValidator 插件扩展点位字段的校验函数，返回错误的 Path 为点位内的字段名
+type PointValidator func(point Point) ValidationErrors
+
+// ValidateDeviceConfig 校验 config.json 的完整配置
+// 包括必填字段、点位定义、设备连接引用，validatePoint 为空时不校验插件扩展字段
+func ValidateDeviceConfig(c DeviceConfig, validatePoint PointValidator) ValidationErrors {
+	var errs ValidationErrors
+	if c.PluginName == "" {
+		errs.add("$.protocolName", "is required")
+	}
+	models := make(map[string]int)
+	devices := make(map[string]string)
+	for i, model := range c.DeviceModels {
+		path := fmt.Sprintf("$.deviceModels[%d]", i)
+		errs = append(errs, ValidateModel(model.Model, validatePoint).prefix(path)...)
+		if j, ok := models[model.Name]; ok && model.Name != "" {
+			errs.add(path+".name", "duplicate model name %q, first defined at $.deviceModels[%d]", model.Name, j)
+		} else {
+			models[model.Name] = i
+		}
+		for j, device := range model.Devices {
+			devicePath := fmt.Sprintf("%s.devices[%d]", path, j)
+			errs = append(errs, ValidateDevice(device, c.Connections).prefix(devicePath)...)
+			if first, ok := devices[device.ID]; ok && device.ID != "" {
+				errs.add(devicePath+".id", "duplicate device id %q, first defined at %s", device.ID, first)
+			} else {
+				devices[device.ID] = devicePath
+			}
+		}
+	}
+	return errs
+}
+
+// ValidateModel 校验模型及点位定义，错误路径相对于模型
+func ValidateModel(model Model, validatePoint PointValidator) ValidationErrors {
+	var errs ValidationErrors
+	if model.Name == "" {
+		errs.add("name", "is required")
+	}
+	points := make(map[string]int)
+	for i, point := range model.DevicePoints {
+		path := fmt.Sprintf("devicePoints[%d]", i)
+		errs = append(errs, ValidatePoint(point).prefix(path)...)
+		if validatePoint != nil {
+			errs = append(errs, validatePoint(point).prefix(path)...)
+		}
+		name, _ := point["name"].(string)
+		if name == "" {
+			continue
+		}
+		if j, ok := points[name]; ok {
+			errs.add(path+".name", "duplicate point name %q, first defined at devicePoints[%d]", name, j)
+		} else {
+			points[name] = i
+		}
+	}
+	return errs
+}
+
+// ValidateDevice 校验设备定义，connections 为空时不校验连接引用，错误路径相对于设备
+// 部分插件（如 mirror）的设备无需连接，connectionKey 为空时不校验
+func ValidateDevice(device Device, connections map[string]interface{}) ValidationErrors {
+	var errs ValidationErrors
+	if device.ID == "" {
+		errs.add("id", "is required")
+	}
+	if device.ConnectionKey != "" && connections != nil {
+		if _, ok := connections[device.ConnectionKey]; !ok {
+			errs.add("connectionKey", "unknown connection %q", device.ConnectionKey)
+		}
+	}
+	return errs
+}
+
+// ValidatePoint 校验点位的通用字段，错误路径相对于点位
+func ValidatePoint(p Point) ValidationErrors {
+	var errs ValidationErrors
+	requireString(&errs, p, "name", nil)
+	requireString(&errs, p, "valueType", []string{
+		string(ValueType_Int), string(ValueType_Float), string(ValueType_String), string(ValueType_Bool),
+		string(ValueType_Array), string(ValueType_Object), string(ValueType_Datetime),
+	})
+	requireString(&errs, p, "readWrite", []string{string(ReadWrite_R), string(ReadWrite_W), string(ReadWrite_RW)})
+	optionalString(&errs, p, "reportMode", []string{string(ReportMode_Real), string(ReportMode_Change)})
+	optionalString(&errs, p, "writePolicy", []string{string(WritePolicy_None), string(WritePolicy_Verify), string(WritePolicy_VerifyAndRetry)})
+	optionalString(&errs, p, "description", nil)
+	optionalString(&errs, p, "units", nil)
+	optionalString(&errs, p, "alarmSeverity", nil)
+	for _, key := range []string{"scale", "decimals", "min", "max", "deadband", "deadbandPercent",
+		"minReportInterval", "maxReportInterval", "alarmHigh", "alarmLow", "alarmDeadband", "alarmDelay"} {
+		if _, ok := p[key]; ok {
+			if _, ok = p.floatField(key); !ok {
+				errs.add(key, "must be a number")
+			}
+		}
+	}
+	if decimals, ok := p.floatField("decimals"); ok && (decimals < 0 || decimals != float64(int(decimals))) {
+		errs.add("decimals", "must be a non-negative integer")
+	}
+	if min, ok := p.floatField("min"); ok {
+		if max, ok := p.floatField("max"); ok && min > max {
+			errs.add("min", "must not be greater than max")
+		}
+	}
+	valueType, _ := p["valueType"].(string)
+	if scale, ok := p.floatField("scale"); ok && scale != 0 && scale != 1 && valueType != string(ValueType_Float) {
+		errs.add("scale", "requires valueType float")
+	}
+	validateEnums(&errs, p["enums"], ValueType(valueType))
+	validateTransforms(&errs, p["transforms"])
+	return errs
+}
+
+func requireString(errs *ValidationErrors, p Point, key string, values []string) {
+	if _, ok := p[key]; !ok {
+		errs.add(key, "is required")
+		return
+	}
+	optionalString(errs, p, key, values)
+}
+
+func optionalString(errs *ValidationErrors, p Point, key string, values []string) {
+	v, ok := p[key]
+	if !ok {
+		return
+	}
+	s, ok := v.(string)
+	if !ok {
+		errs.add(key, "must be a string, got %s", typeName(v))
+		return
+	}
+	if len(values) == 0 {
+		return
+	}
+	for _, value := range values {
+		if s == value {
+			return
+		}
+	}
+	errs.add(key, "invalid value %q, must be one of: %s", s, strings.Join(values, ", "))
+}
+
+// validateEnums 校验枚举定义，枚举值需与点位类型一致
+func validateEnums(errs *ValidationErrors, v interface{}, valueType ValueType) {
+	if v == nil {
+		return
+	}
+	enums, ok := normalize(v).([]interface{})
+	if !ok {
+		errs.add("enums", "must be an array")
+		return
+	}
+	values := make(map[string]int)
+	for i, item := range enums {
+		path := fmt.Sprintf("enums[%d]", i)
+		enum, ok := item.(map[string]interface{})
+		if !ok {
+			errs.add(path, "must be an object")
+			continue
+		}
+		if name, ok := enum["name"].(string); !ok || name == "" {
+			errs.add(path+".name", "is required")
+		}
+		value, ok := enum["value"]
+		if !ok {
+			errs.add(path+".value", "is required")
+			continue
+		}
+		if !enumValueMatch(value, valueType) {
+			errs.add(path+".value", "%v does not match valueType %s", value, valueType)
+		}
+		key := fmt.Sprint(value)
+		if j, ok := values[key]; ok {
+			errs.add(path+".value", "duplicate enum value %v, first defined at enums[%d]", value, j)
+		} else {
+			values[key] = i
+		}
+	}
+}
+
+func enumValueMatch(value interface{}, valueType ValueType) bool {
+	f, isNumber := Point{"value": value}.floatField("value")
+	switch valueType {
+	case ValueType_Int:
+		return isNumber && f == float64(int64(f))
+	case ValueType_Float:
+		return isNumber
+	case ValueType_Bool:
+		_, isBool := value.(bool)
+		return isBool || isNumber
+	default:
+		return true
+	}
+}
+
+// validateTransforms 校验转换链结构，各步骤的参数在执行时校验
+func validateTransforms(errs *ValidationErrors, v interface{}) {
+	if v == nil {
+		return
+	}
+	transforms, ok := normalize(v).([]interface{})
+	if !ok {
+		errs.add("transforms", "must be an array")
+		return
+	}
+	for i, item := range transforms {
+		path := fmt.Sprintf("transforms[%d]", i)
+		transform, ok := item.(map[string]interface{})
+		if !ok {
+			errs.add(path, "must be an object")
+			continue
+		}
+		switch transform["type"] {
+		case "linear", "clamp", "map", "bit", "unit", "invert", "piecewise":
+		default:
+			errs.add(path+".type", "invalid value %v, must be one of: linear, clamp, map, bit, unit, invert, piecewise", transform["type"])
+		}
+	}
+}
+
+// normalize 将代码中构造的结构体、切片等转换为 JSON 解析后的通用结构
+func normalize(v interface{}) interface{} {
+	switch v.(type) {
+	case []interface{}, map[string]interface{}:
+		return v
+	}
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var result interface{}
+	if json.Unmarshal(bs, &result) != nil {
+		return v
+	}
+	return result
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestValidateDeviceConfig(t *testing.T) {
+	c := DeviceConfig{
+		PluginName: "modbus",
+		DeviceModels: []DeviceModel{{
+			Model: Model{
+				Name: "m1",
+				DevicePoints: []Point{
+					{"name": "p1", "valueType": "int", "readWrite": "R", "enums": []interface{}{
+						map[string]interface{}{"name": "on", "value": 1.0},
+						map[string]interface{}{"name": "off", "value": 1.0},
+					}},
+					{"name": "p2", "valueType": "double", "readWrite": "R"},
+					{"name": "p1", "valueType": "float", "readWrite": "RW", "min": 10.0, "max": 1.0},
+				},
+			},
+			Devices: []Device{
+				{ID: "d1", ConnectionKey: "tcp"},
+				{ID: "d1", ConnectionKey: "unknown"},
+			},
+		}},
+		Connections: map[string]interface{}{"tcp": map[string]interface{}{}},
+	}
+	errs := ValidateDeviceConfig(c, nil)
+	want := []string{
+		"$.deviceModels[0].devicePoints[0].enums[1].value",
+		"$.deviceModels[0].devicePoints[1].valueType",
+		"$.deviceModels[0].devicePoints[2].min",
+		"$.deviceModels[0].devicePoints[2].name",
+		"$.deviceModels[0].devices[1].connectionKey",
+		"$.deviceModels[0].devices[1].id",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("errors = %v", errs)
+	}
+	for i, path := range want {
+		if errs[i].Path != path {
+			t.Errorf("errors[%d].Path = %s, want %s", i, errs[i].Path, path)
+		}
+	}
+	if err := ValidateModel(c.DeviceModels[0].Model, func(Point) ValidationErrors { return nil }).Err(); err == nil {
+		t.Error("invalid model should return error")
+	}
+}
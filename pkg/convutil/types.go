@@ -0,0 +1,152 @@
+package convutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayouts 日期时间字符串支持的格式，未包含时区的格式按本地时区解析
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.000",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// 转换为 bool 类型，数值类型非0为true
+func Bool(value interface{}) (bool, error) {
+	if value == nil {
+		return false, nil
+	}
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "1", "on":
+			return true, nil
+		case "false", "0", "off", "":
+			return false, nil
+		}
+		return false, fmt.Errorf("%q convert to bool error", v)
+	default:
+		f, err := Float64(value)
+		if err != nil {
+			return false, fmt.Errorf("%T convert to bool error", value)
+		}
+		return f != 0, nil
+	}
+}
+
+// 转换为数组类型，支持任意切片及 JSON 数组字符串
+func Array(value interface{}) ([]interface{}, error) {
+	if value == nil {
+		return []interface{}{}, nil
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		return v, nil
+	case string:
+		var arr []interface{}
+		if err := json.Unmarshal([]byte(v), &arr); err != nil {
+			return nil, fmt.Errorf("%q convert to array error", v)
+		}
+		return arr, nil
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("%T convert to array error", value)
+	}
+	arr := make([]interface{}, rv.Len())
+	for i := range arr {
+		arr[i] = rv.Index(i).Interface()
+	}
+	return arr, nil
+}
+
+// 转换为对象类型，支持 map、结构体及 JSON 对象字符串
+func Object(value interface{}) (map[string]interface{}, error) {
+	if value == nil {
+		return map[string]interface{}{}, nil
+	}
+	var bs []byte
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v, nil
+	case string:
+		bs = []byte(v)
+	case []byte:
+		bs = v
+	default:
+		kind := reflect.Indirect(reflect.ValueOf(value)).Kind()
+		if kind != reflect.Map && kind != reflect.Struct {
+			return nil, fmt.Errorf("%T convert to object error", value)
+		}
+		var err error
+		if bs, err = json.Marshal(value); err != nil {
+			return nil, err
+		}
+	}
+	obj := make(map[string]interface{})
+	if err := json.Unmarshal(bs, &obj); err != nil {
+		return nil, fmt.Errorf("%T convert to object error: %v", value, err)
+	}
+	return obj, nil
+}
+
+// 转换为日期时间类型
+// 数值视为 Unix 时间戳，小于 1e11 时单位为秒，否则为毫秒
+func Time(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case *time.Time:
+		if v == nil {
+			return time.Time{}, nil
+		}
+		return *v, nil
+	case nil:
+		return time.Time{}, nil
+	case string:
+		s := strings.TrimSpace(v)
+		for _, layout := range timeLayouts {
+			if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+				return t, nil
+			}
+		}
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			return unixTime(n), nil
+		}
+		return time.Time{}, fmt.Errorf("%q convert to datetime error", v)
+	default:
+		n, err := Float64(value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%T convert to datetime error", value)
+		}
+		return unixTime(n), nil
+	}
+}
+
+func unixTime(n float64) time.Time {
+	if n < 1e11 {
+		return time.UnixMilli(int64(n * 1000))
+	}
+	return time.UnixMilli(int64(n))
+}
+
+// Equal 比较两个点位值是否相等，支持数组、对象等不可直接比较的类型
+func Equal(a, b interface{}) bool {
+	if ta, ok := a.(time.Time); ok {
+		tb, ok := b.(time.Time)
+		return ok && ta.Equal(tb)
+	}
+	if a == nil || b == nil || (reflect.TypeOf(a).Comparable() && reflect.TypeOf(b).Comparable()) {
+		return a == b
+	}
+	return reflect.DeepEqual(a, b)
+}
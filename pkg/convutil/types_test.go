@@ -0,0 +1,45 @@
+package convutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+)
+
+func TestPointValue_RichTypes(t *testing.T) {
+	if v, err := PointValue("on", config.ValueType_Bool); err != nil || v != true {
+		t.Errorf("bool = %v, %v", v, err)
+	}
+	if v, err := PointValue(uint16(0), config.ValueType_Bool); err != nil || v != false {
+		t.Errorf("bool = %v, %v", v, err)
+	}
+	if v, err := PointValue([]float32{1, 2}, config.ValueType_Array); err != nil || !Equal(v, []interface{}{float32(1), float32(2)}) {
+		t.Errorf("array = %v, %v", v, err)
+	}
+	if v, err := PointValue(`[1,"a"]`, config.ValueType_Array); err != nil || !Equal(v, []interface{}{1.0, "a"}) {
+		t.Errorf("array = %v, %v", v, err)
+	}
+	if v, err := PointValue(`{"a":1}`, config.ValueType_Object); err != nil || !Equal(v, map[string]interface{}{"a": 1.0}) {
+		t.Errorf("object = %v, %v", v, err)
+	}
+	if _, err := PointValue(1.0, config.ValueType_Object); err == nil {
+		t.Error("number should not convert to object")
+	}
+
+	want := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	for _, raw := range []interface{}{"2024-05-01T08:00:00Z", want.Unix(), want.UnixMilli(), want} {
+		if v, err := PointValue(raw, config.ValueType_Datetime); err != nil || !Equal(v, want) {
+			t.Errorf("datetime(%v) = %v, %v", raw, v, err)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	if !Equal([]interface{}{1.0}, []interface{}{1.0}) || Equal([]interface{}{1.0}, []interface{}{2.0}) {
+		t.Error("array compare error")
+	}
+	if Equal([]interface{}{1.0}, 1.0) || !Equal(nil, nil) || Equal(nil, 1.0) {
+		t.Error("mixed compare error")
+	}
+}
@@ -11,7 +11,7 @@ import (
 
 //	点位类型转换
 //
-// 仅支持三种数据类型：int、float、string
+// 支持的数据类型：int、float、string、bool、array、object、datetime
 func PointValue(value interface{}, valueType config.ValueType) (interface{}, error) {
 	switch valueType {
 	case config.ValueType_Int:
@@ -28,8 +28,16 @@ func PointValue(value interface{}, valueType config.ValueType) (interface{}, err
 		return v, nil
 	case config.ValueType_String:
 		return String(value)
+	case config.ValueType_Bool:
+		return Bool(value)
+	case config.ValueType_Array:
+		return Array(value)
+	case config.ValueType_Object:
+		return Object(value)
+	case config.ValueType_Datetime:
+		return Time(value)
 	default:
-		return nil, fmt.Errorf("point value type must one of (int、float、string) ,unSupport:%v", valueType)
+		return nil, fmt.Errorf("point value type must one of (int、float、string、bool、array、object、datetime) ,unSupport:%v", valueType)
 	}
 }
 
@@ -0,0 +1,511 @@
+// Package diskqueue 基于磁盘的有界 FIFO 队列
+//
+// 数据按顺序追加写入分段文件，每条记录格式为：4 字节长度 + 4 字节 CRC32 + 数据。
+// 读取位置及丢弃计数保存在 meta.json 中，已消费完的分段文件会被删除。
+// 队列达到容量上限时丢弃最早的数据；队首记录连续读取失败时跳过该记录。
+// 打开队列时仅截断分段尾部不完整的记录，校验失败的记录按长度跳过并计入损坏数。
+package diskqueue
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	segmentSuffix = ".seg"
+	metaFile      = "meta.json"
+	headerSize    = 8
+	// 单个分段文件最大字节数，超过后写入新分段
+	defaultSegmentSize = 4 << 20
+	// 单条记录最大字节数，用于识别损坏的记录头
+	maxRecordSize = 64 << 20
+	// 队首记录连续读取失败次数上限，超过后跳过该记录
+	maxReadFailures = 3
+)
+
+var (
+	// ErrEmpty 队列为空
+	ErrEmpty = errors.New("queue is empty")
+	// ErrClosed 队列已关闭
+	ErrClosed = errors.New("queue is closed")
+
+	// errChecksum 记录完整但数据校验失败
+	errChecksum = errors.New("record checksum mismatch")
+)
+
+// meta 队列元数据
+type meta struct {
+	// ReadSegment 当前读取的分段序号
+	ReadSegment int64 `json:"readSegment"`
+	// ReadOffset 当前分段的读取偏移
+	ReadOffset int64 `json:"readOffset"`
+	// Dropped 累计丢弃的数据条数
+	Dropped int64 `json:"dropped"`
+	// Corrupted 累计跳过的损坏数据条数
+	Corrupted int64 `json:"corrupted"`
+}
+
+// Queue 磁盘队列，并发安全
+type Queue struct {
+	dir      string
+	maxCount int
+	// 分段文件大小上限
+	segmentSize int64
+
+	mutex    sync.Mutex
+	meta     meta
+	segments []int64
+	// 每个分段中未读取的记录数
+	counts map[int64]int
+	depth  int
+	writer *os.File
+	// 写入分段当前大小
+	writeSize int64
+	reader    *os.File
+	// 队首记录连续读取失败次数
+	failures int
+	closed   bool
+	// 加载时发现的未读损坏记录，key 为分段序号，value 为记录偏移 -> 记录长度
+	corrupt map[int64]map[int64]int64
+}
+
+// Open 打开或创建队列，maxCount 为队列最大数据条数，<=0 表示不限制
+func Open(dir string, maxCount int) (*Queue, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	q := &Queue{
+		dir:         dir,
+		maxCount:    maxCount,
+		segmentSize: defaultSegmentSize,
+		counts:      make(map[int64]int),
+		corrupt:     make(map[int64]map[int64]int64),
+	}
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// load 加载元数据并扫描分段文件
+func (q *Queue) load() error {
+	bs, err := os.ReadFile(filepath.Join(q.dir, metaFile))
+	if err == nil {
+		if err = json.Unmarshal(bs, &q.meta); err != nil {
+			return fmt.Errorf("invalid queue meta: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseInt(strings.TrimSuffix(name, segmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		// 清理已消费的分段
+		if seq < q.meta.ReadSegment {
+			_ = os.Remove(filepath.Join(q.dir, name))
+			continue
+		}
+		q.segments = append(q.segments, seq)
+	}
+	sort.Slice(q.segments, func(i, j int) bool { return q.segments[i] < q.segments[j] })
+	if len(q.segments) == 0 || q.segments[0] != q.meta.ReadSegment {
+		// 读取位置所在分段不存在，从最早的分段开始读取
+		q.meta.ReadOffset = 0
+		if len(q.segments) > 0 {
+			q.meta.ReadSegment = q.segments[0]
+		}
+	}
+	for _, seq := range q.segments {
+		offset := int64(0)
+		if seq == q.meta.ReadSegment {
+			offset = q.meta.ReadOffset
+		}
+		count, size, err := q.scan(seq, offset)
+		if err != nil {
+			return err
+		}
+		q.counts[seq] = count
+		q.depth += count
+		q.writeSize = size
+	}
+	if len(q.segments) == 0 {
+		q.segments = append(q.segments, q.meta.ReadSegment)
+		q.writeSize = 0
+	}
+	return q.openWriter()
+}
+
+// scan 统计分段中 offset 之后的完整记录数，并截断尾部不完整的记录
+// 校验失败的未读记录不计入记录数，读取至该位置时跳过
+func (q *Queue) scan(seq int64, offset int64) (int, int64, error) {
+	f, err := os.OpenFile(q.segmentPath(seq), os.O_RDWR, 0644)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	count := 0
+	pos := int64(0)
+	for {
+		data, err := readRecord(f)
+		if err == errChecksum {
+			size := int64(headerSize + len(data))
+			if pos >= offset {
+				if q.corrupt[seq] == nil {
+					q.corrupt[seq] = make(map[int64]int64)
+				}
+				q.corrupt[seq][pos] = size
+			}
+			pos += size
+			continue
+		}
+		if err != nil {
+			if err != io.EOF {
+				// 进程异常退出导致尾部记录不完整，截断至最后一条完整记录
+				if err = f.Truncate(pos); err != nil {
+					return 0, 0, err
+				}
+			}
+			break
+		}
+		if pos >= offset {
+			count++
+		}
+		pos += int64(headerSize + len(data))
+	}
+	return count, pos, nil
+}
+
+func (q *Queue) segmentPath(seq int64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", seq, segmentSuffix))
+}
+
+func (q *Queue) openWriter() error {
+	seq := q.segments[len(q.segments)-1]
+	f, err := os.OpenFile(q.segmentPath(seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	q.writer = f
+	return nil
+}
+
+// Push 写入数据至队尾，队列已满时丢弃最早的数据
+func (q *Queue) Push(data []byte) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	if q.maxCount > 0 && q.depth >= q.maxCount {
+		n, err := 1, q.pop()
+		if err != nil {
+			// 队首数据本就需要丢弃，读取失败时直接跳过
+			if n, err = q.skipHead(); err != nil {
+				return err
+			}
+		}
+		q.meta.Dropped += int64(n)
+		if err := q.saveMeta(); err != nil {
+			return err
+		}
+	}
+	if q.writeSize >= q.segmentSize {
+		if err := q.rotate(); err != nil {
+			return err
+		}
+	}
+	buf := make([]byte, headerSize+len(data))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(data)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(data))
+	copy(buf[headerSize:], data)
+	if _, err := q.writer.Write(buf); err != nil {
+		return err
+	}
+	q.writeSize += int64(len(buf))
+	q.counts[q.segments[len(q.segments)-1]]++
+	q.depth++
+	return nil
+}
+
+// rotate 切换至新的写入分段
+func (q *Queue) rotate() error {
+	if err := q.writer.Close(); err != nil {
+		return err
+	}
+	q.segments = append(q.segments, q.segments[len(q.segments)-1]+1)
+	q.writeSize = 0
+	return q.openWriter()
+}
+
+// Peek 读取队首数据，不移除
+func (q *Queue) Peek() ([]byte, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed {
+		return nil, ErrClosed
+	}
+	if q.depth == 0 {
+		return nil, ErrEmpty
+	}
+	return q.peek()
+}
+
+// peek 读取队首数据，连续失败 maxReadFailures 次后跳过该记录
+func (q *Queue) peek() ([]byte, error) {
+	for {
+		for q.counts[q.meta.ReadSegment] == 0 {
+			// 当前分段已读完，切换至下一个分段
+			if err := q.nextSegment(); err != nil {
+				return nil, err
+			}
+		}
+		if err := q.skipCorrupt(); err != nil {
+			return nil, err
+		}
+		data, err := q.readHead()
+		if err == nil {
+			q.failures = 0
+			return data, nil
+		}
+		q.failures++
+		if q.failures < maxReadFailures {
+			return nil, err
+		}
+		n, e := q.skipHead()
+		if e != nil {
+			return nil, e
+		}
+		q.meta.Corrupted += int64(n)
+		if e = q.saveMeta(); e != nil {
+			return nil, e
+		}
+		if q.depth == 0 {
+			return nil, ErrEmpty
+		}
+	}
+}
+
+// skipCorrupt 跳过队首加载时发现的损坏记录，并计入损坏数
+func (q *Queue) skipCorrupt() error {
+	records := q.corrupt[q.meta.ReadSegment]
+	if len(records) == 0 {
+		return nil
+	}
+	skipped := false
+	for size, ok := records[q.meta.ReadOffset]; ok; size, ok = records[q.meta.ReadOffset] {
+		delete(records, q.meta.ReadOffset)
+		q.meta.ReadOffset += size
+		q.meta.Corrupted++
+		skipped = true
+	}
+	if !skipped {
+		return nil
+	}
+	return q.saveMeta()
+}
+
+func (q *Queue) readHead() ([]byte, error) {
+	if q.reader == nil {
+		f, err := os.Open(q.segmentPath(q.meta.ReadSegment))
+		if err != nil {
+			return nil, err
+		}
+		q.reader = f
+	}
+	if _, err := q.reader.Seek(q.meta.ReadOffset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := readRecord(q.reader)
+	if err == io.EOF {
+		// 记录数与文件内容不一致
+		err = errors.New("unexpected end of segment")
+	}
+	return data, err
+}
+
+// skipHead 跳过无法读取的队首记录，返回跳过的条数
+// 记录头可用时按记录长度跳过，否则丢弃当前分段的剩余数据
+func (q *Queue) skipHead() (int, error) {
+	q.failures = 0
+	seq := q.meta.ReadSegment
+	if q.counts[seq] == 0 {
+		return 0, nil
+	}
+	if size, ok := q.headSize(); ok {
+		q.meta.ReadOffset += size
+		q.counts[seq]--
+		q.depth--
+		return 1, nil
+	}
+	n := q.counts[seq]
+	q.counts[seq] = 0
+	q.depth -= n
+	if seq == q.segments[len(q.segments)-1] {
+		// 写入分段后续仍会追加数据，读取位置移至当前末尾
+		q.meta.ReadOffset = q.writeSize
+	}
+	return n, nil
+}
+
+// headSize 根据记录头计算队首记录的长度，记录头损坏或超出分段范围时返回 false
+func (q *Queue) headSize() (int64, bool) {
+	f, err := os.Open(q.segmentPath(q.meta.ReadSegment))
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, false
+	}
+	header := make([]byte, headerSize)
+	if _, err = f.ReadAt(header, q.meta.ReadOffset); err != nil {
+		return 0, false
+	}
+	size := int64(headerSize) + int64(binary.BigEndian.Uint32(header[0:4]))
+	if size-headerSize > maxRecordSize || q.meta.ReadOffset+size > info.Size() {
+		return 0, false
+	}
+	return size, true
+}
+
+// Pop 移除队首数据
+func (q *Queue) Pop() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	if q.depth == 0 {
+		return ErrEmpty
+	}
+	if err := q.pop(); err != nil {
+		return err
+	}
+	return q.saveMeta()
+}
+
+func (q *Queue) pop() error {
+	data, err := q.peek()
+	if err != nil {
+		return err
+	}
+	q.meta.ReadOffset += int64(headerSize + len(data))
+	q.counts[q.meta.ReadSegment]--
+	q.depth--
+	return nil
+}
+
+// nextSegment 删除已读完的分段，读取位置移至下一个分段
+func (q *Queue) nextSegment() error {
+	if len(q.segments) <= 1 {
+		return ErrEmpty
+	}
+	if q.reader != nil {
+		_ = q.reader.Close()
+		q.reader = nil
+	}
+	delete(q.counts, q.meta.ReadSegment)
+	// 分段末尾未读取的损坏记录
+	q.meta.Corrupted += int64(len(q.corrupt[q.meta.ReadSegment]))
+	delete(q.corrupt, q.meta.ReadSegment)
+	_ = os.Remove(q.segmentPath(q.meta.ReadSegment))
+	q.segments = q.segments[1:]
+	q.meta.ReadSegment = q.segments[0]
+	q.meta.ReadOffset = 0
+	return nil
+}
+
+func (q *Queue) saveMeta() error {
+	bs, err := json.Marshal(q.meta)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(q.dir, metaFile+".tmp")
+	if err = os.WriteFile(tmp, bs, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(q.dir, metaFile))
+}
+
+// Len 队列中的数据条数
+func (q *Queue) Len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.depth
+}
+
+// Dropped 因队列已满累计丢弃的数据条数
+func (q *Queue) Dropped() int64 {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.meta.Dropped
+}
+
+// Corrupted 因记录损坏累计跳过的数据条数
+func (q *Queue) Corrupted() int64 {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.meta.Corrupted
+}
+
+// Close 关闭队列
+func (q *Queue) Close() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	if q.reader != nil {
+		_ = q.reader.Close()
+	}
+	err := q.saveMeta()
+	if e := q.writer.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// readRecord 读取一条记录并校验
+func readRecord(r io.Reader) ([]byte, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, errors.New("incomplete record header")
+		}
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header[0:4])
+	if size > maxRecordSize {
+		return nil, errors.New("invalid record size")
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, errors.New("incomplete record data")
+	}
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(header[4:8]) {
+		return data, errChecksum
+	}
+	return data, nil
+}
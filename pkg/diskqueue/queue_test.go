@@ -0,0 +1,202 @@
+package diskqueue
+
+import (
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestQueue_FIFO(t *testing.T) {
+	q, err := Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	// 缩小分段大小以覆盖分段切换
+	q.segmentSize = 32
+	for i := 0; i < 10; i++ {
+		if err = q.Push([]byte(strconv.Itoa(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		data, err := q.Peek()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != strconv.Itoa(i) {
+			t.Fatalf("got %s, want %d", data, i)
+		}
+		if err = q.Pop(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = q.Peek(); err != ErrEmpty {
+		t.Fatalf("expected ErrEmpty, got %v", err)
+	}
+}
+
+func TestQueue_Bounded(t *testing.T) {
+	q, err := Open(t.TempDir(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	for i := 0; i < 5; i++ {
+		if err = q.Push([]byte(strconv.Itoa(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if q.Len() != 3 || q.Dropped() != 2 {
+		t.Fatalf("len=%d dropped=%d", q.Len(), q.Dropped())
+	}
+	data, _ := q.Peek()
+	if string(data) != "2" {
+		t.Fatalf("got %s, want 2", data)
+	}
+}
+
+func TestQueue_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	q, err := Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.segmentSize = 32
+	for i := 0; i < 6; i++ {
+		_ = q.Push([]byte(strconv.Itoa(i)))
+	}
+	_ = q.Pop()
+	_ = q.Pop()
+	if err = q.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// 模拟写入中断产生的不完整记录
+	entries, _ := os.ReadDir(dir)
+	last := entries[len(entries)-1].Name()
+	if last == metaFile {
+		last = entries[len(entries)-2].Name()
+	}
+	f, _ := os.OpenFile(dir+"/"+last, os.O_WRONLY|os.O_APPEND, 0644)
+	_, _ = f.Write([]byte{0, 0, 0, 9, 1})
+	_ = f.Close()
+
+	q, err = Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	if q.Len() != 4 {
+		t.Fatalf("len=%d, want 4", q.Len())
+	}
+	_ = q.Push([]byte("6"))
+	for i := 2; i <= 6; i++ {
+		data, err := q.Peek()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != strconv.Itoa(i) {
+			t.Fatalf("got %s, want %d", data, i)
+		}
+		_ = q.Pop()
+	}
+}
+
+func TestQueue_Corrupted(t *testing.T) {
+	dir := t.TempDir()
+	q, err := Open(dir, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	for i := 0; i < 3; i++ {
+		_ = q.Push([]byte(strconv.Itoa(i)))
+	}
+	// 破坏第一条记录的数据，记录头完好
+	f, _ := os.OpenFile(q.segmentPath(q.meta.ReadSegment), os.O_WRONLY, 0644)
+	_, _ = f.WriteAt([]byte("x"), headerSize)
+	_ = f.Close()
+
+	// 连续失败达到上限后跳过损坏的记录
+	for i := 1; i < maxReadFailures; i++ {
+		if _, err = q.Peek(); err == nil {
+			t.Fatal("expected checksum error")
+		}
+	}
+	data, err := q.Peek()
+	if err != nil || string(data) != "1" {
+		t.Fatalf("got %s, err %v, want 1", data, err)
+	}
+	if q.Len() != 2 || q.Corrupted() != 1 {
+		t.Fatalf("len=%d corrupted=%d", q.Len(), q.Corrupted())
+	}
+
+	// 破坏记录头，队列已满时写入不受影响，当前分段剩余数据被丢弃
+	_ = q.Push([]byte("3"))
+	f, _ = os.OpenFile(q.segmentPath(q.meta.ReadSegment), os.O_WRONLY, 0644)
+	_, _ = f.WriteAt([]byte{0xFF, 0xFF, 0xFF, 0xFF}, q.meta.ReadOffset)
+	_ = f.Close()
+	if err = q.Push([]byte("4")); err != nil {
+		t.Fatal(err)
+	}
+	if q.Len() != 1 || q.Dropped() != 3 {
+		t.Fatalf("len=%d dropped=%d", q.Len(), q.Dropped())
+	}
+	if data, err = q.Peek(); err != nil || string(data) != "4" {
+		t.Fatalf("got %s, err %v, want 4", data, err)
+	}
+}
+
+func TestQueue_CorruptedOnOpen(t *testing.T) {
+	dir := t.TempDir()
+	q, err := Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		_ = q.Push([]byte(strconv.Itoa(i)))
+	}
+	seq := q.meta.ReadSegment
+	if err = q.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// 破坏分段中间一条记录的数据，记录头完好
+	path := q.segmentPath(seq)
+	f, _ := os.OpenFile(path, os.O_WRONLY, 0644)
+	_, _ = f.WriteAt([]byte("x"), 2*headerSize+1)
+	_ = f.Close()
+	before, _ := os.Stat(path)
+
+	q, err = Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 损坏记录之后的数据不被截断
+	after, _ := os.Stat(path)
+	if after.Size() != before.Size() || q.Len() != 2 {
+		t.Fatalf("size=%d want %d, len=%d", after.Size(), before.Size(), q.Len())
+	}
+	for _, want := range []string{"0", "2"} {
+		data, err := q.Peek()
+		if err != nil || string(data) != want {
+			t.Fatalf("got %s, err %v, want %s", data, err, want)
+		}
+		_ = q.Pop()
+	}
+	if q.Corrupted() != 1 {
+		t.Fatalf("corrupted=%d, want 1", q.Corrupted())
+	}
+	if err = q.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新打开后不重复计数
+	q, err = Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	if q.Len() != 0 || q.Corrupted() != 1 {
+		t.Fatalf("len=%d corrupted=%d", q.Len(), q.Corrupted())
+	}
+}
@@ -0,0 +1,403 @@
+// Package expression 布尔/算术表达式解析与求值
+//
+// 支持的语法：
+//   - 字面量：数字(3、1.5)、字符串("cool"、'cool')、true、false、nil
+//   - 变量：以 . 分隔的路径，例如 dev1.temp、dev1.temp.updatedAt、1F_meter.power；包含特殊字符时使用反引号，例如 `vrf/1`.temp
+//   - 算术运算：+ - * / %，其中 + 支持字符串拼接
+//   - 比较运算：== != > >= < <=
+//   - 逻辑运算：&& || !
+//   - 函数：abs(x)、min(a, b, ...)、max(a, b, ...)、avg(a, b, ...)、now()
+package expression
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Resolver 变量解析器，根据变量路径返回变量值
+type Resolver func(path []string) (interface{}, error)
+
+// Expression 编译后的表达式
+type Expression struct {
+	src  string
+	root node
+	vars [][]string
+}
+
+// Compile 编译表达式
+func Compile(src string) (*Expression, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+	}
+	return &Expression{src: src, root: root, vars: p.vars}, nil
+}
+
+// String 返回表达式原文
+func (e *Expression) String() string {
+	return e.src
+}
+
+// Variables 返回表达式引用的所有变量路径
+func (e *Expression) Variables() [][]string {
+	return e.vars
+}
+
+// Eval 表达式求值，结果类型为 float64、string、bool 或 nil
+func (e *Expression) Eval(resolver Resolver) (interface{}, error) {
+	return e.root.eval(resolver)
+}
+
+// Bool 表达式求值，要求结果为布尔类型
+func (e *Expression) Bool(resolver Resolver) (bool, error) {
+	v, err := e.Eval(resolver)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression result is not boolean: %v", v)
+	}
+	return b, nil
+}
+
+//------------------------------ 语法树 ------------------------------
+
+type node interface {
+	eval(resolver Resolver) (interface{}, error)
+}
+
+type literal struct {
+	value interface{}
+}
+
+func (n literal) eval(Resolver) (interface{}, error) {
+	return n.value, nil
+}
+
+type variable struct {
+	path []string
+}
+
+func (n variable) eval(resolver Resolver) (interface{}, error) {
+	if resolver == nil {
+		return nil, fmt.Errorf("unresolved variable: %s", joinPath(n.path))
+	}
+	v, err := resolver(n.path)
+	if err != nil {
+		return nil, err
+	}
+	return normalize(v), nil
+}
+
+type unary struct {
+	op string
+	x  node
+}
+
+func (n unary) eval(resolver Resolver) (interface{}, error) {
+	v, err := n.x.eval(resolver)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "!":
+		b, err := toBool(v)
+		if err != nil {
+			return nil, err
+		}
+		return !b, nil
+	default:
+		f, err := toNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		return -f, nil
+	}
+}
+
+type binary struct {
+	op   string
+	l, r node
+}
+
+func (n binary) eval(resolver Resolver) (interface{}, error) {
+	l, err := n.l.eval(resolver)
+	if err != nil {
+		return nil, err
+	}
+	//逻辑运算短路求值
+	if n.op == "&&" || n.op == "||" {
+		lb, err := toBool(l)
+		if err != nil {
+			return nil, err
+		}
+		if (n.op == "&&" && !lb) || (n.op == "||" && lb) {
+			return lb, nil
+		}
+		r, err := n.r.eval(resolver)
+		if err != nil {
+			return nil, err
+		}
+		return toBool(r)
+	}
+	r, err := n.r.eval(resolver)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return equal(l, r), nil
+	case "!=":
+		return !equal(l, r), nil
+	case ">", ">=", "<", "<=":
+		return compare(n.op, l, r)
+	case "+":
+		ls, lok := l.(string)
+		rs, rok := r.(string)
+		if lok && rok {
+			return ls + rs, nil
+		}
+	}
+	lf, err := toNumber(l)
+	if err != nil {
+		return nil, err
+	}
+	rf, err := toNumber(r)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		if rf == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return lf / rf, nil
+	case "%":
+		if rf == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return math.Mod(lf, rf), nil
+	}
+	return nil, fmt.Errorf("unsupported operator: %s", n.op)
+}
+
+type call struct {
+	name string
+	args []node
+}
+
+func (n call) eval(resolver Resolver) (interface{}, error) {
+	args := make([]float64, 0, len(n.args))
+	for _, arg := range n.args {
+		v, err := arg.eval(resolver)
+		if err != nil {
+			return nil, err
+		}
+		f, err := toNumber(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s(): %w", n.name, err)
+		}
+		args = append(args, f)
+	}
+	switch n.name {
+	case "now":
+		return float64(time.Now().UnixMilli()), nil
+	case "abs":
+		if len(args) != 1 {
+			return nil, errors.New("abs() requires exactly 1 argument")
+		}
+		return math.Abs(args[0]), nil
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("%s() requires at least 1 argument", n.name)
+	}
+	result := args[0]
+	for _, f := range args[1:] {
+		switch n.name {
+		case "min":
+			result = math.Min(result, f)
+		case "max":
+			result = math.Max(result, f)
+		case "avg":
+			result += f
+		}
+	}
+	if n.name == "avg" {
+		result = result / float64(len(args))
+	}
+	return result, nil
+}
+
+// 内置函数及其参数个数，-1 表示不定长
+var functions = map[string]int{
+	"abs": 1,
+	"min": -1,
+	"max": -1,
+	"avg": -1,
+	"now": 0,
+}
+
+//------------------------------ 类型转换 ------------------------------
+
+// normalize 将外部变量值统一为 float64、string、bool、nil
+func normalize(v interface{}) interface{} {
+	switch v.(type) {
+	case nil, string, bool, float64:
+		return v
+	}
+	if f, err := toNumber(v); err == nil {
+		return f
+	}
+	return fmt.Sprint(v)
+}
+
+func toNumber(v interface{}) (float64, error) {
+	switch x := v.(type) {
+	case float64:
+		return x, nil
+	case float32:
+		return float64(x), nil
+	case int:
+		return float64(x), nil
+	case int8:
+		return float64(x), nil
+	case int16:
+		return float64(x), nil
+	case int32:
+		return float64(x), nil
+	case int64:
+		return float64(x), nil
+	case uint:
+		return float64(x), nil
+	case uint8:
+		return float64(x), nil
+	case uint16:
+		return float64(x), nil
+	case uint32:
+		return float64(x), nil
+	case uint64:
+		return float64(x), nil
+	case bool:
+		if x {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		f, err := strconv.ParseFloat(x, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", x)
+		}
+		return f, nil
+	case nil:
+		return 0, errors.New("nil is not a number")
+	default:
+		return 0, fmt.Errorf("%T is not a number", v)
+	}
+}
+
+func toBool(v interface{}) (bool, error) {
+	switch x := v.(type) {
+	case bool:
+		return x, nil
+	case string:
+		b, err := strconv.ParseBool(x)
+		if err != nil {
+			return false, fmt.Errorf("%q is not a boolean", x)
+		}
+		return b, nil
+	case nil:
+		return false, errors.New("nil is not a boolean")
+	default:
+		f, err := toNumber(v)
+		if err != nil {
+			return false, err
+		}
+		return f != 0, nil
+	}
+}
+
+// equal 相等比较，数值与数值字符串视为同一类型
+func equal(l, r interface{}) bool {
+	if l == nil || r == nil {
+		return l == nil && r == nil
+	}
+	if lb, ok := l.(bool); ok {
+		rb, err := toBool(r)
+		return err == nil && lb == rb
+	}
+	if rb, ok := r.(bool); ok {
+		lb, err := toBool(l)
+		return err == nil && lb == rb
+	}
+	lf, lerr := toNumber(l)
+	rf, rerr := toNumber(r)
+	if lerr == nil && rerr == nil {
+		return lf == rf
+	}
+	return fmt.Sprint(l) == fmt.Sprint(r)
+}
+
+func compare(op string, l, r interface{}) (bool, error) {
+	var c int
+	lf, lerr := toNumber(l)
+	rf, rerr := toNumber(r)
+	if lerr == nil && rerr == nil {
+		switch {
+		case lf < rf:
+			c = -1
+		case lf > rf:
+			c = 1
+		}
+	} else {
+		ls, lok := l.(string)
+		rs, rok := r.(string)
+		if !lok || !rok {
+			return false, fmt.Errorf("cannot compare %v %s %v", l, op, r)
+		}
+		switch {
+		case ls < rs:
+			c = -1
+		case ls > rs:
+			c = 1
+		}
+	}
+	switch op {
+	case ">":
+		return c > 0, nil
+	case ">=":
+		return c >= 0, nil
+	case "<":
+		return c < 0, nil
+	default:
+		return c <= 0, nil
+	}
+}
+
+func joinPath(path []string) string {
+	s := ""
+	for i, p := range path {
+		if i > 0 {
+			s += "."
+		}
+		s += p
+	}
+	return s
+}
@@ -0,0 +1,85 @@
+package expression
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExpression_Bool(t *testing.T) {
+	values := map[string]interface{}{
+		"dev1.temp":           int64(28),
+		"dev2.temp":           "24.5",
+		"dev3.mode":           "cool",
+		"dev3.mode.updatedAt": int64(1000),
+		"vrf/1.onOff":         true,
+		"1F_meter.power":      2.5,
+		"dev1.2":              int64(2),
+		"123.temp":            int64(1),
+	}
+	resolver := func(path []string) (interface{}, error) {
+		v, ok := values[joinPath(path)]
+		if !ok {
+			return nil, errors.New("unknown variable " + joinPath(path))
+		}
+		return v, nil
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`dev1.temp - dev2.temp > 3 && dev3.mode == "cool"`, true},
+		{`dev1.temp - dev2.temp > 4 || dev3.mode != 'cool'`, false},
+		{`abs(dev2.temp - dev1.temp) >= 3.5`, true},
+		{`max(dev1.temp, dev2.temp, 30) == 30`, true},
+		{`min(dev1.temp, dev2.temp) < 25`, true},
+		{`avg(dev1.temp, dev2.temp) == 26.25`, true},
+		{`dev3.mode.updatedAt < now()`, true},
+		{`!(dev1.temp % 2 == 0)`, false},
+		{"`vrf/1`.onOff == true", true},
+		{`1F_meter.power * 2 == 5`, true},
+		{`dev1.2 + 1.5 == 3.5`, true},
+		{"`123`.temp == 1", true},
+		{`-dev1.temp + 30 * 2 / 4 == -13`, true},
+		{`false && unknown.point > 1`, false},
+	}
+	for _, tt := range tests {
+		e, err := Compile(tt.expr)
+		if err != nil {
+			t.Fatalf("compile %s: %v", tt.expr, err)
+		}
+		got, err := e.Bool(resolver)
+		if err != nil {
+			t.Fatalf("eval %s: %v", tt.expr, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCompile_Error(t *testing.T) {
+	for _, src := range []string{
+		`dev1.temp >`,
+		`(dev1.temp > 1`,
+		`foo(1)`,
+		`abs(1, 2)`,
+		`dev1.temp > "1`,
+		`dev1.temp # 1`,
+		`123.temp > 1`,
+	} {
+		if _, err := Compile(src); err == nil {
+			t.Errorf("compile %s: expect error", src)
+		}
+	}
+}
+
+func TestExpression_Variables(t *testing.T) {
+	e, err := Compile(`dev1.temp - dev2.temp.updatedAt > 3`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vars := e.Variables()
+	if len(vars) != 2 || joinPath(vars[0]) != "dev1.temp" || joinPath(vars[1]) != "dev2.temp.updatedAt" {
+		t.Errorf("unexpected variables: %v", vars)
+	}
+}
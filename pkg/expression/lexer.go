@@ -0,0 +1,130 @@
+package expression
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOperator
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+	pos  int
+}
+
+// 按最长匹配优先排列
+var operators = []string{"&&", "||", "==", "!=", ">=", "<=", ">", "<", "+", "-", "*", "/", "%", "!", "(", ")", ",", "."}
+
+// tokenize 词法分析
+func tokenize(src string) ([]token, error) {
+	tokens := make([]token, 0)
+	runes := []rune(src)
+	i := 0
+	for i < len(runes) {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c):
+			start := i
+			//数字开头且包含字母或下划线的视为标识符，例如 1F_meter；路径中 . 之后的纯数字同样视为标识符
+			end := scanIdent(runes, i)
+			if afterDot(tokens) || strings.IndexFunc(string(runes[start:end]), isIdentLetter) >= 0 {
+				i = end
+				tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+				continue
+			}
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			text := string(runes[start:i])
+			//纯数字的设备ID无法与数字区分，需使用反引号
+			if i < len(runes) && isIdentLetter(runes[i]) {
+				id := strings.TrimSuffix(text, ".")
+				return nil, fmt.Errorf("invalid number %q at %d, quote numeric identifier with backticks, e.g. `%s`", text, start, id)
+			}
+			num, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at %d", text, start)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: text, num: num, pos: start})
+		case c == '"' || c == '\'':
+			start := i
+			i++
+			var sb strings.Builder
+			for i < len(runes) && runes[i] != c {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at %d", start)
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenString, text: sb.String(), pos: start})
+		case c == '`':
+			//反引号包裹的标识符，用于包含特殊字符的设备ID或点位名称
+			start := i
+			i++
+			for i < len(runes) && runes[i] != '`' {
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated identifier at %d", start)
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start+1 : i]), pos: start})
+			i++
+		case isIdentLetter(c):
+			start := i
+			i = scanIdent(runes, i)
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					tokens = append(tokens, token{kind: tokenOperator, text: op, pos: i})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at %d", c, i)
+			}
+		}
+	}
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(runes)})
+	return tokens, nil
+}
+
+func isIdentLetter(c rune) bool {
+	return unicode.IsLetter(c) || c == '_'
+}
+
+// scanIdent 返回自 i 开始的标识符结束位置
+func scanIdent(runes []rune, i int) int {
+	for i < len(runes) && (isIdentLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+		i++
+	}
+	return i
+}
+
+// afterDot 上一个 token 是否为路径分隔符 .
+func afterDot(tokens []token) bool {
+	n := len(tokens)
+	return n > 0 && tokens[n-1].kind == tokenOperator && tokens[n-1].text == "."
+}
@@ -0,0 +1,163 @@
+package expression
+
+import (
+	"fmt"
+)
+
+// 二元运算符优先级，数值越大优先级越高
+var precedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	">": 4, ">=": 4, "<": 4, "<=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	vars   [][]string
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(op string) error {
+	t := p.next()
+	if t.kind != tokenOperator || t.text != op {
+		if t.kind == tokenEOF {
+			return fmt.Errorf("expected %q at end of expression", op)
+		}
+		return fmt.Errorf("expected %q at %d, got %q", op, t.pos, t.text)
+	}
+	return nil
+}
+
+// parseExpr 按运算符优先级解析二元表达式
+func (p *parser) parseExpr(minPrec int) (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		prec, ok := precedence[t.text]
+		if t.kind != tokenOperator || !ok || prec <= minPrec {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseExpr(prec)
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op: t.text, l: left, r: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	t := p.peek()
+	if t.kind == tokenOperator && (t.text == "!" || t.text == "-") {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unary{op: t.text, x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		return literal{value: t.num}, nil
+	case tokenString:
+		return literal{value: t.text}, nil
+	case tokenOperator:
+		if t.text != "(" {
+			return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+		}
+		x, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+		return x, p.expect(")")
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return literal{value: true}, nil
+		case "false":
+			return literal{value: false}, nil
+		case "nil":
+			return literal{value: nil}, nil
+		}
+		if n := p.peek(); n.kind == tokenOperator && n.text == "(" {
+			return p.parseCall(t)
+		}
+		return p.parseVariable(t)
+	default:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	argc, ok := functions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q at %d", name.text, name.pos)
+	}
+	p.next()
+	args := make([]node, 0)
+	if n := p.peek(); !(n.kind == tokenOperator && n.text == ")") {
+		for {
+			arg, err := p.parseExpr(0)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if n := p.peek(); n.kind == tokenOperator && n.text == "," {
+				p.next()
+				continue
+			}
+			break
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	if argc >= 0 && len(args) != argc {
+		return nil, fmt.Errorf("%s() requires %d argument(s), got %d", name.text, argc, len(args))
+	}
+	if argc < 0 && len(args) == 0 {
+		return nil, fmt.Errorf("%s() requires at least 1 argument", name.text)
+	}
+	return call{name: name.text, args: args}, nil
+}
+
+func (p *parser) parseVariable(first token) (node, error) {
+	path := []string{first.text}
+	for {
+		n := p.peek()
+		if n.kind != tokenOperator || n.text != "." {
+			break
+		}
+		p.next()
+		t := p.next()
+		if t.kind != tokenIdent {
+			return nil, fmt.Errorf("expected identifier after '.' at %d", t.pos)
+		}
+		path = append(path, t.text)
+	}
+	p.vars = append(p.vars, path)
+	return variable{path: path}, nil
+}
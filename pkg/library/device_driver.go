@@ -1,13 +1,11 @@
 package library
 
 import (
-	"fmt"
 	"path"
 	"sync"
 
 	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
 	"github.com/ibuilding-x/driver-box/v2/pkg/config"
-	"github.com/ibuilding-x/driver-box/v2/pkg/convutil"
 	"github.com/ibuilding-x/driver-box/v2/pkg/event"
 	"github.com/ibuilding-x/driver-box/v2/pkg/luautil"
 	glua "github.com/yuin/gopher-lua"
@@ -58,17 +56,11 @@ func (device *DeviceDriver) DeviceEncode(driverKey string, req DeviceEncodeReque
 		pointData := L.NewTable()
 		pointData.RawSetString("name", glua.LString(point.PointName))
 		if req.Mode == plugin.WriteMode {
-			//经过 PointValue 加工，数据类型一定属于string、float64、int64之一
-			switch v := point.Value.(type) {
-			case string:
-				pointData.RawSetString("value", glua.LString(v))
-			case float64:
-				pointData.RawSetString("value", glua.LVAsNumber(glua.LNumber(v)))
-			case int64:
-				pointData.RawSetString("value", glua.LVAsNumber(glua.LNumber(v)))
-			default:
-				return &DeviceEncodeResult{Error: fmt.Errorf("unsupported point value type: %T", v)}
+			value, err := luautil.ToLValue(L, point.Value)
+			if err != nil {
+				return &DeviceEncodeResult{Error: err}
 			}
+			pointData.RawSetString("value", value)
 		}
 		points.Append(pointData)
 	}
@@ -81,7 +73,7 @@ func (device *DeviceDriver) DeviceEncode(driverKey string, req DeviceEncodeReque
 		point := value.(*glua.LTable)
 		res = append(res, plugin.PointData{
 			PointName: glua.LVAsString(point.RawGetString("name")),
-			Value:     glua.LVAsString(point.RawGetString("value")),
+			Value:     luaPointValue(point.RawGetString("value")),
 		})
 	})
 	return &DeviceEncodeResult{
@@ -106,43 +98,55 @@ func (device *DeviceDriver) DeviceDecode(driverKey string, req DeviceDecodeReque
 		L = cache.(*glua.LState)
 	}
 	points := L.CreateTable(len(req.Points), 0) // 预分配数组大小
+	res := make([]plugin.PointData, 0)
+	origins := make(map[string]plugin.PointData)
 	for _, point := range req.Points {
-		pointData := L.CreateTable(0, 2) // 预分配name和value两个字段
+		origins[point.PointName] = point
+		//仅携带异常数据质量的点位无需驱动加工
+		if point.Value == nil && !point.Quality.IsGood() {
+			res = append(res, point)
+			continue
+		}
+		pointData := L.CreateTable(0, 4) // 预分配name、value、quality和timestamp四个字段
 		pointData.RawSetString("name", glua.LString(point.PointName))
-		switch v := point.Value.(type) {
-		case string:
-			pointData.RawSetString("value", glua.LString(v))
-		case int8, int16, int32, int64, int, uint, uint8, uint16, uint32, uint64:
-			intValue, e := convutil.Int64(v)
-			if e != nil {
-				return &DeviceDecodeResult{Error: e}
-			}
-			pointData.RawSetString("value", glua.LVAsNumber(glua.LNumber(intValue)))
-		case float32, float64:
-			floatValue, e := convutil.Float64(v)
-			if e != nil {
-				return &DeviceDecodeResult{Error: e}
-			}
-			pointData.RawSetString("value", glua.LVAsNumber(glua.LNumber(floatValue)))
-		default:
-			return &DeviceDecodeResult{Error: fmt.Errorf("unsupported point value type: %T", v)}
+		if point.Quality != "" {
+			pointData.RawSetString("quality", glua.LString(point.Quality))
 		}
+		if point.Timestamp > 0 {
+			pointData.RawSetString("timestamp", glua.LNumber(point.Timestamp))
+		}
+		lv, e := luautil.ToLValue(L, point.Value)
+		if e != nil {
+			return &DeviceDecodeResult{Error: e}
+		}
+		pointData.RawSetString("value", lv)
 		points.Append(pointData)
 	}
 	result, e := luautil.CallLuaMethodV2(L, "decode", glua.LString(req.DeviceId), points)
 	if e != nil {
 		return &DeviceDecodeResult{Error: e}
 	}
-	res := make([]plugin.PointData, 0)
 	events := make([]event.Data, 0)
 	result.ForEach(func(key, value glua.LValue) {
 		unit := value.(*glua.LTable)
 		//点位解析
 		pointLValue := unit.RawGetString("name")
 		if pointLValue != glua.LNil {
+			pointName := glua.LVAsString(pointLValue)
+			//驱动未指定数据质量、时间戳时沿用原始点位的值
+			quality := plugin.Quality(glua.LVAsString(unit.RawGetString("quality")))
+			if quality == "" {
+				quality = origins[pointName].Quality
+			}
+			timestamp := int64(glua.LVAsNumber(unit.RawGetString("timestamp")))
+			if timestamp == 0 {
+				timestamp = origins[pointName].Timestamp
+			}
 			res = append(res, plugin.PointData{
-				PointName: glua.LVAsString(pointLValue),
-				Value:     glua.LVAsString(unit.RawGetString("value")),
+				PointName: pointName,
+				Value:     luaPointValue(unit.RawGetString("value")),
+				Quality:   quality,
+				Timestamp: timestamp,
 			})
 			return
 		}
@@ -163,6 +167,19 @@ func (device *DeviceDriver) DeviceDecode(driverKey string, req DeviceDecodeReque
 	}
 }
 
+// luaPointValue 读取驱动返回的点位值，table 转换为数组或对象，数值保持字符串形式
+func luaPointValue(lv glua.LValue) interface{} {
+	switch lv.Type() {
+	case glua.LTTable:
+		value, _ := luautil.FromLValue(lv)
+		return value
+	case glua.LTBool:
+		return glua.LVAsBool(lv)
+	default:
+		return glua.LVAsString(lv)
+	}
+}
+
 func convertLuaValue(lv glua.LValue) any {
 	if lv.Type() == glua.LTNumber {
 		return glua.LVAsNumber(lv)
@@ -41,7 +41,7 @@ func (device *DeviceModel) ListModels() []string {
 			return err
 		}
 		if !d.IsDir() && strings.HasSuffix(d.Name(), ".json") {
-			files = append(files, strings.TrimRight(d.Name(), ".json"))
+			files = append(files, strings.TrimSuffix(d.Name(), ".json"))
 		}
 		return nil
 	})
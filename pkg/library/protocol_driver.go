@@ -73,17 +73,11 @@ func (device *ProtocolDriver) Encode(protocolKey string, req ProtocolEncodeReque
 		pointData := L.NewTable()
 		pointData.RawSetString("name", glua.LString(point.PointName))
 		if req.Mode == plugin.WriteMode {
-			//经过ConvPointType加工，数据类型一定属于string、float64、int64之一
-			switch v := point.Value.(type) {
-			case string:
-				pointData.RawSetString("value", glua.LString(v))
-			case float64:
-				pointData.RawSetString("value", glua.LVAsNumber(glua.LNumber(v)))
-			case int64:
-				pointData.RawSetString("value", glua.LVAsNumber(glua.LNumber(v)))
-			default:
-				return "", fmt.Errorf("unsupported point value type: %T", v)
+			value, err := luautil.ToLValue(L, point.Value)
+			if err != nil {
+				return "", err
 			}
+			pointData.RawSetString("value", value)
 		}
 		points.Append(pointData)
 	}
@@ -107,17 +101,11 @@ func (device *ProtocolDriver) EncodeV2(protocolKey string, req ProtocolEncodeReq
 		pointData := L.NewTable()
 		pointData.RawSetString("name", glua.LString(point.PointName))
 		if req.Mode == plugin.WriteMode {
-			//经过ConvPointType加工，数据类型一定属于string、float64、int64之一
-			switch v := point.Value.(type) {
-			case string:
-				pointData.RawSetString("value", glua.LString(v))
-			case float64:
-				pointData.RawSetString("value", glua.LVAsNumber(glua.LNumber(v)))
-			case int64:
-				pointData.RawSetString("value", glua.LVAsNumber(glua.LNumber(v)))
-			default:
-				return nil, fmt.Errorf("unsupported point value type: %T", v)
+			value, err := luautil.ToLValue(L, point.Value)
+			if err != nil {
+				return nil, err
 			}
+			pointData.RawSetString("value", value)
 		}
 		points.Append(pointData)
 	}
@@ -186,7 +174,9 @@ func (device *ProtocolDriver) DecodeV2(protocolKey string, param func(L *glua.LS
 				point := value.(*glua.LTable)
 				pointData := plugin.PointData{
 					PointName: glua.LVAsString(point.RawGetString("name")),
-					Value:     glua.LVAsString(point.RawGetString("value")),
+					Value:     luaPointValue(point.RawGetString("value")),
+					Quality:   plugin.Quality(glua.LVAsString(point.RawGetString("quality"))),
+					Timestamp: int64(glua.LVAsNumber(point.RawGetString("timestamp"))),
 				}
 				deviceData.Values = append(deviceData.Values, pointData)
 			})
@@ -1,18 +1,21 @@
 package luautil
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/cjoudrey/gluahttp"
 	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
 	"github.com/ibuilding-x/driver-box/v2/internal/logger"
 	"github.com/ibuilding-x/driver-box/v2/pkg/config"
 	"github.com/ibuilding-x/driver-box/v2/pkg/fileutil"
+	"github.com/ibuilding-x/driver-box/v2/pkg/metrics"
 	lua "github.com/yuin/gopher-lua"
 	"go.uber.org/zap"
 	luajson "layeh.com/gopher-json"
@@ -27,6 +30,40 @@ func InitLuaVM(filePath string) (*lua.LState, error) {
 		logger.Logger.Warn("lua script not found, aborting initializing lua vm", zap.Any("filePath", filePath))
 		return nil, errors.New("lua script not found")
 	}
+	ls := newLuaState()
+	// 文件路径
+	// 脚本解析
+	err := ls.DoFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	//注册同步锁
+	luaLocks.Store(ls, &sync.Mutex{})
+	return ls, nil
+}
+
+// DoString 在独立的 lua 虚拟机中执行脚本片段，执行完毕后释放虚拟机
+// globals 为注入脚本的全局变量，ctx 取消时中断脚本执行
+func DoString(ctx context.Context, script string, globals map[string]interface{}) error {
+	ls := newLuaState()
+	defer ls.Close()
+	for name, value := range globals {
+		bs, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		lv, err := luajson.Decode(ls, bs)
+		if err != nil {
+			return err
+		}
+		ls.SetGlobal(name, lv)
+	}
+	ls.SetContext(ctx)
+	return ls.DoString(script)
+}
+
+// newLuaState 创建 lua 虚拟机并预加载内置模块
+func newLuaState() *lua.LState {
 	ls := lua.NewState(lua.Options{
 		RegistrySize:    128,
 		RegistryMaxSize: 1024 * 8,
@@ -42,15 +79,7 @@ func InitLuaVM(filePath string) (*lua.LState, error) {
 			return 0
 		})
 	}
-	// 文件路径
-	// 脚本解析
-	err := ls.DoFile(filePath)
-	if err != nil {
-		return nil, err
-	}
-	//注册同步锁
-	luaLocks.Store(ls, &sync.Mutex{})
-	return ls, nil
+	return ls
 }
 
 func CallLuaConverter(L *lua.LState, method string, raw interface{}) ([]plugin.DeviceData, error) {
@@ -83,12 +112,14 @@ func CallLuaMethod(L *lua.LState, method string, args ...lua.LValue) (string, er
 	lock.(*sync.Mutex).Lock()
 	defer lock.(*sync.Mutex).Unlock()
 	// 调用脚本函数
+	start := time.Now()
 	err := L.CallByParam(lua.P{
 		Fn:      L.GetGlobal(method),
 		NRet:    1,
 		Protect: true,
 		Handler: nil,
 	}, args...)
+	metrics.LuaCallDuration.Since(start, method, metrics.Result(err))
 	defer L.Remove(1)
 	if err != nil {
 		return "", err
@@ -110,12 +141,14 @@ func CallLuaMethodV2(L *lua.LState, method string, args ...lua.LValue) (*lua.LTa
 	lock.(*sync.Mutex).Lock()
 	defer lock.(*sync.Mutex).Unlock()
 	// 调用脚本函数
+	start := time.Now()
 	err := L.CallByParam(lua.P{
 		Fn:      L.GetGlobal(method),
 		NRet:    1,
 		Protect: true,
 		Handler: nil,
 	}, args...)
+	metrics.LuaCallDuration.Since(start, method, metrics.Result(err))
 	defer L.Remove(1)
 	if err != nil {
 		return nil, err
@@ -76,6 +76,17 @@ func (lm *LuaModule) getDeviceShadow(L *lua.LState) int {
 			} else {
 				logger.Logger.Error("could not conv2 float", zap.String("deviceId", deviceId), zap.Any("point", point), zap.Error(e))
 			}
+		default:
+			v, e := convutil.PointValue(point.Value, p.ValueType())
+			if e == nil {
+				var lv lua.LValue
+				if lv, e = ToLValue(L, v); e == nil {
+					points.RawSetString(point.Name, lv)
+				}
+			}
+			if e != nil {
+				logger.Logger.Error("could not conv2 lua value", zap.String("deviceId", deviceId), zap.Any("point", point), zap.Error(e))
+			}
 		}
 	}
 	return 1
@@ -111,7 +122,7 @@ func (lm *LuaModule) setDeviceProp(L *lua.LState) int {
 	propValue := L.ToString(3)
 	deviceTable := L.NewTable()
 	defer L.Push(deviceTable)
-	cache.Get().UpdateDeviceProperty(deviceId, propName, propValue)
+	cache.Get().WithSource("lua").UpdateDeviceProperty(deviceId, propName, propValue)
 	return 1
 }
 
@@ -0,0 +1,64 @@
+package luautil
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/ibuilding-x/driver-box/v2/pkg/convutil"
+	lua "github.com/yuin/gopher-lua"
+	luajson "layeh.com/gopher-json"
+)
+
+// ToLValue 将点位值转换为 Lua 值
+// 切片、map、结构体转换为 table，日期时间转换为 RFC3339 格式字符串
+func ToLValue(L *lua.LState, value interface{}) (lua.LValue, error) {
+	switch v := value.(type) {
+	case nil:
+		return lua.LNil, nil
+	case string:
+		return lua.LString(v), nil
+	case bool:
+		return lua.LBool(v), nil
+	case time.Time:
+		return lua.LString(v.Format(time.RFC3339Nano)), nil
+	}
+	if f, err := convutil.Float64(value); err == nil {
+		return lua.LNumber(f), nil
+	}
+	switch reflect.Indirect(reflect.ValueOf(value)).Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		bs, err := json.Marshal(value)
+		if err != nil {
+			return lua.LNil, err
+		}
+		return luajson.Decode(L, bs)
+	default:
+		return lua.LNil, fmt.Errorf("unsupported point value type: %T", value)
+	}
+}
+
+// FromLValue 将 Lua 值转换为点位值，table 转换为数组或对象
+func FromLValue(lv lua.LValue) (interface{}, error) {
+	switch v := lv.(type) {
+	case *lua.LNilType:
+		return nil, nil
+	case lua.LBool:
+		return bool(v), nil
+	case lua.LNumber:
+		return float64(v), nil
+	case lua.LString:
+		return string(v), nil
+	case *lua.LTable:
+		bs, err := luajson.Encode(v)
+		if err != nil {
+			return nil, err
+		}
+		var value interface{}
+		err = json.Unmarshal(bs, &value)
+		return value, err
+	default:
+		return nil, fmt.Errorf("unsupported lua value type: %s", lv.Type())
+	}
+}
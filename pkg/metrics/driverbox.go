@@ -0,0 +1,45 @@
+package metrics
+
+import "time"
+
+// 网关内置指标
+var (
+	// IORequests 插件通讯次数
+	IORequests = NewCounterVec("driverbox_io_requests_total", "Total number of plugin read/write requests.", "plugin", "connection", "op", "result")
+	// IODuration 插件通讯耗时
+	IODuration = NewHistogramVec("driverbox_io_duration_seconds", "Latency of plugin read/write requests.", nil, "plugin", "connection", "op")
+
+	// DeviceOnline 设备在线状态，1：在线，0：离线
+	DeviceOnline = NewGaugeVec("driverbox_device_online", "Whether the device is online.", "device", "model")
+	// DeviceDisconnectTimes 设备连续通讯失败次数
+	DeviceDisconnectTimes = NewGaugeVec("driverbox_device_disconnect_times", "Consecutive communication failures of the device.", "device")
+	// PointUpdateAge 点位距最近一次更新的时长
+	PointUpdateAge = NewGaugeVec("driverbox_point_update_age_seconds", "Seconds since the point value was last updated.", "device", "point")
+
+	// ExportDuration Export 处理设备数据的耗时
+	ExportDuration = NewHistogramVec("driverbox_export_duration_seconds", "Latency of exporting device data.", nil, "export")
+	// ExportQueueDepth 断网续传队列待补发数据条数
+	ExportQueueDepth = NewGaugeVec("driverbox_export_queue_depth", "Number of records waiting in the export queue.", "queue")
+	// ExportQueueDropped 断网续传队列丢弃数据条数
+	ExportQueueDropped = NewGaugeVec("driverbox_export_queue_dropped", "Number of records dropped because the export queue is full.", "queue")
+
+	// LinkEdgeExecutions 场景联动执行次数
+	LinkEdgeExecutions = NewCounterVec("driverbox_linkedge_executions_total", "Total number of linkedge executions.", "id", "result")
+
+	// LuaCallDuration Lua 脚本函数调用耗时
+	LuaCallDuration = NewHistogramVec("driverbox_lua_call_duration_seconds", "Latency of lua function calls.", nil, "method", "result")
+)
+
+// ObserveIO 记录一次插件通讯结果及耗时
+func ObserveIO(plugin, connection, op string, start time.Time, err error) {
+	IODuration.Since(start, plugin, connection, op)
+	IORequests.Inc(plugin, connection, op, Result(err))
+}
+
+// Result 根据 err 返回结果标签：ok、error
+func Result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
@@ -0,0 +1,306 @@
+// Package metrics 提供轻量的指标采集能力，并以 Prometheus 文本格式输出
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets 默认直方图分桶，单位：秒
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// labelSep 标签值拼接分隔符
+const labelSep = "\xff"
+
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+var (
+	registry   []metric
+	collectors []func()
+	mutex      sync.Mutex
+	// 串行化输出，避免并发采集回调相互覆盖
+	scrapeMutex sync.Mutex
+)
+
+func register(m metric) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	registry = append(registry, m)
+}
+
+// RegisterCollector 注册采集回调，在每次输出指标前执行，用于刷新瞬时值
+func RegisterCollector(fn func()) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	collectors = append(collectors, fn)
+}
+
+// WriteTo 以 Prometheus 文本格式输出所有指标
+func WriteTo(w io.Writer) error {
+	scrapeMutex.Lock()
+	defer scrapeMutex.Unlock()
+	mutex.Lock()
+	fns := append([]func(){}, collectors...)
+	ms := append([]metric{}, registry...)
+	mutex.Unlock()
+	for _, fn := range fns {
+		fn()
+	}
+	bw := bufio.NewWriter(w)
+	for _, m := range ms {
+		m.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler 指标输出的 http handler
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = WriteTo(w)
+	})
+}
+
+// desc 指标描述
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (d *desc) header(w *bufio.Writer, typ string) {
+	w.WriteString("# HELP " + d.name + " " + d.help + "\n")
+	w.WriteString("# TYPE " + d.name + " " + typ + "\n")
+}
+
+// series 单条时间序列的标签值
+type series struct {
+	values []string
+}
+
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic("metrics: " + d.name + " label count mismatch")
+	}
+	return strings.Join(values, labelSep)
+}
+
+// sample 输出一行样本数据，extra 为附加的标签（如直方图的 le）
+func (d *desc) sample(w *bufio.Writer, name string, values []string, extraName, extraValue string, v float64) {
+	w.WriteString(name)
+	if len(values) > 0 || extraName != "" {
+		w.WriteByte('{')
+		for i, label := range d.labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(label + `="` + escape(values[i]) + `"`)
+		}
+		if extraName != "" {
+			if len(values) > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(extraName + `="` + extraValue + `"`)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+// CounterVec 按标签区分的计数器
+type CounterVec struct {
+	desc
+	mutex  sync.Mutex
+	series map[string]*counter
+}
+
+type counter struct {
+	series
+	value float64
+}
+
+// NewCounterVec 创建并注册计数器
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{desc: desc{name: name, help: help, labels: labels}, series: make(map[string]*counter)}
+	register(c)
+	return c
+}
+
+// Inc 计数加一
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add 计数增加 v，v 不可为负数
+func (c *CounterVec) Add(v float64, values ...string) {
+	if v < 0 {
+		return
+	}
+	key := c.key(values)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &counter{series: series{values: append([]string{}, values...)}}
+		c.series[key] = s
+	}
+	s.value += v
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.header(w, "counter")
+	for _, key := range sortedKeys(c.series) {
+		s := c.series[key]
+		c.sample(w, c.name, s.values, "", "", s.value)
+	}
+}
+
+// GaugeVec 按标签区分的瞬时值
+type GaugeVec struct {
+	desc
+	mutex  sync.Mutex
+	series map[string]*counter
+}
+
+// NewGaugeVec 创建并注册瞬时值指标
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{desc: desc{name: name, help: help, labels: labels}, series: make(map[string]*counter)}
+	register(g)
+	return g
+}
+
+// Set 设置瞬时值
+func (g *GaugeVec) Set(v float64, values ...string) {
+	key := g.key(values)
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	s, ok := g.series[key]
+	if !ok {
+		s = &counter{series: series{values: append([]string{}, values...)}}
+		g.series[key] = s
+	}
+	s.value = v
+}
+
+// Reset 清空所有时间序列，用于在采集回调中移除已不存在的对象
+func (g *GaugeVec) Reset() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.series = make(map[string]*counter)
+}
+
+func (g *GaugeVec) write(w *bufio.Writer) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.header(w, "gauge")
+	for _, key := range sortedKeys(g.series) {
+		s := g.series[key]
+		g.sample(w, g.name, s.values, "", "", s.value)
+	}
+}
+
+// HistogramVec 按标签区分的直方图
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mutex   sync.Mutex
+	series  map[string]*histogram
+}
+
+type histogram struct {
+	series
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec 创建并注册直方图，buckets 为空时使用 DefaultBuckets
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+	h := &HistogramVec{desc: desc{name: name, help: help, labels: labels}, buckets: buckets, series: make(map[string]*histogram)}
+	register(h)
+	return h
+}
+
+// Observe 记录一次观测值
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	key := h.key(values)
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogram{series: series{values: append([]string{}, values...)}, counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+// Since 记录自 start 起的耗时，单位：秒
+func (h *HistogramVec) Since(start time.Time, values ...string) {
+	h.Observe(time.Since(start).Seconds(), values...)
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.header(w, "histogram")
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		for i, upper := range h.buckets {
+			h.sample(w, h.name+"_bucket", s.values, "le", formatFloat(upper), float64(s.counts[i]))
+		}
+		h.sample(w, h.name+"_bucket", s.values, "le", "+Inf", float64(s.count))
+		h.sample(w, h.name+"_sum", s.values, "", "", s.sum)
+		h.sample(w, h.name+"_count", s.values, "", "", float64(s.count))
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
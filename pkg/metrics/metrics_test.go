@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCounterVec(t *testing.T) {
+	c := &CounterVec{desc: desc{name: "test_total", help: "test", labels: []string{"a"}}, series: make(map[string]*counter)}
+	c.Inc("x")
+	c.Add(2, "x")
+	c.Inc(`y"1`)
+	c.Add(-1, "x")
+	out := render(c)
+	for _, want := range []string{
+		"# TYPE test_total counter\n",
+		`test_total{a="x"} 3` + "\n",
+		`test_total{a="y\"1"} 1` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestHistogramVec(t *testing.T) {
+	h := &HistogramVec{desc: desc{name: "test_seconds", help: "test"}, buckets: []float64{0.1, 1}, series: make(map[string]*histogram)}
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(5)
+	out := render(h)
+	for _, want := range []string{
+		`test_seconds_bucket{le="0.1"} 1` + "\n",
+		`test_seconds_bucket{le="1"} 2` + "\n",
+		`test_seconds_bucket{le="+Inf"} 3` + "\n",
+		"test_seconds_sum 5.55\n",
+		"test_seconds_count 3\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestGaugeVec_Reset(t *testing.T) {
+	g := &GaugeVec{desc: desc{name: "test_gauge", help: "test", labels: []string{"a"}}, series: make(map[string]*counter)}
+	g.Set(1, "x")
+	g.Reset()
+	g.Set(2, "y")
+	out := render(g)
+	if strings.Contains(out, `a="x"`) || !strings.Contains(out, `test_gauge{a="y"} 2`) {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+}
+
+func render(m metric) string {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	m.write(w)
+	_ = w.Flush()
+	return buf.String()
+}
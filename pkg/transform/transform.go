@@ -0,0 +1,263 @@
+// Package transform 实现点位值的声明式转换链
+package transform
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+	"github.com/ibuilding-x/driver-box/v2/pkg/convutil"
+)
+
+// 转换类型
+const (
+	TypeLinear    = "linear"
+	TypeClamp     = "clamp"
+	TypeMap       = "map"
+	TypeBit       = "bit"
+	TypeUnit      = "unit"
+	TypeInvert    = "invert"
+	TypePiecewise = "piecewise"
+)
+
+// ErrNotInvertible 转换不可逆
+var ErrNotInvertible = errors.New("transform is not invertible")
+
+// Forward 按顺序执行转换链，用于点位读取
+func Forward(transforms []config.PointTransform, value interface{}) (interface{}, error) {
+	var err error
+	for _, t := range transforms {
+		value, err = forward(t, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s transform error: %w", t.Type, err)
+		}
+	}
+	return value, nil
+}
+
+// Inverse 逆序执行逆转换，用于点位写入
+func Inverse(transforms []config.PointTransform, value interface{}) (interface{}, error) {
+	var err error
+	for i := len(transforms) - 1; i >= 0; i-- {
+		t := transforms[i]
+		value, err = inverse(t, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s transform error: %w", t.Type, err)
+		}
+	}
+	return value, nil
+}
+
+func forward(t config.PointTransform, value interface{}) (interface{}, error) {
+	switch t.Type {
+	case TypeMap:
+		return lookup(t, value)
+	case TypeInvert:
+		return invert(value)
+	}
+	v, err := convutil.Float64(value)
+	if err != nil {
+		return nil, err
+	}
+	switch t.Type {
+	case TypeLinear:
+		return t.A*v + t.B, nil
+	case TypeClamp:
+		if t.Min != nil && v < *t.Min {
+			v = *t.Min
+		}
+		if t.Max != nil && v > *t.Max {
+			v = *t.Max
+		}
+		return v, nil
+	case TypeBit:
+		length := t.Length
+		if length <= 0 {
+			length = 1
+		}
+		if t.Offset < 0 || t.Offset+length > 64 {
+			return nil, fmt.Errorf("invalid bit range: offset %d, length %d", t.Offset, length)
+		}
+		return int64((uint64(int64(v)) >> t.Offset) & (1<<length - 1)), nil
+	case TypeUnit:
+		return convertUnit(v, t.From, t.To)
+	case TypePiecewise:
+		return interpolate(t.Table, v, false)
+	default:
+		return nil, fmt.Errorf("unknown transform type: %s", t.Type)
+	}
+}
+
+func inverse(t config.PointTransform, value interface{}) (interface{}, error) {
+	switch t.Type {
+	case TypeMap:
+		return reverseLookup(t, value)
+	case TypeInvert:
+		return invert(value)
+	case TypeBit:
+		//位提取丢失了其余位的信息，无法还原
+		return nil, ErrNotInvertible
+	}
+	v, err := convutil.Float64(value)
+	if err != nil {
+		return nil, err
+	}
+	switch t.Type {
+	case TypeLinear:
+		if t.A == 0 {
+			return nil, ErrNotInvertible
+		}
+		return (v - t.B) / t.A, nil
+	case TypeClamp:
+		//写入值超出限幅范围时拒绝写入
+		if (t.Min != nil && v < *t.Min) || (t.Max != nil && v > *t.Max) {
+			return nil, fmt.Errorf("value %v out of range", v)
+		}
+		return v, nil
+	case TypeUnit:
+		return convertUnit(v, t.To, t.From)
+	case TypePiecewise:
+		return interpolate(t.Table, v, true)
+	default:
+		return nil, fmt.Errorf("unknown transform type: %s", t.Type)
+	}
+}
+
+// lookup 映射表查找，key 为原始值的字符串形式
+func lookup(t config.PointTransform, value interface{}) (interface{}, error) {
+	key, err := convutil.String(value)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := t.Map[key]; ok {
+		return v, nil
+	}
+	if t.Default != nil {
+		return t.Default, nil
+	}
+	return nil, fmt.Errorf("unmapped value: %s", key)
+}
+
+// reverseLookup 映射表反查，转换后的值对应多个原始值时无法还原
+func reverseLookup(t config.PointTransform, value interface{}) (interface{}, error) {
+	target, err := convutil.String(value)
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	for k, v := range t.Map {
+		s, err := convutil.String(v)
+		if err != nil || s != target {
+			continue
+		}
+		if result != nil {
+			return nil, ErrNotInvertible
+		}
+		result = k
+	}
+	if result == nil {
+		return nil, fmt.Errorf("unmapped value: %s", target)
+	}
+	return result, nil
+}
+
+// invert 布尔取反，数值类型非0视为true
+func invert(value interface{}) (interface{}, error) {
+	if b, ok := value.(bool); ok {
+		return !b, nil
+	}
+	v, err := convutil.Float64(value)
+	if err != nil {
+		return nil, err
+	}
+	if v == 0 {
+		return int64(1), nil
+	}
+	return int64(0), nil
+}
+
+// interpolate 分段线性插值，超出表格范围时按首尾两段外推
+// reverse 为 true 时由转换值反算原始值，要求转换值单调
+func interpolate(table [][2]float64, v float64, reverse bool) (float64, error) {
+	if len(table) < 2 {
+		return 0, errors.New("piecewise table requires at least 2 points")
+	}
+	x, y := 0, 1
+	if reverse {
+		x, y = 1, 0
+	}
+	increasing := table[1][x] > table[0][x]
+	for i := 1; i < len(table); i++ {
+		if table[i][x] == table[i-1][x] || (table[i][x] > table[i-1][x]) != increasing {
+			if reverse {
+				return 0, ErrNotInvertible
+			}
+			return 0, errors.New("piecewise table must be strictly monotonic")
+		}
+	}
+	i := 1
+	for i < len(table)-1 && (v > table[i][x]) == increasing && v != table[i][x] {
+		i++
+	}
+	p0, p1 := table[i-1], table[i]
+	return p0[y] + (v-p0[x])*(p1[y]-p0[y])/(p1[x]-p0[x]), nil
+}
+
+// unit 单位定义，基准单位值 = 值*scale + offset
+type unit struct {
+	category string
+	scale    float64
+	offset   float64
+}
+
+var units = map[string]unit{
+	//温度，基准单位：℃
+	"℃":  {"temperature", 1, 0},
+	"°C": {"temperature", 1, 0},
+	"℉":  {"temperature", 5.0 / 9, -160.0 / 9},
+	"°F": {"temperature", 5.0 / 9, -160.0 / 9},
+	"K":  {"temperature", 1, -273.15},
+	//压力，基准单位：Pa
+	"Pa":  {"pressure", 1, 0},
+	"kPa": {"pressure", 1e3, 0},
+	"MPa": {"pressure", 1e6, 0},
+	"bar": {"pressure", 1e5, 0},
+	"psi": {"pressure", 6894.757293168, 0},
+	//长度，基准单位：m
+	"mm": {"length", 1e-3, 0},
+	"cm": {"length", 1e-2, 0},
+	"m":  {"length", 1, 0},
+	"km": {"length", 1e3, 0},
+	//功率，基准单位：W
+	"W":  {"power", 1, 0},
+	"kW": {"power", 1e3, 0},
+	"MW": {"power", 1e6, 0},
+	//电能，基准单位：Wh
+	"Wh":  {"energy", 1, 0},
+	"kWh": {"energy", 1e3, 0},
+	"MWh": {"energy", 1e6, 0},
+	//体积流量，基准单位：m³/h
+	"m³/h":  {"flow", 1, 0},
+	"L/s":   {"flow", 3.6, 0},
+	"L/min": {"flow", 0.06, 0},
+}
+
+// convertUnit 单位换算
+func convertUnit(v float64, from, to string) (float64, error) {
+	f, ok := units[from]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit: %s", from)
+	}
+	t, ok := units[to]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit: %s", to)
+	}
+	if f.category != t.category {
+		return 0, fmt.Errorf("cannot convert %s to %s", from, to)
+	}
+	base := v*f.scale + f.offset
+	result := (base - t.offset) / t.scale
+	//消除浮点运算误差
+	return math.Round(result*1e9) / 1e9, nil
+}
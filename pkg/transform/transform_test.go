@@ -0,0 +1,55 @@
+package transform
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+)
+
+func TestForwardInverse(t *testing.T) {
+	max := 100.0
+	cases := []struct {
+		name       string
+		transforms []config.PointTransform
+		raw        interface{}
+		want       interface{}
+	}{
+		{"linear", []config.PointTransform{{Type: TypeLinear, A: 0.1, B: -40}}, 500.0, 10.0},
+		{"clamp", []config.PointTransform{{Type: TypeClamp, Max: &max}}, 80.0, 80.0},
+		{"map", []config.PointTransform{{Type: TypeMap, Map: map[string]interface{}{"0": "off", "1": "on"}}}, "1", "on"},
+		{"invert", []config.PointTransform{{Type: TypeInvert}}, int64(0), int64(1)},
+		{"unit", []config.PointTransform{{Type: TypeUnit, From: "℉", To: "℃"}}, 212.0, 100.0},
+		{"piecewise", []config.PointTransform{{Type: TypePiecewise, Table: [][2]float64{{0, 0}, {10, 100}, {20, 150}}}}, 15.0, 125.0},
+		{"chain", []config.PointTransform{{Type: TypeLinear, A: 0.1}, {Type: TypeUnit, From: "kW", To: "W"}}, 25.0, 2500.0},
+	}
+	for _, c := range cases {
+		got, err := Forward(c.transforms, c.raw)
+		if err != nil || got != c.want {
+			t.Errorf("%s: Forward = %v, %v, want %v", c.name, got, err, c.want)
+			continue
+		}
+		raw, err := Inverse(c.transforms, got)
+		if err != nil || raw != c.raw {
+			t.Errorf("%s: Inverse = %v, %v, want %v", c.name, raw, err, c.raw)
+		}
+	}
+}
+
+func TestForward_Bit(t *testing.T) {
+	bits := []config.PointTransform{{Type: TypeBit, Offset: 2, Length: 2}}
+	got, err := Forward(bits, 0b1101)
+	if err != nil || got != int64(0b11) {
+		t.Fatalf("Forward = %v, %v", got, err)
+	}
+	if _, err = Inverse(bits, got); !errors.Is(err, ErrNotInvertible) {
+		t.Fatalf("Inverse error = %v, want ErrNotInvertible", err)
+	}
+}
+
+func TestInverse_Clamp(t *testing.T) {
+	max := 100.0
+	if _, err := Inverse([]config.PointTransform{{Type: TypeClamp, Max: &max}}, 120.0); err == nil {
+		t.Fatal("write out of clamp range should fail")
+	}
+}
@@ -242,6 +242,7 @@ func (c *connector) Decode(raw interface{}) (res []plugin.DeviceData, err error)
 	pointDatalist := []plugin.PointData{{
 		PointName: resp.PointName,
 		Value:     resp.Value,
+		Quality:   resp.Quality,
 	}}
 	res = append(res, plugin.DeviceData{
 		ID:     resp.DeviceId,
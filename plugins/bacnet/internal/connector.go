@@ -12,6 +12,7 @@ import (
 	"github.com/ibuilding-x/driver-box/v2/pkg/config"
 	"github.com/ibuilding-x/driver-box/v2/pkg/convutil"
 	"github.com/ibuilding-x/driver-box/v2/pkg/crontab"
+	"github.com/ibuilding-x/driver-box/v2/pkg/metrics"
 	"github.com/ibuilding-x/driver-box/v2/plugins/bacnet/internal/bacnet"
 	"github.com/ibuilding-x/driver-box/v2/plugins/bacnet/internal/bacnet/btypes"
 	"github.com/ibuilding-x/driver-box/v2/plugins/bacnet/internal/bacnet/network"
@@ -197,6 +198,11 @@ func (c *connector) Send(raw interface{}) (err error) {
 		driverbox.Log().Error("none device config")
 		return err
 	}
+	if !c.virtual {
+		defer func(start time.Time) {
+			metrics.ObserveIO(ProtocolName, c.key, string(br.mode), start, err)
+		}(time.Now())
+	}
 	switch br.mode {
 	// 读
 	case plugin.ReadMode:
@@ -265,13 +271,13 @@ func (c *connector) Send(raw interface{}) (err error) {
 type readResponse struct {
 	Value     interface{}       `json:"value"`
 	Status    map[string]string `json:"status"`
+	Quality   plugin.Quality    `json:"quality"`
 	DeviceId  string            `json:"deviceId"`
 	PointName string            `json:"pointName"`
 }
 
 func convertObj2Resp(object *btypes.Object) (resp *readResponse, err error) {
 	resp = &readResponse{}
-	normal := true
 	for _, prop := range object.Properties {
 		switch prop.Type {
 		case btypes.PROP_PRESENT_VALUE:
@@ -293,22 +299,32 @@ func convertObj2Resp(object *btypes.Object) (resp *readResponse, err error) {
 				} else if i == 3 {
 					status["outofservice"] = cast.ToString(bitValues[i])
 				}
-				if bitValues[i] {
-					normal = false
-				}
 			}
 			resp.Status = status
+			resp.Quality = statusFlagsQuality(status)
 		}
 	}
-	if !normal {
-		return nil, fmt.Errorf("point is abnormal")
-	}
 	if resp.Value == nil {
 		return nil, fmt.Errorf("read value is nil")
 	}
 	return resp, nil
 }
 
+// statusFlagsQuality 将 BACnet 状态标志转换为点位数据质量
+// fault：数据异常；out-of-service：数据不确定；overridden：数据为本地强制值
+func statusFlagsQuality(status map[string]string) plugin.Quality {
+	switch {
+	case status["fault"] == "true":
+		return plugin.QualityBad
+	case status["outofservice"] == "true":
+		return plugin.QualityUncertain
+	case status["overridden"] == "true":
+		return plugin.QualitySubstituted
+	default:
+		return plugin.QualityGood
+	}
+}
+
 func (c *connector) Release() (err error) {
 	return nil
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/ibuilding-x/driver-box/v2/pkg/config"
 	"github.com/ibuilding-x/driver-box/v2/pkg/convutil"
 	"github.com/ibuilding-x/driver-box/v2/pkg/crontab"
+	"github.com/ibuilding-x/driver-box/v2/pkg/metrics"
 	dlt "github.com/ibuilding-x/driver-box/v2/plugins/dlt645/internal/core"
 	"github.com/ibuilding-x/driver-box/v2/plugins/dlt645/internal/core/dltcon"
 	"go.uber.org/zap"
@@ -195,7 +196,9 @@ func (c *connector) sendReadCommand(group *pointGroup) error {
 	if c.virtual {
 		value = 0
 	} else {
+		start := time.Now()
 		value, err = c.read(group.SlaveId, group.DataMaker)
+		metrics.ObserveIO(ProtocolName, c.config.ConnectionKey, string(plugin.ReadMode), start, err)
 	}
 
 	if err != nil {
@@ -63,7 +63,7 @@ func (c *connector) Encode(deviceId string, mode plugin.EncodeMode, values ...pl
 	//寻找待读点位关联的pointGroup
 	for _, readPoint := range values {
 		ok = false
-		for _, group := range slave.pointGroup {
+		for _, group := range c.pointGroups(slave) {
 			for _, point := range group.Points {
 				if point.Name() == readPoint.PointName {
 					if _, ok := indexes[group.index]; !ok {
@@ -140,6 +140,7 @@ func (c *connector) batchWriteEncode(deviceId string, points []plugin.PointData)
 		copy(bytes, preValue.Value)
 		copy(bytes[len(preValue.Value):], v.Value)
 		preValue.Value = bytes
+		preValue.checks = append(preValue.checks, v.checks...)
 	}
 	return mergedValues, nil
 }
@@ -163,6 +164,13 @@ func (c *connector) getWriteValue(deviceId string, pointData plugin.PointData, w
 		driverbox.Log().Error("error modbus point config", zap.String("deviceId", deviceId), zap.Any("point", pointData.PointName), zap.Error(err))
 		return writeValue{}, err
 	}
+	//布尔类型点位按 0/1 写入
+	if b, ok := value.(bool); ok {
+		value = 0
+		if b {
+			value = 1
+		}
+	}
 	var values []uint16
 	switch ext.RegisterType {
 	case Coil: // 线圈固定长度1
@@ -210,6 +218,9 @@ func (c *connector) getWriteValue(deviceId string, pointData plugin.PointData, w
 						driverbox.Log().Info("merge bits", zap.Uint16("preValue", writeVal.Value[0]), zap.Uint16("bitValue", intoUint16))
 						writeVal.Value[0] = (writeVal.Value[0] & ^(((1 << ext.BitLen) - 1) << ext.Bit)) | (intoUint16 & (((1 << ext.BitLen) - 1) << ext.Bit))
 						driverbox.Log().Info("merge bits result", zap.Uint16("finalVal", writeVal.Value[0]))
+						if check := newWriteCheck(deviceId, ext, []uint16{intoUint16}); check != nil {
+							writeVal.checks = append(writeVal.checks, check)
+						}
 						return writeValue{}, nil
 					}
 				}
@@ -376,11 +387,15 @@ func (c *connector) getWriteValue(deviceId string, pointData plugin.PointData, w
 	default:
 		return writeValue{}, fmt.Errorf("unsupported write register type: %v", ext)
 	}
-	return writeValue{
+	wv := writeValue{
 		unitID:       unitId,
 		RegisterType: ext.RegisterType,
 		Address:      ext.Address,
 		Value:        values,
 		MultiWrite:   ext.MultiWrite,
-	}, nil
+	}
+	if check := newWriteCheck(deviceId, ext, values); check != nil {
+		wv.checks = []*writeCheck{check}
+	}
+	return wv, nil
 }
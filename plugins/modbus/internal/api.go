@@ -0,0 +1,169 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ibuilding-x/driver-box/v2/driverbox"
+)
+
+// modbus 插件 API，统一挂载于 /api/v1/ 下
+const (
+	apiLayout    = "modbus/layout"
+	apiRegisters = "modbus/registers"
+	apiStats     = "modbus/stats"
+)
+
+var errConnectionKeyRequired = errors.New("connectionKey is required")
+
+// blockLayout 读取块布局
+type blockLayout struct {
+	UnitID       uint8         `json:"unitID"`
+	PrimaryTable primaryTable  `json:"primaryTable"`
+	Address      uint16        `json:"address"`
+	Quantity     uint16        `json:"quantity"`
+	Duration     string        `json:"duration"`
+	Points       []pointLayout `json:"points"`
+}
+
+type pointLayout struct {
+	DeviceID string `json:"deviceId"`
+	Name     string `json:"name"`
+	Address  uint16 `json:"address"`
+	Quantity uint16 `json:"quantity"`
+}
+
+// connectionLayout 连接的读取块布局及已知的拆分边界
+type connectionLayout struct {
+	ConnectionKey string              `json:"connectionKey"`
+	BatchReadLen  uint16              `json:"batchReadLen"`
+	MaxGap        *int                `json:"maxGap"`
+	Blocks        []blockLayout       `json:"blocks"`
+	Splits        map[string][]uint16 `json:"splits"`
+}
+
+func (p *Plugin) registerApi() {
+	driverbox.BaseExport().HandleFunc(http.MethodGet, apiLayout, p.apiLayout)
+	driverbox.BaseExport().HandleFunc(http.MethodGet, apiRegisters, p.apiReadRegisters)
+	driverbox.BaseExport().HandleFunc(http.MethodPost, apiRegisters, p.apiWriteRegisters)
+	driverbox.BaseExport().HandleFunc(http.MethodGet, apiStats, p.apiStats)
+}
+
+// getConnection 根据请求参数 connectionKey 获取连接
+func (p *Plugin) getConnection(r *http.Request) (*connector, error) {
+	key := r.URL.Query().Get("connectionKey")
+	if key == "" {
+		return nil, errConnectionKeyRequired
+	}
+	p.mutex.RLock()
+	conn, ok := p.connPool[key]
+	p.mutex.RUnlock()
+	if !ok {
+		return nil, errors.New("not found connection key, key is " + key)
+	}
+	return conn, nil
+}
+
+// 查询连接当前采用的读取块布局
+// curl "http://127.0.0.1:8081/api/v1/modbus/layout?connectionKey=xxx"
+func (p *Plugin) apiLayout(r *http.Request) (any, error) {
+	conn, err := p.getConnection(r)
+	if err != nil {
+		return nil, err
+	}
+	return conn.layout(), nil
+}
+
+// 绕过物模型读取原始寄存器，返回原始值及各数据类型、字节序组合的解析结果
+// curl "http://127.0.0.1:8081/api/v1/modbus/registers?connectionKey=xxx&unitID=1&primaryTable=HOLDING_REGISTER&address=0&quantity=4"
+func (p *Plugin) apiReadRegisters(r *http.Request) (any, error) {
+	conn, err := p.getConnection(r)
+	if err != nil {
+		return nil, err
+	}
+	req, err := parseRegisterRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	return conn.diagnoseRead(req)
+}
+
+// 绕过物模型写入原始寄存器，返回回读结果
+// curl -X POST -d '{"values":[1,2]}' "http://127.0.0.1:8081/api/v1/modbus/registers?connectionKey=xxx&unitID=1&primaryTable=HOLDING_REGISTER&address=0"
+func (p *Plugin) apiWriteRegisters(r *http.Request) (any, error) {
+	conn, err := p.getConnection(r)
+	if err != nil {
+		return nil, err
+	}
+	req, err := parseRegisterRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	if err = readRegisterBody(r, &req); err != nil {
+		return nil, err
+	}
+	return conn.diagnoseWrite(req)
+}
+
+// 查询连接的运行状态：最近一次通讯错误、各点位组的连续超时次数及退避后的采集间隔
+// curl "http://127.0.0.1:8081/api/v1/modbus/stats?connectionKey=xxx"
+func (p *Plugin) apiStats(r *http.Request) (any, error) {
+	conn, err := p.getConnection(r)
+	if err != nil {
+		return nil, err
+	}
+	return conn.stats(), nil
+}
+
+// layout 连接下各通讯设备的读取块，按从机地址排序
+func (c *connector) layout() connectionLayout {
+	result := connectionLayout{
+		ConnectionKey: c.config.ConnectionKey,
+		BatchReadLen:  c.config.BatchReadLen,
+		MaxGap:        c.config.MaxGap,
+		Blocks:        make([]blockLayout, 0),
+		Splits:        make(map[string][]uint16),
+	}
+	for _, unitID := range c.unitIDs() {
+		for _, group := range c.pointGroups(c.devices[unitID]) {
+			block := blockLayout{
+				UnitID:       group.UnitID,
+				PrimaryTable: group.RegisterType,
+				Address:      group.Address,
+				Quantity:     group.Quantity,
+				Duration:     group.Duration.String(),
+				Points:       make([]pointLayout, 0, len(group.Points)),
+			}
+			for _, point := range group.Points {
+				block.Points = append(block.Points, pointLayout{
+					DeviceID: point.DeviceId,
+					Name:     point.Name(),
+					Address:  point.Address,
+					Quantity: point.Quantity,
+				})
+			}
+			result.Blocks = append(result.Blocks, block)
+		}
+	}
+	prefix := c.config.ConnectionKey + "/"
+	c.plugin.splitMutex.Lock()
+	for key, boundaries := range c.plugin.splits {
+		if strings.HasPrefix(key, prefix) {
+			result.Splits[strings.TrimPrefix(key, prefix)] = boundaries
+		}
+	}
+	c.plugin.splitMutex.Unlock()
+	return result
+}
+
+// unitIDs 连接下的从机地址，升序排列
+func (c *connector) unitIDs() []uint8 {
+	unitIDs := make([]uint8, 0, len(c.devices))
+	for unitID := range c.devices {
+		unitIDs = append(unitIDs, unitID)
+	}
+	sort.Slice(unitIDs, func(i, j int) bool { return unitIDs[i] < unitIDs[j] })
+	return unitIDs
+}
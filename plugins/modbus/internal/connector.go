@@ -7,6 +7,7 @@ import (
 	"math"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ibuilding-x/driver-box/v2/driverbox"
@@ -14,6 +15,7 @@ import (
 	"github.com/ibuilding-x/driver-box/v2/pkg/config"
 	"github.com/ibuilding-x/driver-box/v2/pkg/convutil"
 	"github.com/ibuilding-x/driver-box/v2/pkg/crontab"
+	"github.com/ibuilding-x/driver-box/v2/pkg/metrics"
 	"github.com/simonvetter/modbus"
 	"github.com/spf13/cast"
 	"go.uber.org/zap"
@@ -33,22 +35,19 @@ func newConnector(p *Plugin, cf *ConnectionConfig) (*connector, error) {
 		cf.BatchReadLen = 32
 	}
 
-	client, err := modbus.NewClient(&modbus.ClientConfiguration{
-		URL:      fmt.Sprintf("%s://%s", cf.Mode, cf.Address),
-		Speed:    cf.BaudRate,
-		DataBits: cf.DataBits,
-		Parity:   cf.Parity,
-		StopBits: cf.StopBits,
-		Timeout:  time.Duration(cf.Timeout) * time.Millisecond,
-	})
+	sessions, err := newSessions(cf)
 	conn := &connector{
-		config:  cf,
-		plugin:  p,
-		client:  client,
-		virtual: cf.Virtual || config.IsVirtual(),
-		devices: make(map[uint8]*slaveDevice),
+		config:   cf,
+		plugin:   p,
+		pool:     make(chan *session, len(sessions)),
+		sessions: sessions,
+		virtual:  cf.Virtual || config.IsVirtual(),
+		devices:  make(map[uint8]*slaveDevice),
+		done:     make(chan struct{}),
+	}
+	for _, s := range sessions {
+		conn.pool <- s
 	}
-
 	return conn, err
 }
 
@@ -56,75 +55,100 @@ func (c *connector) initCollectTask(conf *ConnectionConfig) (*crontab.Future, er
 
 	//注册定时采集任务
 	return driverbox.AddFunc("1s", func() {
+		//连接池存在多个会话时，各通讯设备并发采集
+		if conf.PoolSize > 1 {
+			var wg sync.WaitGroup
+			for unitID, device := range c.devices {
+				if c.close.Load() {
+					break
+				}
+				wg.Add(1)
+				go func(unitID uint8, device *slaveDevice) {
+					defer wg.Done()
+					c.collectDevice(conf, unitID, device)
+				}(unitID, device)
+			}
+			wg.Wait()
+			return
+		}
 		//遍历所有通讯设备
 		for unitID, device := range c.devices {
-			if len(device.pointGroup) == 0 {
-				driverbox.Log().Warn("device has none read point", zap.Uint8("unitID", unitID))
-				continue
+			if c.close.Load() {
+				return
 			}
-			//批量遍历通讯设备下的点位，并将结果关联至物模型设备
-			for i, group := range device.pointGroup {
-				if c.close {
-					driverbox.Log().Warn("modbus connection is closed, ignore collect task!", zap.String("key", c.config.ConnectionKey))
-					return
-				}
+			c.collectDevice(conf, unitID, device)
+		}
+	})
+}
 
-				duration := group.Duration
-				if group.TimeOutCount > 0 {
-					duration = duration * time.Duration(1<<group.TimeOutCount)
-					//最大不超过一分钟
-					if duration > time.Minute {
-						duration = time.Minute
-					} else if duration < 0 { //溢出，重置
-						group.TimeOutCount = 0
-						duration = group.Duration
-					}
-					driverbox.Log().Warn("modbus connection has timeout, increase duration", zap.Any("group", group), zap.Any("duration", duration))
-				}
-				//采集时间未到
-				if group.LatestTime.Add(duration).After(time.Now()) {
-					continue
-				}
+// collectDevice 采集通讯设备下到期的点位组
+func (c *connector) collectDevice(conf *ConnectionConfig, unitID uint8, device *slaveDevice) {
+	groups := c.pointGroups(device)
+	if len(groups) == 0 {
+		driverbox.Log().Warn("device has none read point", zap.Uint8("unitID", unitID))
+		return
+	}
+	//批量遍历通讯设备下的点位，并将结果关联至物模型设备
+	for i, group := range groups {
+		if c.close.Load() {
+			driverbox.Log().Warn("modbus connection is closed, ignore collect task!", zap.String("key", c.config.ConnectionKey))
+			return
+		}
 
-				//最近发生过写操作，推测当前时段可能存在其他设备的写入需求，采集任务主动避让
-				if c.writeSemaphore.Load() > 0 || c.latestWriteTime.Add(time.Duration(conf.MinInterval)).After(time.Now()) {
-					driverbox.Log().Warn("modbus connection is writing, ignore collect task!", zap.String("key", c.config.ConnectionKey), zap.Any("semaphore", c.writeSemaphore.Load()))
-					continue
-				}
+		c.groupMutex.RLock()
+		duration := group.backoff()
+		if group.TimeOutCount > 0 {
+			driverbox.Log().Warn("modbus connection has timeout, increase duration", zap.Any("group", group), zap.Any("duration", duration))
+		}
+		latestTime := group.LatestTime
+		c.groupMutex.RUnlock()
+		//采集时间未到
+		if latestTime.Add(duration).After(time.Now()) {
+			continue
+		}
 
-				driverbox.Log().Debug("timer read modbus", zap.Any("group", i), zap.Any("latestTime", group.LatestTime), zap.Any("duration", group.Duration))
-				bac := command{
-					Mode:  plugin.ReadMode,
-					Value: group,
-				}
-				if err := c.Send(bac); err != nil {
-					driverbox.Log().Error("read error", zap.Any("connection", conf), zap.Any("group", group), zap.Error(err))
-					//发生读超时，设备可能离线或者当前group点位配置有问题。将当前group的采集时间设置为未来值，跳过数个采集周期
-					if errors.Is(err, modbus.ErrRequestTimedOut) {
-						group.TimeOutCount += 1
-					}
-					//通讯失败，触发离线
-					devices := make(map[string]interface{})
-					for _, point := range group.Points {
-						if devices[point.DeviceId] != nil {
-							continue
-						}
-						devices[point.DeviceId] = point.Name
-						_ = driverbox.Shadow().MayBeOffline(point.DeviceId)
-					}
-				} else {
-					group.TimeOutCount = 0
+		//最近发生过写操作，推测当前时段可能存在其他设备的写入需求，采集任务主动避让
+		if c.writeSemaphore.Load() > 0 || time.Unix(0, c.latestWriteTime.Load()).Add(time.Duration(conf.MinInterval)).After(time.Now()) {
+			driverbox.Log().Warn("modbus connection is writing, ignore collect task!", zap.String("key", c.config.ConnectionKey), zap.Any("semaphore", c.writeSemaphore.Load()))
+			continue
+		}
+
+		driverbox.Log().Debug("timer read modbus", zap.Any("group", i), zap.Any("latestTime", latestTime), zap.Any("duration", group.Duration))
+		bac := command{
+			Mode:  plugin.ReadMode,
+			Value: group,
+		}
+		err := c.Send(bac)
+		if err != nil {
+			driverbox.Log().Error("read error", zap.Any("connection", conf), zap.Uint8("unitID", group.UnitID), zap.Uint16("address", group.Address), zap.Uint16("quantity", group.Quantity), zap.Error(err))
+			//从机拒绝读取该区间，拆分读取块后于下一周期重新采集
+			if errors.Is(err, modbus.ErrIllegalDataAddress) && c.bisect(device, group) {
+				return
+			}
+			//通讯失败，触发离线
+			devices := make(map[string]interface{})
+			for _, point := range group.Points {
+				if devices[point.DeviceId] != nil {
+					continue
 				}
-				group.LatestTime = time.Now()
+				devices[point.DeviceId] = point.Name
+				_ = driverbox.Shadow().MayBeOffline(point.DeviceId)
 			}
-
 		}
-	})
+		c.groupMutex.Lock()
+		if err == nil {
+			group.TimeOutCount = 0
+		} else if errors.Is(err, modbus.ErrRequestTimedOut) {
+			//发生读超时，设备可能离线或者当前group点位配置有问题。延长当前group的采集间隔，跳过数个采集周期
+			group.TimeOutCount += 1
+		}
+		group.LatestTime = time.Now()
+		c.groupMutex.Unlock()
+	}
 }
 
-// 采集任务分组
+// createPointGroup 收集设备的采集点位，全部设备收集完成后由 buildPointGroups 统一分组
 func (c *connector) createPointGroup(conf *ConnectionConfig, model config.DeviceModel, dev config.Device) {
-	groupIndex := 0
 	for _, point := range model.DevicePoints {
 		if point.ReadWrite() != config.ReadWrite_R && point.ReadWrite() != config.ReadWrite_RW {
 			continue
@@ -146,54 +170,9 @@ func (c *connector) createPointGroup(conf *ConnectionConfig, model config.Device
 			driverbox.Log().Error("error modbus device config", zap.String("deviceId", dev.ID), zap.Any("config", point), zap.Error(err))
 			continue
 		}
-		ok := false
-		//同一寄存器地址可能通过位运算对应多个点位，也需要将该点加入group
-		for _, group := range device.pointGroup {
-			//相同采集频率为同一组
-			if group.Duration != duration {
-				continue
-			}
-			//不同寄存器类型不为一组
-			if group.RegisterType != ext.RegisterType {
-				continue
-			}
-
-			//如果ext和group中的其他addres区间长度不超过maxLen，则添加到group中
-			start := group.Address
-			if start > ext.Address {
-				start = ext.Address
-			}
-			end := group.Address + group.Quantity
-			if end < ext.Address+ext.Quantity {
-				end = ext.Address + ext.Quantity
-			}
-			//超过最大长度，拆成新的一组
-			if end-start <= conf.BatchReadLen {
-				group.Points = append(group.Points, ext)
-				ok = true
-				group.Address = start
-				group.Quantity = end - start
-				break
-			}
-		}
-		//新增一个点位组
-		if !ok {
-			ext.DeviceId = dev.ID
-			device.pointGroup = append(device.pointGroup, &pointGroup{
-				index:        groupIndex,
-				UnitID:       device.unitID,
-				Duration:     duration,
-				RegisterType: ext.RegisterType,
-				Address:      ext.Address,
-				Quantity:     ext.Quantity,
-				Points: []*Point{
-					ext,
-				},
-			})
-			groupIndex++
-		}
+		ext.interval = duration
+		device.points = append(device.points, ext)
 	}
-
 }
 
 // Send 发送数据
@@ -234,23 +213,28 @@ func (c *connector) Release() (err error) {
 	return
 }
 
+// Close 关闭连接，空闲会话立即关闭，使用中的会话归还时关闭
 func (c *connector) Close() {
-	c.close = true
+	c.poolMutex.Lock()
+	defer c.poolMutex.Unlock()
+	if c.close.Swap(true) {
+		return
+	}
+	close(c.done)
 	if c.collectTask != nil {
 		c.collectTask.Disable()
 	}
-	if c.keepAlive {
-		_ = c.client.Close()
+	if c.scanTask != nil {
+		c.scanTask.Disable()
 	}
-}
-
-// ensureInterval 确保与前一次IO至少间隔minInterval毫秒
-func (c *connector) ensureInterval() {
-	np := c.latestIoTime.Add(time.Duration(c.config.MinInterval) * time.Millisecond)
-	if time.Now().Before(np) {
-		time.Sleep(time.Until(np))
+	for {
+		select {
+		case s := <-c.pool:
+			s.close()
+		default:
+			return
+		}
 	}
-	c.latestIoTime = time.Now()
 }
 
 func (c *connector) sendReadCommand(group *pointGroup) error {
@@ -262,7 +246,7 @@ func (c *connector) sendReadCommand(group *pointGroup) error {
 
 	if c.writeSemaphore.Load() > 0 {
 		c.resetCollectTime(group)
-		driverbox.Log().Warn("modbus connection is writing, ignore collect task!", zap.String("key", c.config.ConnectionKey), zap.Any("semaphore", c.writeSemaphore))
+		driverbox.Log().Warn("modbus connection is writing, ignore collect task!", zap.String("key", c.config.ConnectionKey), zap.Any("semaphore", c.writeSemaphore.Load()))
 		return nil
 	}
 
@@ -271,7 +255,9 @@ func (c *connector) sendReadCommand(group *pointGroup) error {
 	if c.virtual {
 		values, err = c.mockRead(group.UnitID, string(group.RegisterType), group.Address, group.Quantity)
 	} else {
+		start := time.Now()
 		values, err = c.read(group.UnitID, string(group.RegisterType), group.Address, group.Quantity)
+		metrics.ObserveIO(ProtocolName, c.config.ConnectionKey, string(plugin.ReadMode), start, err)
 	}
 
 	if err != nil {
@@ -279,60 +265,11 @@ func (c *connector) sendReadCommand(group *pointGroup) error {
 	}
 	// 转化数据并上报
 	for _, point := range group.Points {
-		var value interface{}
 		start := point.Address - group.Address
-		rawValues := values[start : start+point.Quantity]
-		reverseUint16s(rawValues)
-		switch point.RegisterType {
-		case Coil, DiscreteInput: // 线圈和离散都是单个长度，直接返回值即可
-			value = rawValues[0]
-		case InputRegister, HoldingRegister: // 输入寄存器和保持寄存器需要根据大小端还有bit位进行处理
-			switch strings.ToUpper(point.RawType) {
-			case strings.ToUpper(ValueTypeUint16), strings.ToUpper(ValueTypeInt16):
-				out := getBytesFromUint16s(rawValues, point.ByteSwap)
-				val := binary.BigEndian.Uint16(out)
-				// 根据bit位读取数据
-				if point.BitLen > 0 {
-					value = getBitsFromPosition(val, point.Bit, point.BitLen)
-				} else {
-					if strings.ToUpper(point.RawType) == strings.ToUpper(ValueTypeInt16) {
-						value = int16(val)
-					} else {
-						value = val
-					}
-				}
-			case strings.ToUpper(ValueTypeUint32), strings.ToUpper(ValueTypeInt32), strings.ToUpper(ValueTypeFloat32):
-				out := getBytesFromUint16s(rawValues, point.ByteSwap)
-				out = swapWords(out, point.WordSwap)
-				val := binary.BigEndian.Uint32(out)
-				switch strings.ToUpper(point.RawType) {
-				case strings.ToUpper(ValueTypeUint32):
-					value = val
-				case strings.ToUpper(ValueTypeInt32):
-					value = int32(val)
-				case strings.ToUpper(ValueTypeFloat32):
-					value = math.Float32frombits(val)
-				}
-			case strings.ToUpper(ValueTypeUint64), strings.ToUpper(ValueTypeInt64), strings.ToUpper(ValueTypeFloat64):
-				out := getBytesFromUint16s(rawValues, point.ByteSwap)
-				out = swapWords(out, point.WordSwap)
-				val := binary.BigEndian.Uint64(out)
-				switch strings.ToUpper(point.RawType) {
-				case strings.ToUpper(ValueTypeUint64):
-					value = val
-				case strings.ToUpper(ValueTypeInt64):
-					value = int32(val)
-				case strings.ToUpper(ValueTypeFloat64):
-					value = math.Float64frombits(val)
-				}
-			case strings.ToUpper(ValueTypeString):
-				out := getBytesFromUint16s(rawValues, point.ByteSwap)
-				out = swapWords(out, point.WordSwap)
-				value = string(out)
-			default:
-				driverbox.Log().Error(fmt.Sprintf("unsupported raw type: %v", point))
-				continue
-			}
+		value, err := decodePoint(point, values[start:start+point.Quantity])
+		if err != nil {
+			driverbox.Log().Error(err.Error())
+			continue
 		}
 		pointReadValue := plugin.PointReadValue{
 			ID:        point.DeviceId,
@@ -348,7 +285,83 @@ func (c *connector) sendReadCommand(group *pointGroup) error {
 	return nil
 }
 
+// decodePoint 按点位的数据类型及字节序解析寄存器值，registers 为从机返回的原始寄存器
+func decodePoint(point *Point, registers []uint16) (interface{}, error) {
+	var value interface{}
+	rawValues := make([]uint16, len(registers))
+	copy(rawValues, registers)
+	reverseUint16s(rawValues)
+	switch point.RegisterType {
+	case Coil, DiscreteInput: // 线圈和离散都是单个长度，直接返回值即可
+		value = rawValues[0]
+	case InputRegister, HoldingRegister: // 输入寄存器和保持寄存器需要根据大小端还有bit位进行处理
+		switch strings.ToUpper(point.RawType) {
+		case strings.ToUpper(ValueTypeUint16), strings.ToUpper(ValueTypeInt16):
+			out := getBytesFromUint16s(rawValues, point.ByteSwap)
+			val := binary.BigEndian.Uint16(out)
+			// 根据bit位读取数据
+			if point.BitLen > 0 {
+				value = getBitsFromPosition(val, point.Bit, point.BitLen)
+			} else {
+				if strings.ToUpper(point.RawType) == strings.ToUpper(ValueTypeInt16) {
+					value = int16(val)
+				} else {
+					value = val
+				}
+			}
+		case strings.ToUpper(ValueTypeUint32), strings.ToUpper(ValueTypeInt32), strings.ToUpper(ValueTypeFloat32):
+			out := getBytesFromUint16s(rawValues, point.ByteSwap)
+			out = swapWords(out, point.WordSwap)
+			val := binary.BigEndian.Uint32(out)
+			switch strings.ToUpper(point.RawType) {
+			case strings.ToUpper(ValueTypeUint32):
+				value = val
+			case strings.ToUpper(ValueTypeInt32):
+				value = int32(val)
+			case strings.ToUpper(ValueTypeFloat32):
+				value = math.Float32frombits(val)
+			}
+		case strings.ToUpper(ValueTypeUint64), strings.ToUpper(ValueTypeInt64), strings.ToUpper(ValueTypeFloat64):
+			out := getBytesFromUint16s(rawValues, point.ByteSwap)
+			out = swapWords(out, point.WordSwap)
+			val := binary.BigEndian.Uint64(out)
+			switch strings.ToUpper(point.RawType) {
+			case strings.ToUpper(ValueTypeUint64):
+				value = val
+			case strings.ToUpper(ValueTypeInt64):
+				value = int64(val)
+			case strings.ToUpper(ValueTypeFloat64):
+				value = math.Float64frombits(val)
+			}
+		case strings.ToUpper(ValueTypeString):
+			out := getBytesFromUint16s(rawValues, point.ByteSwap)
+			out = swapWords(out, point.WordSwap)
+			value = string(out)
+		default:
+			return nil, fmt.Errorf("unsupported raw type: %v", point)
+		}
+	}
+	return value, nil
+}
+
+// backoff 点位组当前的采集间隔，连续超时时每次翻倍，最大不超过一分钟
+func (group *pointGroup) backoff() time.Duration {
+	duration := group.Duration
+	if group.TimeOutCount == 0 {
+		return duration
+	}
+	for i := 0; i < group.TimeOutCount && duration < time.Minute; i++ {
+		duration *= 2
+	}
+	if duration > time.Minute {
+		duration = time.Minute
+	}
+	return duration
+}
+
 func (c *connector) resetCollectTime(group *pointGroup) {
+	c.groupMutex.Lock()
+	defer c.groupMutex.Unlock()
 	for _, device := range c.devices {
 		if device.unitID == group.UnitID {
 			for _, g := range device.pointGroup {
@@ -374,7 +387,9 @@ func getBytesFromUint16s(values []uint16, byteSwap bool) (out []byte) {
 
 func swapWords(in []byte, wordSwap bool) (out []byte) {
 	if len(in) >= 4 {
-		for i := 0; i < len(in); i += 4 {
+		//不足一个字的剩余字节保持原样，避免奇数个寄存器的字符串越界
+		i := 0
+		for ; i+4 <= len(in); i += 4 {
 			if wordSwap {
 				out = append(out, []byte{
 					in[i+2], in[i+3], in[i], in[i+1],
@@ -385,6 +400,7 @@ func swapWords(in []byte, wordSwap bool) (out []byte) {
 				}...)
 			}
 		}
+		out = append(out, in[i:]...)
 	} else {
 		out = in
 	}
@@ -421,15 +437,25 @@ func (c *connector) sendWriteCommand(pc *writeValue) error {
 
 	c.writeSemaphore.Add(1)
 	defer func() {
-		c.latestWriteTime = time.Now()
+		c.latestWriteTime.Store(time.Now().UnixNano())
 		c.writeSemaphore.Add(-1)
 	}()
+	if err := c.writeWithRetry(pc); err != nil {
+		return err
+	}
+	return c.verifyWrite(pc)
+}
+
+// writeWithRetry 写入寄存器，失败时按连接的重试次数重新写入
+func (c *connector) writeWithRetry(pc *writeValue) error {
 	var err error
 	for i := 0; i < c.config.Retry; i++ {
 		if c.virtual {
 			err = c.mockWrite(pc.unitID, pc.RegisterType, pc.Address, pc.Value)
 		} else {
+			start := time.Now()
 			err = c.write(pc)
+			metrics.ObserveIO(ProtocolName, c.config.ConnectionKey, string(plugin.WriteMode), start, err)
 		}
 		if err == nil {
 			break
@@ -490,68 +516,38 @@ func castModbusAddress(i interface{}) (address uint16, err error) {
 // read 读操作
 // 首次读取失败，将尝试重连 modbus 连接
 func (c *connector) read(slaveId uint8, registerType string, address, quantity uint16) (values []uint16, err error) {
-	err = c.openModbusClient()
+	s, err := c.acquire()
 	if err != nil {
 		return nil, err
 	}
-	defer func() { c.closeModbusClient(err) }()
-	if err = c.client.SetUnitId(slaveId); err != nil {
+	defer func() { c.release(s, err) }()
+	if err = s.client.SetUnitId(slaveId); err != nil {
 		return nil, err
 	}
-	c.ensureInterval()
+	s.ensureInterval(c.config.MinInterval)
 	switch strings.ToUpper(registerType) {
 	case string(Coil):
-		responseData, err := c.client.ReadCoils(address, quantity)
+		responseData, err := s.client.ReadCoils(address, quantity)
 		if err != nil {
 			return nil, err
 		}
 		values = boolSliceToUint16(responseData)
 	case string(DiscreteInput):
-		responseData, err := c.client.ReadDiscreteInputs(address, quantity)
+		responseData, err := s.client.ReadDiscreteInputs(address, quantity)
 		if err != nil {
 			return nil, err
 		}
 		values = boolSliceToUint16(responseData)
 	case string(InputRegister):
-		values, err = c.client.ReadRegisters(address, quantity, modbus.INPUT_REGISTER)
+		values, err = s.client.ReadRegisters(address, quantity, modbus.INPUT_REGISTER)
 	case string(HoldingRegister):
-		values, err = c.client.ReadRegisters(address, quantity, modbus.HOLDING_REGISTER)
+		values, err = s.client.ReadRegisters(address, quantity, modbus.HOLDING_REGISTER)
 	default:
 		return nil, fmt.Errorf("unsupported register type %v", registerType)
 	}
 	return
 }
 
-func (c *connector) openModbusClient() error {
-	c.mutex.Lock()
-	//modbus连接已打开
-	if c.keepAlive {
-		return nil
-	}
-	err := c.client.Open()
-	if err != nil {
-		c.mutex.Unlock()
-		driverbox.Log().Error("open modbus client error", zap.Any("modbus", c.config), zap.Error(err))
-	} else {
-		c.keepAlive = true
-	}
-	return err
-}
-
-func (c *connector) closeModbusClient(e error) {
-	defer func() {
-		c.mutex.Unlock()
-	}()
-	if e != nil {
-		driverbox.Log().Error("modbus client error, will close it", zap.Error(e))
-	}
-	//RTU 模式下，连接不关闭
-	if c.config.Mode != "rtu" || e != nil {
-		c.keepAlive = false
-		_ = c.client.Close()
-	}
-}
-
 func boolSliceToUint16(arr []bool) []uint16 {
 	if arr == nil {
 		return *new([]uint16)
@@ -584,16 +580,16 @@ func (c *connector) write(wv *writeValue) (err error) {
 	registerType := wv.RegisterType
 	address := wv.Address
 	values := wv.Value
-	err = c.openModbusClient()
+	s, err := c.acquire()
 	if err != nil {
 		return err
 	}
-	defer c.closeModbusClient(err)
-	err = c.client.SetUnitId(slaveID)
+	defer func() { c.release(s, err) }()
+	err = s.client.SetUnitId(slaveID)
 	if err != nil {
 		return
 	}
-	c.ensureInterval()
+	s.ensureInterval(c.config.MinInterval)
 	switch registerType {
 	case Coil:
 		// fix: 单线圈和多线圈采用不同的功能码
@@ -602,18 +598,18 @@ func (c *connector) write(wv *writeValue) (err error) {
 			return
 		}
 		if len(bools) == 1 {
-			return c.client.WriteCoil(address, bools[0])
+			return s.client.WriteCoil(address, bools[0])
 		}
-		return c.client.WriteCoils(address, bools)
+		return s.client.WriteCoils(address, bools)
 	case HoldingRegister:
 		// fix：单寄存器和多寄存器采用不同的功能码
 		if len(values) == 0 {
 			return
 		}
 		if len(values) == 1 && !wv.MultiWrite {
-			return c.client.WriteRegister(address, values[0])
+			return s.client.WriteRegister(address, values[0])
 		}
-		return c.client.WriteRegisters(address, values)
+		return s.client.WriteRegisters(address, values)
 	default:
 		return errors.New("unsupport write command register type")
 	}
@@ -0,0 +1,304 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// 单次诊断读写的最大数量，与 modbus 协议的单帧上限一致
+const (
+	maxDiagnoseRegisters = 125
+	maxDiagnoseBits      = 2000
+	// 写多个寄存器（0x10）的单帧上限
+	maxDiagnoseWriteRegisters = 123
+	// 写多个线圈（0x0F）的单帧上限
+	maxDiagnoseWriteBits = 1968
+)
+
+// ioError 连接最近一次通讯错误
+type ioError struct {
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// registerRequest 寄存器诊断请求
+type registerRequest struct {
+	UnitID       uint8
+	PrimaryTable primaryTable
+	Address      uint16
+	Quantity     uint16
+	// 写入的原始寄存器值，仅写操作有效
+	Values []uint16
+	// 单个保持寄存器是否采用多寄存器写接口
+	MultiWrite bool
+}
+
+// registerResult 寄存器诊断结果
+type registerResult struct {
+	UnitID       uint8        `json:"unitID"`
+	PrimaryTable primaryTable `json:"primaryTable"`
+	Address      uint16       `json:"address"`
+	Quantity     uint16       `json:"quantity"`
+	// 从机返回的原始值
+	Values []uint16 `json:"values"`
+	Hex    []string `json:"hex"`
+	// 自起始地址按各数据类型及字节序组合解析的结果
+	Decoded []decodedValue `json:"decoded,omitempty"`
+}
+
+type decodedValue struct {
+	RawType  string      `json:"rawType"`
+	ByteSwap bool        `json:"byteSwap"`
+	WordSwap bool        `json:"wordSwap"`
+	Value    interface{} `json:"value"`
+}
+
+// connectionStats 连接的运行状态
+type connectionStats struct {
+	ConnectionKey string `json:"connectionKey"`
+	PoolSize      int    `json:"poolSize"`
+	// 空闲会话数
+	IdleSessions    int          `json:"idleSessions"`
+	WriteSemaphore  int32        `json:"writeSemaphore"`
+	LatestWriteTime time.Time    `json:"latestWriteTime"`
+	LastError       *ioError     `json:"lastError"`
+	Groups          []groupStats `json:"groups"`
+}
+
+type groupStats struct {
+	UnitID       uint8        `json:"unitID"`
+	PrimaryTable primaryTable `json:"primaryTable"`
+	Address      uint16       `json:"address"`
+	Quantity     uint16       `json:"quantity"`
+	Duration     string       `json:"duration"`
+	TimeOutCount int          `json:"timeOutCount"`
+	// 超时退避后的实际采集间隔
+	Backoff    string    `json:"backoff"`
+	LatestTime time.Time `json:"latestTime"`
+}
+
+// parseRegisterRequest 解析查询参数中的从机地址、寄存器类型、起始地址及数量
+func parseRegisterRequest(r *http.Request) (registerRequest, error) {
+	var req registerRequest
+	query := r.URL.Query()
+	unitID, err := strconv.ParseUint(query.Get("unitID"), 10, 8)
+	//不允许广播地址 0，避免诊断写入作用于总线上的所有从机
+	if err != nil || unitID == 0 || unitID > 247 {
+		return req, fmt.Errorf("invalid unitID: %q, must be between 1 and 247", query.Get("unitID"))
+	}
+	req.UnitID = uint8(unitID)
+	req.PrimaryTable = primaryTable(strings.ToUpper(query.Get("primaryTable")))
+	switch req.PrimaryTable {
+	case Coil, DiscreteInput, InputRegister, HoldingRegister:
+	default:
+		return req, fmt.Errorf("invalid primaryTable: %q", query.Get("primaryTable"))
+	}
+	if req.Address, err = castModbusAddress(query.Get("address")); err != nil || query.Get("address") == "" {
+		return req, fmt.Errorf("invalid address: %q", query.Get("address"))
+	}
+	req.Quantity = 1
+	if s := query.Get("quantity"); s != "" {
+		if req.Quantity, err = cast.ToUint16E(s); err != nil || req.Quantity == 0 {
+			return req, fmt.Errorf("invalid quantity: %q", s)
+		}
+	}
+	limit := uint16(maxDiagnoseRegisters)
+	if req.PrimaryTable == Coil || req.PrimaryTable == DiscreteInput {
+		limit = maxDiagnoseBits
+	}
+	if req.Quantity > limit {
+		return req, fmt.Errorf("quantity must not exceed %d", limit)
+	}
+	return req, nil
+}
+
+// diagnoseRead 绕过物模型直接读取寄存器，与采集任务共用连接池，写操作进行中时拒绝读取
+func (c *connector) diagnoseRead(req registerRequest) (registerResult, error) {
+	result := registerResult{
+		UnitID:       req.UnitID,
+		PrimaryTable: req.PrimaryTable,
+		Address:      req.Address,
+		Quantity:     req.Quantity,
+	}
+	if c.close.Load() {
+		return result, errConnectionClosed
+	}
+	if c.writeSemaphore.Load() > 0 {
+		return result, errors.New("modbus connection is writing, try again later")
+	}
+	var err error
+	if c.virtual {
+		result.Values, err = c.mockRead(req.UnitID, string(req.PrimaryTable), req.Address, req.Quantity)
+	} else {
+		result.Values, err = c.read(req.UnitID, string(req.PrimaryTable), req.Address, req.Quantity)
+	}
+	if err != nil {
+		return result, err
+	}
+	result.Hex = make([]string, 0, len(result.Values))
+	for _, v := range result.Values {
+		result.Hex = append(result.Hex, fmt.Sprintf("0x%04X", v))
+	}
+	if req.PrimaryTable == InputRegister || req.PrimaryTable == HoldingRegister {
+		result.Decoded = decodeAll(result.Values)
+	}
+	return result, nil
+}
+
+// diagnoseWrite 绕过物模型直接写入寄存器，写入期间采集任务避让，写入后回读寄存器
+func (c *connector) diagnoseWrite(req registerRequest) (registerResult, error) {
+	if c.close.Load() {
+		return registerResult{}, errConnectionClosed
+	}
+	if req.PrimaryTable != Coil && req.PrimaryTable != HoldingRegister {
+		return registerResult{}, fmt.Errorf("%s is read only", req.PrimaryTable)
+	}
+	limit := maxDiagnoseWriteRegisters
+	if req.PrimaryTable == Coil {
+		limit = maxDiagnoseWriteBits
+	}
+	if len(req.Values) == 0 || len(req.Values) > limit {
+		return registerResult{}, fmt.Errorf("values length must be between 1 and %d", limit)
+	}
+	c.writeEncodeMu.Lock()
+	err := c.sendWriteCommand(&writeValue{
+		unitID:       req.UnitID,
+		RegisterType: req.PrimaryTable,
+		Address:      req.Address,
+		Value:        req.Values,
+		MultiWrite:   req.MultiWrite,
+	})
+	c.writeEncodeMu.Unlock()
+	if err != nil {
+		return registerResult{}, err
+	}
+	req.Quantity = uint16(len(req.Values))
+	result, err := c.diagnoseRead(req)
+	if err != nil {
+		return result, fmt.Errorf("write success, read back error: %v", err)
+	}
+	return result, nil
+}
+
+// decodeAll 按各数据类型及字节序组合解析寄存器，寄存器数量不足的数据类型不解析
+func decodeAll(values []uint16) []decodedValue {
+	decoded := make([]decodedValue, 0)
+	for _, rawType := range registerRawTypes {
+		var quantity int
+		switch rawType {
+		case ValueTypeUint16, ValueTypeInt16:
+			quantity = 1
+		case ValueTypeUint32, ValueTypeInt32, ValueTypeFloat32:
+			quantity = 2
+		case ValueTypeUint64, ValueTypeInt64, ValueTypeFloat64:
+			quantity = 4
+		default:
+			quantity = len(values)
+		}
+		if quantity == 0 || quantity > len(values) {
+			continue
+		}
+		for _, byteSwap := range []bool{false, true} {
+			for _, wordSwap := range []bool{false, true} {
+				//单寄存器不涉及字交换
+				if wordSwap && quantity < 2 {
+					continue
+				}
+				point := &Point{
+					RegisterType: HoldingRegister,
+					RawType:      rawType,
+					Quantity:     uint16(quantity),
+					ByteSwap:     byteSwap,
+					WordSwap:     wordSwap,
+				}
+				value, err := decodePoint(point, values[:quantity])
+				if err != nil {
+					continue
+				}
+				decoded = append(decoded, decodedValue{
+					RawType:  rawType,
+					ByteSwap: byteSwap,
+					WordSwap: wordSwap,
+					Value:    jsonSafe(value),
+				})
+			}
+		}
+	}
+	return decoded
+}
+
+// jsonSafe NaN 及无穷大无法序列化为 JSON，以字符串表示
+func jsonSafe(value interface{}) interface{} {
+	var f float64
+	switch v := value.(type) {
+	case float32:
+		f = float64(v)
+	case float64:
+		f = v
+	default:
+		return value
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Sprint(f)
+	}
+	return value
+}
+
+// stats 连接的运行状态及各点位组的超时退避情况
+func (c *connector) stats() connectionStats {
+	stats := connectionStats{
+		ConnectionKey:  c.config.ConnectionKey,
+		PoolSize:       len(c.sessions),
+		IdleSessions:   len(c.pool),
+		WriteSemaphore: c.writeSemaphore.Load(),
+		LastError:      c.lastError.Load(),
+		Groups:         make([]groupStats, 0),
+	}
+	if t := c.latestWriteTime.Load(); t > 0 {
+		stats.LatestWriteTime = time.Unix(0, t)
+	}
+	c.groupMutex.RLock()
+	defer c.groupMutex.RUnlock()
+	for _, unitID := range c.unitIDs() {
+		for _, group := range c.devices[unitID].pointGroup {
+			stats.Groups = append(stats.Groups, groupStats{
+				UnitID:       group.UnitID,
+				PrimaryTable: group.RegisterType,
+				Address:      group.Address,
+				Quantity:     group.Quantity,
+				Duration:     group.Duration.String(),
+				TimeOutCount: group.TimeOutCount,
+				Backoff:      group.backoff().String(),
+				LatestTime:   group.LatestTime,
+			})
+		}
+	}
+	return stats
+}
+
+// readRegisterBody 解析写操作的请求体
+func readRegisterBody(r *http.Request, req *registerRequest) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+	var data struct {
+		Values     []uint16 `json:"values"`
+		MultiWrite bool     `json:"multiWrite"`
+	}
+	if err = json.Unmarshal(body, &data); err != nil {
+		return err
+	}
+	req.Values, req.MultiWrite = data.Values, data.MultiWrite
+	return nil
+}
@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDecodeAll(t *testing.T) {
+	bits := math.Float32bits(1.5)
+	//寄存器按写入编码的顺序排列：低位字在前
+	decoded := decodeAll([]uint16{uint16(bits), uint16(bits >> 16), 0x4142})
+	found := false
+	for _, d := range decoded {
+		if d.RawType == ValueTypeFloat32 && !d.ByteSwap && !d.WordSwap {
+			found = d.Value == float32(1.5)
+		}
+		if d.RawType == ValueTypeUint16 && d.WordSwap {
+			t.Fatal("single register should not be word swapped")
+		}
+		if d.RawType == ValueTypeFloat64 {
+			t.Fatal("float64 requires 4 registers")
+		}
+	}
+	if !found {
+		t.Fatalf("float32 not decoded: %+v", decoded)
+	}
+	if v := jsonSafe(float32(math.NaN())); v != "NaN" {
+		t.Fatalf("jsonSafe = %v", v)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	group := &pointGroup{Duration: time.Second}
+	if group.backoff() != time.Second {
+		t.Fatalf("backoff = %s", group.backoff())
+	}
+	group.TimeOutCount = 3
+	if group.backoff() != 8*time.Second {
+		t.Fatalf("backoff = %s", group.backoff())
+	}
+	//超时次数较大时不溢出，最大一分钟
+	group.TimeOutCount = 100
+	if group.backoff() != time.Minute {
+		t.Fatalf("backoff = %s", group.backoff())
+	}
+}
+
+func TestDiagnoseWriteLimit(t *testing.T) {
+	c := &connector{}
+	//写多个寄存器的单帧上限小于读取上限
+	req := registerRequest{PrimaryTable: HoldingRegister, Values: make([]uint16, maxDiagnoseWriteRegisters+1)}
+	if _, err := c.diagnoseWrite(req); err == nil {
+		t.Fatal("values exceed write limit should be rejected")
+	}
+	req = registerRequest{PrimaryTable: InputRegister, Values: []uint16{1}}
+	if _, err := c.diagnoseWrite(req); err == nil {
+		t.Fatal("input register should be read only")
+	}
+}
+
+func TestParseRegisterRequest(t *testing.T) {
+	for unitID, valid := range map[string]bool{"0": false, "1": true, "247": true, "248": false} {
+		r := httptest.NewRequest(http.MethodGet, "/?primaryTable=HOLDING_REGISTER&address=0&unitID="+unitID, nil)
+		if _, err := parseRegisterRequest(r); (err == nil) != valid {
+			t.Errorf("unitID %s: err = %v", unitID, err)
+		}
+	}
+}
@@ -55,10 +55,26 @@ type ConnectionConfig struct {
 	StopBits      uint   `json:"stopBits"`      // 停止位（仅串口模式）
 	Parity        uint   `json:"parity"`        // 奇偶性校验（仅串口模式）
 	BatchReadLen  uint16 `json:"batchReadLen"`  // 最长连续读个数
+	MaxGap        *int   `json:"maxGap"`        // 合并读取时相邻点位允许的最大地址间隔，未设置时不限制
 	BatchWriteLen uint16 `json:"batchWriteLen"` // 支持连续写的最大长度
 	MinInterval   uint16 `json:"minInterval"`   // 最小读取间隔
 	Timeout       uint16 `json:"timeout"`       // 请求超时
 	Retry         int    `json:"retry"`         // 重试次数
+	PoolSize      int    `json:"poolSize"`      // 并发会话数（仅 tcp 模式），默认 1
+	KeepAlive     bool   `json:"keepAlive"`     // 通讯后保持连接（tcp 模式），rtu 模式始终保持
+	// 设备扫描配置
+	Discover *DiscoverConfig `json:"discover"`
+}
+
+// DiscoverConfig 设备扫描配置
+type DiscoverConfig struct {
+	Enable bool `json:"enable"`
+	// 扫描的从机地址范围，如 "1-32,40"，默认 1-247
+	UnitIDs string `json:"unitIDs"`
+	// 扫描周期，如 "24h"，为空时仅在启动后扫描一次
+	Duration string `json:"duration"`
+	// 发现设备的ID前缀，设备ID为前缀加从机地址，默认为 {connectionKey}_
+	DeviceIDPrefix string `json:"deviceIdPrefix"`
 }
 
 // Point modbus点位
@@ -69,6 +85,7 @@ type Point struct {
 
 	//点位采集周期
 	Duration     string `json:"duration"`
+	interval     time.Duration
 	Address      uint16
 	RegisterType primaryTable `json:"primaryTable"`
 	//该配置无需设置
@@ -80,12 +97,16 @@ type Point struct {
 	WordSwap bool   `json:"wordSwap"`
 	//写操作是否强制要求多寄存器写接口。某些设备点位虽然只占据一个寄存器地址，但要求采用多寄存器写接口
 	MultiWrite bool `json:"multiWrite"`
+	//写入回读校验时允许的数值误差，按寄存器原始值比较
+	Tolerance float64 `json:"tolerance"`
 }
 
 // 采集组
 type slaveDevice struct {
 	// 通讯设备，采集点位可以对应多个物模型设备
 	unitID uint8
+	//采集点位
+	points []*Point
 	//分组
 	pointGroup []*pointGroup
 }
@@ -125,4 +146,6 @@ type writeValue struct {
 	//写操作是否强制要求多寄存器写接口
 	MultiWrite   bool
 	RegisterType primaryTable `json:"primaryTable"`
+	//写入后需回读校验的点位
+	checks []*writeCheck
 }
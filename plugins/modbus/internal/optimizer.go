@@ -0,0 +1,164 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ibuilding-x/driver-box/v2/driverbox"
+	"go.uber.org/zap"
+)
+
+// buildPointGroups 为连接下的全部通讯设备生成读取块
+func (c *connector) buildPointGroups() {
+	c.groupMutex.Lock()
+	defer c.groupMutex.Unlock()
+	for _, device := range c.devices {
+		device.pointGroup = c.groupPoints(device)
+	}
+}
+
+// pointGroups 通讯设备当前的点位组
+func (c *connector) pointGroups(device *slaveDevice) []*pointGroup {
+	c.groupMutex.RLock()
+	defer c.groupMutex.RUnlock()
+	return device.pointGroup
+}
+
+// groupPoints 将通讯设备的采集点位按寄存器类型、采集周期分类后按地址排序，
+// 相邻点位在不超过 batchReadLen、maxGap 以及已知拆分边界的前提下合并为同一读取块
+func (c *connector) groupPoints(device *slaveDevice) []*pointGroup {
+	points := make([]*Point, len(device.points))
+	copy(points, device.points)
+	sort.SliceStable(points, func(i, j int) bool {
+		if points[i].RegisterType != points[j].RegisterType {
+			return points[i].RegisterType < points[j].RegisterType
+		}
+		if points[i].interval != points[j].interval {
+			return points[i].interval < points[j].interval
+		}
+		return points[i].Address < points[j].Address
+	})
+
+	groups := make([]*pointGroup, 0)
+	var group *pointGroup
+	for _, point := range points {
+		if group != nil && c.mergeable(group, point) {
+			end := int(point.Address) + int(point.Quantity)
+			if end > int(group.Address)+int(group.Quantity) {
+				group.Quantity = uint16(end - int(group.Address))
+			}
+			group.Points = append(group.Points, point)
+			continue
+		}
+		group = &pointGroup{
+			index:        len(groups),
+			UnitID:       device.unitID,
+			Duration:     point.interval,
+			RegisterType: point.RegisterType,
+			Address:      point.Address,
+			Quantity:     point.Quantity,
+			Points:       []*Point{point},
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// mergeable 点位能否并入读取块，点位地址不小于读取块起始地址
+func (c *connector) mergeable(group *pointGroup, point *Point) bool {
+	if group.Duration != point.interval || group.RegisterType != point.RegisterType {
+		return false
+	}
+	start := int(group.Address)
+	end := start + int(group.Quantity)
+	if e := int(point.Address) + int(point.Quantity); e > end {
+		end = e
+	}
+	//超过最大连续读个数
+	if end-start > int(c.config.BatchReadLen) {
+		return false
+	}
+	//地址间隔过大，避免读取大段无效寄存器
+	gap := int(point.Address) - (int(group.Address) + int(group.Quantity))
+	if c.config.MaxGap != nil && gap > *c.config.MaxGap {
+		return false
+	}
+	//读取块不可跨越从机拒绝访问的边界
+	for _, boundary := range c.plugin.getSplits(c.splitKey(group.UnitID, group.RegisterType)) {
+		if start < int(boundary) && end > int(boundary) {
+			return false
+		}
+	}
+	return true
+}
+
+// bisect 读取块因非法数据地址被从机拒绝时，在点位间隙处对半拆分并记住拆分边界，
+// 返回 false 表示读取块无法继续拆分
+func (c *connector) bisect(device *slaveDevice, group *pointGroup) bool {
+	points := group.Points
+	if len(points) < 2 {
+		return false
+	}
+	//寻找最接近中间位置且不截断点位的拆分点
+	split := -1
+	maxEnd := 0
+	for i := 1; i < len(points); i++ {
+		if e := int(points[i-1].Address) + int(points[i-1].Quantity); e > maxEnd {
+			maxEnd = e
+		}
+		if maxEnd > int(points[i].Address) {
+			continue
+		}
+		if split < 0 || abs(i-len(points)/2) < abs(split-len(points)/2) {
+			split = i
+		}
+	}
+	if split < 0 {
+		return false
+	}
+	boundary := points[split].Address
+	c.plugin.addSplit(c.splitKey(group.UnitID, group.RegisterType), boundary)
+	driverbox.Log().Warn("modbus read block rejected by slave, split it", zap.String("key", c.config.ConnectionKey),
+		zap.Uint8("unitID", group.UnitID), zap.String("primaryTable", string(group.RegisterType)),
+		zap.Uint16("address", group.Address), zap.Uint16("quantity", group.Quantity), zap.Uint16("boundary", boundary))
+	groups := c.groupPoints(device)
+	c.groupMutex.Lock()
+	device.pointGroup = groups
+	c.groupMutex.Unlock()
+	return true
+}
+
+func (c *connector) splitKey(unitID uint8, registerType primaryTable) string {
+	return fmt.Sprintf("%s/%d/%s", c.config.ConnectionKey, unitID, registerType)
+}
+
+// getSplits 读取块的拆分边界
+func (p *Plugin) getSplits(key string) []uint16 {
+	p.splitMutex.Lock()
+	defer p.splitMutex.Unlock()
+	return p.splits[key]
+}
+
+// addSplit 记录读取块的拆分边界，连接重启后依然生效
+func (p *Plugin) addSplit(key string, boundary uint16) {
+	p.splitMutex.Lock()
+	defer p.splitMutex.Unlock()
+	if p.splits == nil {
+		p.splits = make(map[string][]uint16)
+	}
+	for _, b := range p.splits[key] {
+		if b == boundary {
+			return
+		}
+	}
+	boundaries := append(append([]uint16{}, p.splits[key]...), boundary)
+	sort.Slice(boundaries, func(i, j int) bool { return boundaries[i] < boundaries[j] })
+	p.splits[key] = boundaries
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ibuilding-x/driver-box/v2/internal/logger"
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+	"go.uber.org/zap"
+)
+
+func newTestPoint(name string, table primaryTable, address, quantity uint16) *Point {
+	return &Point{
+		Point:        config.Point{"name": name},
+		DeviceId:     "d1",
+		interval:     time.Second,
+		RegisterType: table,
+		Address:      address,
+		Quantity:     quantity,
+	}
+}
+
+func layoutOf(groups []*pointGroup) [][2]uint16 {
+	result := make([][2]uint16, 0, len(groups))
+	for _, g := range groups {
+		result = append(result, [2]uint16{g.Address, g.Quantity})
+	}
+	return result
+}
+
+func TestGroupPoints(t *testing.T) {
+	logger.Logger = zap.NewNop()
+	maxGap := 4
+	c := &connector{
+		config: &ConnectionConfig{BatchReadLen: 32, MaxGap: &maxGap},
+		plugin: &Plugin{},
+	}
+	c.config.ConnectionKey = "line1"
+	device := &slaveDevice{unitID: 1, points: []*Point{
+		newTestPoint("p4", HoldingRegister, 20, 2),
+		newTestPoint("p1", HoldingRegister, 0, 1),
+		newTestPoint("p2", HoldingRegister, 1, 2),
+		newTestPoint("p3", HoldingRegister, 7, 1),
+		newTestPoint("p5", InputRegister, 0, 1),
+	}}
+	//0-2 与 7 间隔 4，20 与 7 间隔 12，输入寄存器单独成组
+	groups := c.groupPoints(device)
+	if got := layoutOf(groups); len(got) != 3 || got[0] != [2]uint16{0, 8} || got[1] != [2]uint16{20, 2} || got[2] != [2]uint16{0, 1} {
+		t.Fatalf("layout = %v", got)
+	}
+	for i, g := range groups {
+		if g.index != i {
+			t.Errorf("group %d index = %d", i, g.index)
+		}
+	}
+
+	//非法数据地址时对半拆分，拆分边界对重新分组生效
+	device.pointGroup = groups
+	if !c.bisect(device, groups[0]) {
+		t.Fatal("bisect failed")
+	}
+	if got := layoutOf(device.pointGroup); len(got) != 4 || got[0] != [2]uint16{0, 1} || got[1] != [2]uint16{1, 7} {
+		t.Fatalf("bisected layout = %v", got)
+	}
+	if got := c.plugin.getSplits("line1/1/" + string(HoldingRegister)); len(got) != 1 || got[0] != 1 {
+		t.Fatalf("splits = %v", got)
+	}
+	//单点位无法继续拆分
+	if c.bisect(device, device.pointGroup[0]) {
+		t.Fatal("single point group should not be bisected")
+	}
+}
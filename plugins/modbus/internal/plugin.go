@@ -12,7 +12,6 @@ import (
 	"github.com/ibuilding-x/driver-box/v2/pkg/convutil"
 	"github.com/ibuilding-x/driver-box/v2/pkg/crontab"
 	"github.com/ibuilding-x/driver-box/v2/pkg/luautil"
-	"github.com/simonvetter/modbus"
 	"go.uber.org/zap"
 )
 
@@ -22,38 +21,54 @@ const ProtocolName = "modbus"
 type Plugin struct {
 	connPool map[string]*connector // 连接器
 	config   config.DeviceConfig
+	mutex    sync.RWMutex
+	//各连接最近一次设备扫描的时间
+	scanTimes sync.Map
+	//读取块的拆分边界，key 为 {connectionKey}/{unitID}/{primaryTable}
+	splits     map[string][]uint16
+	splitMutex sync.Mutex
 }
 
 // connector 连接器
 type connector struct {
 	config *ConnectionConfig
 	plugin *Plugin
-	client *modbus.ModbusClient
-	//串口保持打开状态
-	keepAlive    bool
-	latestIoTime time.Time // 最近一次执行IO的时间
-	mutex        sync.Mutex
+	//连接池，空闲的会话
+	pool     chan *session
+	sessions []*session
 	//通讯设备集合
 	retry uint8
 
 	devices map[uint8]*slaveDevice
 	//当前连接的定时扫描任务
 	collectTask *crontab.Future
+	//设备扫描任务
+	scanTask *crontab.Future
 	//当前连接是否已关闭
-	close bool
+	close atomic.Bool
+	//连接关闭时关闭该通道，唤醒等待会话的请求
+	done chan struct{}
+	//保护连接池的归还与关闭，连接关闭后归还的会话直接关闭
+	poolMutex sync.Mutex
+	//保护各通讯设备的点位组，读取块被拒绝时会拆分并替换点位组
+	groupMutex sync.RWMutex
 	//是否虚拟链接
 	virtual bool
 
 	//写操作信号量
 	writeSemaphore  atomic.Int32
-	latestWriteTime time.Time //最近一次写操作时间
+	latestWriteTime atomic.Int64 //最近一次写操作时间，UnixNano
 
 	writeEncodeMu sync.Mutex
+
+	//最近一次通讯错误
+	lastError atomic.Pointer[ioError]
 }
 
 // Initialize 插件初始化
 func (p *Plugin) Initialize(c config.DeviceConfig) {
 	p.config = c
+	p.registerApi()
 	//初始化连接池
 	p.initNetworks(c)
 
@@ -61,63 +76,98 @@ func (p *Plugin) Initialize(c config.DeviceConfig) {
 
 // 初始化Modbus连接池
 func (p *Plugin) initNetworks(config config.DeviceConfig) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
 	p.connPool = make(map[string]*connector)
 	//某个连接配置有问题，不影响其他连接的建立
 	for key, connConfig := range config.Connections {
-		connectionConfig := new(ConnectionConfig)
-		if err := convutil.Struct(connConfig, connectionConfig); err != nil {
-			driverbox.Log().Error("convert connector config error", zap.Any("connection", connConfig), zap.Error(err))
-			continue
-		}
-		conn, err := newConnector(p, connectionConfig)
-		conn.config.ConnectionKey = key
-		if err != nil {
-			driverbox.Log().Error("init connector error", zap.Any("connection", connConfig), zap.Error(err))
-			continue
+		p.initConnection(config, key, connConfig)
+	}
+}
+
+// ReloadConnections 重启指定连接，其他连接的采集任务不受影响
+func (p *Plugin) ReloadConnections(c config.DeviceConfig, keys []string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.config = c
+	if p.connPool == nil {
+		p.connPool = make(map[string]*connector)
+	}
+	for _, key := range keys {
+		if conn, ok := p.connPool[key]; ok {
+			conn.Close()
+			delete(p.connPool, key)
 		}
-		if conn.virtual {
-			InitMockLua()
+		if connConfig, ok := c.Connections[key]; ok {
+			p.initConnection(c, key, connConfig)
 		}
+	}
+	return nil
+}
 
-		//生成点位采集组
-		for _, model := range config.DeviceModels {
-			//如果模型不存在关联设备,清理该模型
-			if len(model.Devices) == 0 {
-				e := driverbox.CoreCache().DeleteModel(model.Name)
-				if e != nil {
-					driverbox.Log().Error("delete model error", zap.Any("model", model), zap.Error(e))
-				} else {
-					driverbox.Log().Warn("delete idle model", zap.Any("model", model))
-				}
-				continue
-			}
-			for _, dev := range model.Devices {
-				if dev.ConnectionKey != conn.config.ConnectionKey {
-					continue
-				}
-				conn.createPointGroup(connectionConfig, model, dev)
-			}
-		}
+// initConnection 初始化单个连接并启动采集任务
+func (p *Plugin) initConnection(config config.DeviceConfig, key string, connConfig interface{}) {
+	connectionConfig := new(ConnectionConfig)
+	if err := convutil.Struct(connConfig, connectionConfig); err != nil {
+		driverbox.Log().Error("convert connector config error", zap.Any("connection", connConfig), zap.Error(err))
+		return
+	}
+	conn, err := newConnector(p, connectionConfig)
+	conn.config.ConnectionKey = key
+	if err != nil {
+		driverbox.Log().Error("init connector error", zap.Any("connection", connConfig), zap.Error(err))
+		return
+	}
+	if conn.virtual {
+		InitMockLua()
+	}
 
-		if len(conn.devices) == 0 {
-			err = driverbox.CoreCache().DeleteConnection(conn.config.ConnectionKey)
-			if err != nil {
-				driverbox.Log().Error("delete connection error", zap.Any("connection", connConfig), zap.Error(err))
+	//生成点位采集组
+	for _, model := range config.DeviceModels {
+		//如果模型不存在关联设备,清理该模型
+		if len(model.Devices) == 0 {
+			e := driverbox.CoreCache().WithSource(ProtocolName).DeleteModel(model.Name)
+			if e != nil {
+				driverbox.Log().Error("delete model error", zap.Any("model", model), zap.Error(e))
 			} else {
-				driverbox.Log().Warn("delete idle connection", zap.Any("connection", connConfig))
+				driverbox.Log().Warn("delete idle model", zap.Any("model", model))
 			}
 			continue
 		}
-		if !connectionConfig.Enable {
-			driverbox.Log().Warn("modbus connection is disabled, ignore collect task", zap.String("key", key))
-			continue
+		for _, dev := range model.Devices {
+			if dev.ConnectionKey != conn.config.ConnectionKey {
+				continue
+			}
+			conn.createPointGroup(connectionConfig, model, dev)
 		}
+	}
+	conn.buildPointGroups()
 
-		//启动采集任务
-		conn.collectTask, err = conn.initCollectTask(connectionConfig)
-		p.connPool[key] = conn
+	discover := connectionConfig.Discover != nil && connectionConfig.Discover.Enable && !conn.virtual
+	//开启设备扫描的连接即使暂无设备也需保留
+	if len(conn.devices) == 0 && !discover {
+		err = driverbox.CoreCache().WithSource(ProtocolName).DeleteConnection(conn.config.ConnectionKey)
 		if err != nil {
-			driverbox.Log().Error("init connector collect task error", zap.Any("connection", connConfig), zap.Error(err))
+			driverbox.Log().Error("delete connection error", zap.Any("connection", connConfig), zap.Error(err))
+		} else {
+			driverbox.Log().Warn("delete idle connection", zap.Any("connection", connConfig))
+		}
+		return
+	}
+	if !connectionConfig.Enable {
+		driverbox.Log().Warn("modbus connection is disabled, ignore collect task", zap.String("key", key))
+		return
+	}
+
+	//启动采集任务
+	conn.collectTask, err = conn.initCollectTask(connectionConfig)
+	p.connPool[key] = conn
+	if err != nil {
+		driverbox.Log().Error("init connector collect task error", zap.Any("connection", connConfig), zap.Error(err))
+	}
+	if discover {
+		if err = conn.initScanTask(connectionConfig.Discover); err != nil {
+			driverbox.Log().Error("init connector scan task error", zap.Any("connection", connConfig), zap.Error(err))
 		}
 	}
 }
@@ -129,7 +179,9 @@ func (p *Plugin) Connector(deviceId string) (conn plugin.Connector, err error) {
 	if !ok {
 		return nil, errors.New("not found device connection key")
 	}
+	p.mutex.RLock()
 	c, ok := p.connPool[device.ConnectionKey]
+	p.mutex.RUnlock()
 	if !ok {
 		driverbox.Log().Error("not found connection key, key is ", zap.String("key", device.ConnectionKey), zap.Any("connections", p.connPool))
 		return nil, errors.New("not found connection key, key is " + device.ConnectionKey)
@@ -139,9 +191,11 @@ func (p *Plugin) Connector(deviceId string) (conn plugin.Connector, err error) {
 
 // Destroy 销毁驱动插件
 func (p *Plugin) Destroy() error {
+	p.mutex.RLock()
 	for _, conn := range p.connPool {
 		conn.Close()
 	}
+	p.mutex.RUnlock()
 	if ls != nil {
 		luautil.Close(ls)
 		ls = nil
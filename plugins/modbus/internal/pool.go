@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ibuilding-x/driver-box/v2/driverbox"
+	"github.com/simonvetter/modbus"
+	"go.uber.org/zap"
+)
+
+var errConnectionClosed = errors.New("modbus connection is closed")
+
+// session 连接池中的一条通讯会话，同一时刻仅被一个请求占用
+type session struct {
+	client *modbus.ModbusClient
+	//连接已打开
+	opened       bool
+	latestIoTime time.Time // 最近一次执行IO的时间
+}
+
+// newSessions 创建连接池，仅 tcp 模式支持多个会话，其他模式的通讯需串行
+func newSessions(cf *ConnectionConfig) ([]*session, error) {
+	if cf.PoolSize <= 0 || cf.Mode != "tcp" {
+		cf.PoolSize = 1
+	}
+	sessions := make([]*session, 0, cf.PoolSize)
+	for i := 0; i < cf.PoolSize; i++ {
+		client, err := modbus.NewClient(&modbus.ClientConfiguration{
+			URL:      fmt.Sprintf("%s://%s", cf.Mode, cf.Address),
+			Speed:    cf.BaudRate,
+			DataBits: cf.DataBits,
+			Parity:   cf.Parity,
+			StopBits: cf.StopBits,
+			Timeout:  time.Duration(cf.Timeout) * time.Millisecond,
+		})
+		if err != nil {
+			return sessions, err
+		}
+		sessions = append(sessions, &session{client: client})
+	}
+	return sessions, nil
+}
+
+// acquire 从连接池获取会话并确保连接已打开，使用完毕后需调用 release 归还
+// 连接关闭后等待中的请求立即返回错误
+func (c *connector) acquire() (*session, error) {
+	var s *session
+	select {
+	case s = <-c.pool:
+	case <-c.done:
+		return nil, errConnectionClosed
+	}
+	if s.opened {
+		return s, nil
+	}
+	if err := s.client.Open(); err != nil {
+		c.put(s)
+		c.lastError.Store(&ioError{Message: err.Error(), Time: time.Now()})
+		driverbox.Log().Error("open modbus client error", zap.Any("modbus", c.config), zap.Error(err))
+		return nil, err
+	}
+	s.opened = true
+	return s, nil
+}
+
+// release 归还会话，发生错误时关闭连接；rtu 模式及开启 keepAlive 的连接保持打开，其他模式每次通讯后关闭
+func (c *connector) release(s *session, e error) {
+	if e != nil {
+		driverbox.Log().Error("modbus client error, will close it", zap.Error(e))
+		c.lastError.Store(&ioError{Message: e.Error(), Time: time.Now()})
+	}
+	if e != nil || (c.config.Mode != "rtu" && !c.config.KeepAlive) {
+		s.close()
+	}
+	c.put(s)
+}
+
+// put 将会话放回连接池，连接已关闭时关闭会话
+func (c *connector) put(s *session) {
+	c.poolMutex.Lock()
+	defer c.poolMutex.Unlock()
+	if c.close.Load() {
+		s.close()
+		return
+	}
+	c.pool <- s
+}
+
+// close 关闭会话的连接
+func (s *session) close() {
+	if s.opened {
+		s.opened = false
+		_ = s.client.Close()
+	}
+}
+
+// ensureInterval 确保与该会话前一次IO至少间隔minInterval毫秒
+func (s *session) ensureInterval(minInterval uint16) {
+	np := s.latestIoTime.Add(time.Duration(minInterval) * time.Millisecond)
+	if time.Now().Before(np) {
+		time.Sleep(time.Until(np))
+	}
+	s.latestIoTime = time.Now()
+}
@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewSessions(t *testing.T) {
+	cf := &ConnectionConfig{Mode: "tcp", Address: "127.0.0.1:502", PoolSize: 3}
+	sessions, err := newSessions(cf)
+	if err != nil || len(sessions) != 3 {
+		t.Fatalf("tcp sessions = %d, %v", len(sessions), err)
+	}
+	//串口通讯仅支持单会话
+	cf = &ConnectionConfig{Mode: "rtuovertcp", Address: "127.0.0.1:502", PoolSize: 3}
+	if sessions, err = newSessions(cf); err != nil || len(sessions) != 1 || cf.PoolSize != 1 {
+		t.Fatalf("rtuovertcp sessions = %d, %v", len(sessions), err)
+	}
+}
+
+func TestPoolClose(t *testing.T) {
+	c, err := newConnector(&Plugin{}, &ConnectionConfig{Mode: "tcp", Address: "127.0.0.1:502", PoolSize: 2, KeepAlive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	//模拟使用中的会话
+	s := <-c.pool
+	s.opened = true
+	c.Close()
+	if len(c.pool) != 0 {
+		t.Fatalf("idle sessions = %d", len(c.pool))
+	}
+	//连接关闭后归还的会话直接关闭，不再放回连接池
+	c.release(s, nil)
+	if s.opened || len(c.pool) != 0 {
+		t.Fatalf("session opened = %v, idle sessions = %d", s.opened, len(c.pool))
+	}
+	//连接关闭后获取会话不再阻塞
+	done := make(chan error)
+	go func() {
+		_, err := c.acquire()
+		done <- err
+	}()
+	select {
+	case err = <-done:
+		if err != errConnectionClosed {
+			t.Fatalf("acquire err = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("acquire blocked after close")
+	}
+	c.Close()
+}
@@ -0,0 +1,294 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ibuilding-x/driver-box/v2/driverbox"
+	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
+	"github.com/ibuilding-x/driver-box/v2/pkg/convutil"
+	"github.com/ibuilding-x/driver-box/v2/pkg/event"
+	"github.com/ibuilding-x/driver-box/v2/pkg/library"
+	"github.com/simonvetter/modbus"
+	"go.uber.org/zap"
+)
+
+// FingerprintAttribute 物模型库中声明 modbus 设备指纹的扩展属性
+const FingerprintAttribute = "modbusFingerprint"
+
+// fingerprint 设备指纹，扫描时读取指定寄存器并与期望值比对，全部条件满足时识别为该物模型
+type fingerprint struct {
+	RegisterType primaryTable `json:"primaryTable"`
+	StartAddress interface{}  `json:"startAddress"`
+	// 期望的寄存器值，按顺序逐个比对
+	Values []uint16 `json:"values"`
+	// 期望的字符串，寄存器按高字节在前解析，忽略末尾的空字符和空格
+	Text string `json:"text"`
+
+	address  uint16
+	quantity uint16
+}
+
+// fingerprintModel 声明了设备指纹的物模型
+type fingerprintModel struct {
+	modelKey     string
+	description  string
+	fingerprints []fingerprint
+}
+
+// parseUnitIDs 解析从机地址范围，如 "1-32,40"，为空时扫描 1-247
+func parseUnitIDs(s string) ([]uint8, error) {
+	if strings.TrimSpace(s) == "" {
+		s = "1-247"
+	}
+	exists := make(map[uint8]bool)
+	unitIDs := make([]uint8, 0)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		begin, end, found := strings.Cut(item, "-")
+		if !found {
+			end = begin
+		}
+		from, err := strconv.ParseUint(strings.TrimSpace(begin), 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid unitIDs %q: %v", item, err)
+		}
+		to, err := strconv.ParseUint(strings.TrimSpace(end), 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid unitIDs %q: %v", item, err)
+		}
+		if from == 0 || from > to || to > 247 {
+			return nil, fmt.Errorf("invalid unitIDs %q, must be between 1 and 247", item)
+		}
+		for id := from; id <= to; id++ {
+			if !exists[uint8(id)] {
+				exists[uint8(id)] = true
+				unitIDs = append(unitIDs, uint8(id))
+			}
+		}
+	}
+	return unitIDs, nil
+}
+
+// parseFingerprints 解析物模型扩展属性中的设备指纹
+func parseFingerprints(value interface{}) ([]fingerprint, error) {
+	var fingerprints []fingerprint
+	if err := convutil.Struct(value, &fingerprints); err != nil {
+		return nil, err
+	}
+	if len(fingerprints) == 0 {
+		return nil, errors.New("fingerprint is empty")
+	}
+	for i := range fingerprints {
+		f := &fingerprints[i]
+		address, err := castModbusAddress(f.StartAddress)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fingerprint startAddress %v: %v", f.StartAddress, err)
+		}
+		f.address = address
+		switch {
+		case len(f.Values) > 0:
+			f.quantity = uint16(len(f.Values))
+		case f.Text != "":
+			f.quantity = uint16((len(f.Text) + 1) / 2)
+		default:
+			return nil, errors.New("fingerprint values or text is required")
+		}
+		switch f.RegisterType {
+		case Coil, DiscreteInput:
+			if f.Text != "" {
+				return nil, fmt.Errorf("text fingerprint is not supported by %s", f.RegisterType)
+			}
+		case InputRegister, HoldingRegister:
+		default:
+			return nil, fmt.Errorf("unsupported fingerprint primaryTable: %s", f.RegisterType)
+		}
+	}
+	return fingerprints, nil
+}
+
+// match 比对读取到的寄存器值
+func (f fingerprint) match(values []uint16) bool {
+	if len(values) != int(f.quantity) {
+		return false
+	}
+	if len(f.Values) > 0 {
+		for i, v := range f.Values {
+			if values[i] != v {
+				return false
+			}
+		}
+		return true
+	}
+	text := strings.TrimRight(string(getBytesFromUint16s(values, false)), "\x00 ")
+	return text == f.Text
+}
+
+// loadFingerprintModels 加载物模型库中声明了设备指纹的模型，按 modelKey 排序
+func loadFingerprintModels() []fingerprintModel {
+	keys := library.Model().ListModels()
+	sort.Strings(keys)
+	models := make([]fingerprintModel, 0)
+	for _, key := range keys {
+		model, err := library.Model().LoadLibrary(key)
+		if err != nil {
+			driverbox.Log().Error("load library model error", zap.String("modelKey", key), zap.Error(err))
+			continue
+		}
+		value, ok := model.Attributes[FingerprintAttribute]
+		if !ok {
+			continue
+		}
+		fingerprints, err := parseFingerprints(value)
+		if err != nil {
+			driverbox.Log().Error("invalid modbus fingerprint", zap.String("modelKey", key), zap.Error(err))
+			continue
+		}
+		models = append(models, fingerprintModel{
+			modelKey:     key,
+			description:  model.Description,
+			fingerprints: fingerprints,
+		})
+	}
+	return models
+}
+
+// 每个扫描周期最多扫描的从机数量，避免整条总线扫描期间长时间占用会话，影响采集及写操作
+const scanUnitsPerTick = 8
+
+// initScanTask 注册设备扫描任务，未设置扫描周期时仅扫描一次
+// 每 10 秒扫描一批从机，一轮扫描完成后记录扫描时间，连接重启后不会立即重复扫描
+func (c *connector) initScanTask(conf *DiscoverConfig) error {
+	unitIDs, err := parseUnitIDs(conf.UnitIDs)
+	if err != nil {
+		return err
+	}
+	var duration time.Duration
+	if conf.Duration != "" {
+		if duration, err = time.ParseDuration(conf.Duration); err != nil {
+			return err
+		}
+	}
+	//本轮扫描的进度及新增的设备数量
+	var cursor, added int
+	c.scanTask, err = driverbox.AddFunc("10s", func() {
+		if c.close.Load() {
+			return
+		}
+		key := c.config.ConnectionKey
+		if cursor == 0 {
+			if latest, ok := c.plugin.scanTimes.Load(key); ok && (duration == 0 || time.Since(latest.(time.Time)) < duration) {
+				return
+			}
+		}
+		n, scanned := c.scan(unitIDs[cursor:min(cursor+scanUnitsPerTick, len(unitIDs))], conf.DeviceIDPrefix)
+		added += n
+		cursor += scanned
+		if cursor < len(unitIDs) {
+			return
+		}
+		driverbox.Log().Info("modbus scan finished", zap.String("key", key), zap.Int("added", added))
+		c.plugin.scanTimes.Store(key, time.Now())
+		cursor = 0
+		if added > 0 {
+			added = 0
+			c.plugin.reloadConnection(key)
+		}
+	})
+	return err
+}
+
+// scan 扫描从机地址并按设备指纹识别物模型，触发设备自动发现事件
+// 存在写操作时让出会话，剩余从机于下一周期扫描；返回新增的设备数量及已扫描的从机数量
+func (c *connector) scan(unitIDs []uint8, prefix string) (added int, scanned int) {
+	models := loadFingerprintModels()
+	if len(models) == 0 {
+		driverbox.Log().Warn("none library model has modbus fingerprint, ignore scan", zap.String("key", c.config.ConnectionKey))
+		return 0, len(unitIDs)
+	}
+	if prefix == "" {
+		prefix = c.config.ConnectionKey + "_"
+	}
+	driverbox.Log().Debug("modbus scan", zap.String("key", c.config.ConnectionKey), zap.Uint8s("unitIDs", unitIDs))
+	for _, unitID := range unitIDs {
+		if c.close.Load() || c.writeSemaphore.Load() > 0 {
+			return
+		}
+		scanned++
+		//已配置的从机不再扫描
+		if _, ok := c.devices[unitID]; ok {
+			continue
+		}
+		deviceId := prefix + strconv.Itoa(int(unitID))
+		if _, ok := driverbox.CoreCache().GetDevice(deviceId); ok {
+			continue
+		}
+		model, ok := c.identify(unitID, models)
+		if !ok {
+			continue
+		}
+		driverbox.Log().Info("modbus device discovered", zap.String("key", c.config.ConnectionKey), zap.Uint8("unitID", unitID), zap.String("modelKey", model.modelKey))
+		deviceData := []plugin.DeviceData{{
+			ID: deviceId,
+			Events: []event.Data{{
+				Code: event.DeviceDiscover,
+				Value: map[string]interface{}{
+					"modelKey": model.modelKey,
+					"device": map[string]interface{}{
+						"id":          deviceId,
+						"description": model.description,
+						"properties": map[string]string{
+							"unitID": strconv.Itoa(int(unitID)),
+						},
+					},
+				},
+			}},
+		}}
+		plugin.WrapperDiscoverEvent(deviceData, c.config.ConnectionKey, ProtocolName)
+		driverbox.Export(deviceData)
+		if _, ok = driverbox.CoreCache().GetDevice(deviceId); ok {
+			added++
+		}
+	}
+	return
+}
+
+// identify 读取从机的指纹寄存器并匹配物模型，从机无响应时跳过其余模型
+func (c *connector) identify(unitID uint8, models []fingerprintModel) (fingerprintModel, bool) {
+	//相同寄存器区间仅读取一次
+	results := make(map[string][]uint16)
+	for _, model := range models {
+		matched := true
+		for _, f := range model.fingerprints {
+			key := fmt.Sprintf("%s:%d:%d", f.RegisterType, f.address, f.quantity)
+			values, ok := results[key]
+			if !ok {
+				var err error
+				values, err = c.read(unitID, string(f.RegisterType), f.address, f.quantity)
+				if errors.Is(err, modbus.ErrRequestTimedOut) {
+					return fingerprintModel{}, false
+				}
+				results[key] = values
+			}
+			if !f.match(values) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return model, true
+		}
+	}
+	return fingerprintModel{}, false
+}
+
+// reloadConnection 重启连接，使扫描新增的设备加入采集
+func (p *Plugin) reloadConnection(key string) {
+	if err := p.ReloadConnections(driverbox.PluginConfig(ProtocolName), []string{key}); err != nil {
+		driverbox.Log().Error("reload modbus connection error", zap.String("key", key), zap.Error(err))
+	}
+}
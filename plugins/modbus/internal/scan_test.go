@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseUnitIDs(t *testing.T) {
+	ids, err := parseUnitIDs("3-5, 1,4")
+	if err != nil || !reflect.DeepEqual(ids, []uint8{3, 4, 5, 1}) {
+		t.Fatalf("parseUnitIDs = %v, %v", ids, err)
+	}
+	if ids, _ = parseUnitIDs(""); len(ids) != 247 {
+		t.Fatalf("default unitIDs = %d", len(ids))
+	}
+	for _, s := range []string{"0-3", "5-2", "1-300", "a"} {
+		if _, err = parseUnitIDs(s); err == nil {
+			t.Errorf("parseUnitIDs(%q) expected error", s)
+		}
+	}
+}
+
+func TestFingerprintMatch(t *testing.T) {
+	fingerprints, err := parseFingerprints([]interface{}{
+		map[string]interface{}{"primaryTable": "HOLDING_REGISTER", "startAddress": "0x0010", "values": []interface{}{0x1234, 2}},
+		map[string]interface{}{"primaryTable": "INPUT_REGISTER", "startAddress": 100, "text": "ACME"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := fingerprints[0]; f.address != 0x10 || f.quantity != 2 || !f.match([]uint16{0x1234, 2}) || f.match([]uint16{0x1234, 3}) {
+		t.Errorf("values fingerprint = %+v", f)
+	}
+	if f := fingerprints[1]; f.quantity != 2 || !f.match([]uint16{0x4143, 0x4d45}) || f.match([]uint16{0x4143}) {
+		t.Errorf("text fingerprint = %+v", f)
+	}
+	if _, err = parseFingerprints([]interface{}{map[string]interface{}{"primaryTable": "COIL", "startAddress": 1}}); err == nil {
+		t.Error("fingerprint without values expected error")
+	}
+}
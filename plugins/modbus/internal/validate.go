@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+)
+
+// registerRawTypes 寄存器点位支持的原始数据类型
+var registerRawTypes = []string{
+	ValueTypeUint16, ValueTypeInt16, ValueTypeUint32, ValueTypeInt32, ValueTypeFloat32,
+	ValueTypeUint64, ValueTypeInt64, ValueTypeFloat64, ValueTypeString,
+}
+
+// ValidatePoint 校验 modbus 点位扩展字段
+func (p *Plugin) ValidatePoint(point config.Point) config.ValidationErrors {
+	var errs config.ValidationErrors
+	add := func(path, format string, args ...interface{}) {
+		errs = append(errs, config.ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	table, ok := point["primaryTable"].(string)
+	switch {
+	case !ok:
+		add("primaryTable", "is required")
+	case primaryTable(table) != Coil && primaryTable(table) != DiscreteInput &&
+		primaryTable(table) != InputRegister && primaryTable(table) != HoldingRegister:
+		add("primaryTable", "invalid value %q, must be one of: %s, %s, %s, %s", table, Coil, DiscreteInput, InputRegister, HoldingRegister)
+	}
+
+	if address, ok := point["startAddress"]; !ok {
+		add("startAddress", "is required")
+	} else if _, err := castModbusAddress(address); err != nil {
+		add("startAddress", "invalid modbus address %v: %v", address, err)
+	}
+
+	//寄存器点位需指定原始数据类型
+	if primaryTable(table) == InputRegister || primaryTable(table) == HoldingRegister {
+		rawType, _ := point["rawType"].(string)
+		valid := false
+		for _, t := range registerRawTypes {
+			if strings.EqualFold(rawType, t) {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			add("rawType", "invalid value %q, must be one of: %s", rawType, strings.Join(registerRawTypes, ", "))
+		}
+	}
+	if primaryTable(table) == DiscreteInput || primaryTable(table) == InputRegister {
+		if point.ReadWrite() == config.ReadWrite_W || point.ReadWrite() == config.ReadWrite_RW {
+			add("readWrite", "%s is read only", table)
+		}
+	}
+
+	bit, bitOk := intField(point, "bit")
+	if _, ok := point["bit"]; ok && (!bitOk || bit < 0 || bit > 15) {
+		add("bit", "must be an integer between 0 and 15")
+	}
+	bitLen, bitLenOk := intField(point, "bitLen")
+	if _, ok := point["bitLen"]; ok && (!bitLenOk || bitLen < 0 || bitLen > 16) {
+		add("bitLen", "must be an integer between 0 and 16")
+	} else if bitOk && bitLenOk && bit+bitLen > 16 {
+		add("bitLen", "bit + bitLen must not exceed 16")
+	}
+	if v, ok := point["tolerance"]; ok {
+		if n, isNum := v.(float64); !isNum || n < 0 {
+			add("tolerance", "must be a non-negative number")
+		}
+	}
+	return errs
+}
+
+func intField(point config.Point, key string) (int, bool) {
+	n, ok := point[key].(float64)
+	if !ok || n != float64(int(n)) {
+		return 0, false
+	}
+	return int(n), true
+}
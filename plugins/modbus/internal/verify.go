@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/ibuilding-x/driver-box/v2/driverbox"
+	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+	"github.com/spf13/cast"
+	"go.uber.org/zap"
+)
+
+// writeCheck 点位的写入校验条件
+type writeCheck struct {
+	deviceId string
+	point    *Point
+	policy   config.WritePolicy
+	// 期望的寄存器值，与写入顺序一致
+	value []uint16
+	// 参与比较的位，位操作点位仅比较写入的位
+	mask uint16
+}
+
+// SupportWritePolicy 写操作中按点位 writePolicy 回读校验
+func (p *Plugin) SupportWritePolicy() bool {
+	return true
+}
+
+// newWriteCheck 根据点位的写入校验策略生成校验条件，未启用校验时返回 nil
+func newWriteCheck(deviceId string, point *Point, value []uint16) *writeCheck {
+	policy := point.WritePolicy()
+	if policy != config.WritePolicy_Verify && policy != config.WritePolicy_VerifyAndRetry {
+		return nil
+	}
+	check := &writeCheck{
+		deviceId: deviceId,
+		point:    point,
+		policy:   policy,
+		value:    append([]uint16{}, value...),
+		mask:     0xFFFF,
+	}
+	if point.RegisterType == HoldingRegister && point.BitLen > 0 {
+		check.mask = uint16((1<<point.BitLen)-1) << point.Bit
+		//写入时位操作在交换字节后进行，掩码同样需要交换
+		if point.ByteSwap {
+			check.mask = (check.mask << 8) | (check.mask >> 8)
+		}
+	}
+	return check
+}
+
+// match 比较回读的寄存器值，寄存器不一致时按 tolerance 比较解析后的数值
+func (w *writeCheck) match(actual []uint16) bool {
+	equal := true
+	for i, v := range w.value {
+		if (v^actual[i])&w.mask != 0 {
+			equal = false
+			break
+		}
+	}
+	if equal || w.point.Tolerance <= 0 || w.point.BitLen > 0 {
+		return equal
+	}
+	expect, err := decodePoint(w.point, w.value)
+	if err != nil {
+		return false
+	}
+	got, err := decodePoint(w.point, actual)
+	if err != nil {
+		return false
+	}
+	e, err1 := cast.ToFloat64E(expect)
+	g, err2 := cast.ToFloat64E(got)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return math.Abs(e-g) <= w.point.Tolerance
+}
+
+// verifyWrite 回读校验写入结果，存在 verifyAndRetry 策略的点位时不一致则重新写入，最多重写 retry 次
+func (c *connector) verifyWrite(pc *writeValue) error {
+	if len(pc.checks) == 0 {
+		return nil
+	}
+	retry := 0
+	for _, check := range pc.checks {
+		if check.policy == config.WritePolicy_VerifyAndRetry {
+			retry = c.config.Retry
+		}
+	}
+	for i := 0; ; i++ {
+		err := c.readBack(pc)
+		if err == nil || i >= retry || !errors.Is(err, plugin.WriteNotConfirmed) {
+			return err
+		}
+		driverbox.Log().Warn("modbus write not confirmed, rewrite", zap.String("key", c.config.ConnectionKey), zap.Any("value", pc), zap.Int("times", i+1), zap.Error(err))
+		if err = c.writeWithRetry(pc); err != nil {
+			return err
+		}
+	}
+}
+
+// readBack 读取写入的寄存器并逐个点位比较，校验通过的点位值同步至设备影子
+func (c *connector) readBack(pc *writeValue) error {
+	var values []uint16
+	var err error
+	quantity := uint16(len(pc.Value))
+	if c.virtual {
+		values, err = c.mockRead(pc.unitID, string(pc.RegisterType), pc.Address, quantity)
+	} else {
+		values, err = c.read(pc.unitID, string(pc.RegisterType), pc.Address, quantity)
+	}
+	if err != nil {
+		return fmt.Errorf("read back [%v] error: %v", pc, err)
+	}
+	if len(values) < int(quantity) {
+		return fmt.Errorf("read back [%v] error: expect %d registers, got %d", pc, quantity, len(values))
+	}
+	mismatches := make([]string, 0)
+	for _, check := range pc.checks {
+		offset := int(check.point.Address) - int(pc.Address)
+		actual := values[offset : offset+len(check.value)]
+		value, _ := decodePoint(check.point, actual)
+		if !check.match(actual) {
+			expect, _ := decodePoint(check.point, check.value)
+			mismatches = append(mismatches, fmt.Sprintf("%s expect %v, actual %v", check.point.Name(), expect, value))
+			continue
+		}
+		res, err := c.Decode(plugin.PointReadValue{
+			ID:        check.deviceId,
+			PointName: check.point.Name(),
+			Value:     value,
+		})
+		if err == nil {
+			driverbox.Export(res)
+		}
+	}
+	if len(mismatches) > 0 {
+		// 回读的寄存器值与写入值不一致，设备可能拒绝或忽略了写操作
+		return fmt.Errorf("%w: %s", plugin.WriteNotConfirmed, strings.Join(mismatches, "; "))
+	}
+	return nil
+}
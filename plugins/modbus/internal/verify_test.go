@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ibuilding-x/driver-box/v2/pkg/config"
+)
+
+func TestWriteCheck(t *testing.T) {
+	if newWriteCheck("d1", &Point{Point: config.Point{"name": "p1"}}, []uint16{1}) != nil {
+		t.Fatal("write check should be disabled by default")
+	}
+
+	//位操作点位仅比较写入的位，字节交换时掩码同样交换
+	bits := &Point{
+		Point:        config.Point{"name": "bits", "writePolicy": "verify"},
+		RegisterType: HoldingRegister,
+		RawType:      ValueTypeUint16,
+		Bit:          0,
+		BitLen:       4,
+		ByteSwap:     true,
+	}
+	check := newWriteCheck("d1", bits, []uint16{0x0500})
+	if check.mask != 0x0F00 {
+		t.Fatalf("mask = %#x", check.mask)
+	}
+	if !check.match([]uint16{0xF5FF}) || check.match([]uint16{0x0400}) {
+		t.Fatal("bit mask match failed")
+	}
+
+	//浮点数按解析后的数值比较误差，与字节序无关
+	float := &Point{
+		Point:        config.Point{"name": "float", "writePolicy": "verifyAndRetry"},
+		RegisterType: HoldingRegister,
+		RawType:      ValueTypeFloat32,
+		WordSwap:     true,
+		Tolerance:    0.01,
+	}
+	encode := func(v float32) []uint16 {
+		b := math.Float32bits(v)
+		//与写入编码一致：字交换后低位字在前
+		return []uint16{uint16(b >> 16), uint16(b)}
+	}
+	check = newWriteCheck("d1", float, encode(21.5))
+	if check.policy != config.WritePolicy_VerifyAndRetry {
+		t.Fatalf("policy = %s", check.policy)
+	}
+	if v, _ := decodePoint(float, check.value); v != float32(21.5) {
+		t.Fatalf("decode = %v", v)
+	}
+	if !check.match(encode(21.505)) || check.match(encode(21.6)) {
+		t.Fatal("float tolerance match failed")
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/ibuilding-x/driver-box/v2/driverbox"
 	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
 	"github.com/ibuilding-x/driver-box/v2/pkg/library"
+	"github.com/ibuilding-x/driver-box/v2/pkg/metrics"
 	"go.uber.org/zap"
 )
 
@@ -32,7 +34,11 @@ func (conn *connector) Send(data interface{}) error {
 		return err
 	}
 	for _, encodeData := range encodeDatas {
-		if token := conn.client.Publish(encodeData.Topic, 0, false, encodeData.Payload); token.Wait() && token.Error() != nil {
+		start := time.Now()
+		token := conn.client.Publish(encodeData.Topic, 0, false, encodeData.Payload)
+		token.Wait()
+		metrics.ObserveIO(ProtocolName, conn.config.ConnectionKey, string(plugin.WriteMode), start, token.Error())
+		if token.Error() != nil {
 			driverbox.Log().Error(fmt.Sprintf("publish %s to topic %s error: %s",
 				encodeData.Payload, encodeData.Topic, token.Error().Error()))
 			return token.Error()
@@ -37,7 +37,7 @@ func (p *Plugin) initConnPool(c config.DeviceConfig) error {
 		}
 		// 删除不支持自动发现，且未关联设备得连接
 		if !connectConfig.Discover && !config.HasDevice(k, c) {
-			err := driverbox.CoreCache().DeleteConnection(k)
+			err := driverbox.CoreCache().WithSource(ProtocolName).DeleteConnection(k)
 			if err != nil {
 				driverbox.Log().Error("delete connection error", zap.Any("connection", connectConfig), zap.Error(err))
 			} else {
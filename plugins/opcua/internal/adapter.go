@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/ibuilding-x/driver-box/v2/driverbox"
+	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
 	"github.com/gopcua/opcua"
 	"github.com/gopcua/opcua/ua"
 	"go.uber.org/zap"
@@ -85,18 +86,28 @@ func (oc *opcuaClient) connect() error {
 	return nil
 }
 
-func (oc *opcuaClient) ReadNodes(nodeIds []string) (map[string]interface{}, error) {
-	result := make(map[string]interface{})
+// nodeValue 节点读取结果
+type nodeValue struct {
+	value   interface{}
+	quality plugin.Quality
+	// timestamp 数据源时间，Unix 毫秒
+	timestamp int64
+}
+
+func (oc *opcuaClient) ReadNodes(nodeIds []string) (map[string]nodeValue, error) {
+	result := make(map[string]nodeValue)
 	if len(nodeIds) == 0 {
 		return result, nil
 	}
 	nodesToRead := make([]*ua.ReadValueID, 0, len(nodeIds))
+	validIds := make([]string, 0, len(nodeIds))
 	for _, idStr := range nodeIds {
 		id, err := ua.ParseNodeID(idStr)
 		if err != nil {
 			driverbox.Log().Warn("invalid nodeId", zap.String("nodeId", idStr), zap.Error(err))
 			continue
 		}
+		validIds = append(validIds, idStr)
 		nodesToRead = append(nodesToRead, &ua.ReadValueID{
 			NodeID:      id,
 			AttributeID: ua.AttributeIDValue,
@@ -118,15 +129,50 @@ func (oc *opcuaClient) ReadNodes(nodeIds []string) (map[string]interface{}, erro
 		return nil, fmt.Errorf("read service error: %s", resp.ResponseHeader.ServiceResult)
 	}
 	for i, res := range resp.Results {
+		if i >= len(validIds) {
+			break
+		}
+		nv := nodeValue{quality: statusQuality(res.Status)}
 		if res.Status != ua.StatusOK {
-			driverbox.Log().Warn("read node error", zap.String("nodeId", nodeIds[i]), zap.Any("status", res.Status))
-			continue
+			driverbox.Log().Warn("read node error", zap.String("nodeId", validIds[i]), zap.Any("status", res.Status))
+		}
+		// 异常状态的值不可信，仅上报数据质量
+		if res.Value != nil && nv.quality != plugin.QualityBad && nv.quality != plugin.QualityCommFailure {
+			nv.value = res.Value.Value()
 		}
-		result[nodeIds[i]] = res.Value.Value()
+		if !res.SourceTimestamp.IsZero() {
+			nv.timestamp = res.SourceTimestamp.UnixMilli()
+		}
+		result[validIds[i]] = nv
 	}
 	return result, nil
 }
 
+// statusQuality 将 OPC UA 状态码转换为点位数据质量
+func statusQuality(status ua.StatusCode) plugin.Quality {
+	switch status {
+	case ua.StatusOK:
+		return plugin.QualityGood
+	case ua.StatusBadCommunicationError, ua.StatusBadNoCommunication, ua.StatusBadNotConnected:
+		return plugin.QualityCommFailure
+	case ua.StatusBadOutOfRange:
+		return plugin.QualityOutOfRange
+	case ua.StatusUncertainSubstituteValue:
+		return plugin.QualitySubstituted
+	case ua.StatusUncertainLastUsableValue:
+		return plugin.QualityStale
+	}
+	// 状态码高两位为严重程度：00 正常，01 不确定，10 异常
+	switch uint32(status) >> 30 {
+	case 0:
+		return plugin.QualityGood
+	case 1:
+		return plugin.QualityUncertain
+	default:
+		return plugin.QualityBad
+	}
+}
+
 func (oc *opcuaClient) WriteNode(nodeId string, value interface{}) error {
 	id, err := ua.ParseNodeID(nodeId)
 	if err != nil {
@@ -9,6 +9,7 @@ import (
 	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
 	"github.com/ibuilding-x/driver-box/v2/pkg/config"
 	"github.com/ibuilding-x/driver-box/v2/pkg/convutil"
+	"github.com/ibuilding-x/driver-box/v2/pkg/metrics"
 	"go.uber.org/zap"
 )
 
@@ -65,7 +66,9 @@ func (c *connector) collectData() {
 		driverbox.Log().Error("opcua client is nil")
 		return
 	}
+	start := time.Now()
 	values, err := c.client.ReadNodes(c.getNodeIds())
+	metrics.ObserveIO(ProtocolName, c.config.ConnectionKey, string(plugin.ReadMode), start, err)
 	if err != nil {
 		driverbox.Log().Error("read opcua nodes error", zap.Error(err))
 		return
@@ -84,10 +87,11 @@ func (c *connector) getNodeIds() []string {
 	return nodeIds
 }
 
-func (c *connector) processReadValues(values map[string]interface{}) plugin.DeviceData {
+func (c *connector) processReadValues(values map[string]nodeValue) plugin.DeviceData {
 	pointData := make([]plugin.PointData, 0, len(values))
 	for pointName, node := range c.nodes {
-		if value, ok := values[node.NodeId]; ok {
+		if nv, ok := values[node.NodeId]; ok {
+			value := nv.value
 			if node.Scale != 0 && node.Scale != 1 {
 				switch v := value.(type) {
 				case float64:
@@ -101,6 +105,8 @@ func (c *connector) processReadValues(values map[string]interface{}) plugin.Devi
 			pointData = append(pointData, plugin.PointData{
 				PointName: pointName,
 				Value:     value,
+				Quality:   nv.quality,
+				Timestamp: nv.timestamp,
 			})
 		}
 	}
@@ -151,11 +157,16 @@ func (c *connector) Send(data interface{}) (err error) {
 	}
 	switch req := data.(type) {
 	case *ReadRequest:
+		start := time.Now()
 		_, err := c.client.ReadNodes(req.Nodes)
+		metrics.ObserveIO(ProtocolName, c.config.ConnectionKey, string(plugin.ReadMode), start, err)
 		return err
 	case []*WriteRequest:
 		for _, wr := range req {
-			if err := c.client.WriteNode(wr.NodeId, wr.Value); err != nil {
+			start := time.Now()
+			err := c.client.WriteNode(wr.NodeId, wr.Value)
+			metrics.ObserveIO(ProtocolName, c.config.ConnectionKey, string(plugin.WriteMode), start, err)
+			if err != nil {
 				driverbox.Log().Error("write node error", zap.String("nodeId", wr.NodeId), zap.Error(err))
 			}
 		}
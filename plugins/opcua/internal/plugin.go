@@ -50,7 +50,7 @@ func (p *Plugin) initConnections(config config.DeviceConfig) {
 		}
 		for _, model := range config.DeviceModels {
 			if len(model.Devices) == 0 {
-				e := driverbox.CoreCache().DeleteModel(model.Name)
+				e := driverbox.CoreCache().WithSource(ProtocolName).DeleteModel(model.Name)
 				if e != nil {
 					driverbox.Log().Error("delete model error", zap.Any("model", model), zap.Error(e))
 				} else {
@@ -66,7 +66,7 @@ func (p *Plugin) initConnections(config config.DeviceConfig) {
 			}
 		}
 		if len(conn.nodes) == 0 {
-			err = driverbox.CoreCache().DeleteConnection(conn.config.ConnectionKey)
+			err = driverbox.CoreCache().WithSource(ProtocolName).DeleteConnection(conn.config.ConnectionKey)
 			if err != nil {
 				driverbox.Log().Error("delete connection error", zap.Any("connection", connConfig), zap.Error(err))
 			} else {
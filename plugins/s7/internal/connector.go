@@ -9,6 +9,7 @@ import (
 	"github.com/ibuilding-x/driver-box/v2/driverbox/plugin"
 	"github.com/ibuilding-x/driver-box/v2/pkg/config"
 	"github.com/ibuilding-x/driver-box/v2/pkg/convutil"
+	"github.com/ibuilding-x/driver-box/v2/pkg/metrics"
 	"go.uber.org/zap"
 )
 
@@ -65,7 +66,9 @@ func (c *connector) collectData() {
 		driverbox.Log().Error("s7 client is nil")
 		return
 	}
+	start := time.Now()
 	values, err := c.client.ReadNodes(c.getNodes())
+	metrics.ObserveIO(ProtocolName, c.config.ConnectionKey, string(plugin.ReadMode), start, err)
 	if err != nil {
 		driverbox.Log().Error("read s7 nodes error", zap.Error(err))
 		return
@@ -151,11 +154,16 @@ func (c *connector) Send(data interface{}) (err error) {
 	}
 	switch req := data.(type) {
 	case *ReadRequest:
+		start := time.Now()
 		_, err := c.client.ReadNodes(req.Nodes)
+		metrics.ObserveIO(ProtocolName, c.config.ConnectionKey, string(plugin.ReadMode), start, err)
 		return err
 	case []*WriteRequest:
 		for _, wr := range req {
-			if err := c.client.WriteNode(wr.Node, wr.Value); err != nil {
+			start := time.Now()
+			err := c.client.WriteNode(wr.Node, wr.Value)
+			metrics.ObserveIO(ProtocolName, c.config.ConnectionKey, string(plugin.WriteMode), start, err)
+			if err != nil {
 				driverbox.Log().Error("write node error", zap.String("point", wr.Node.PointName), zap.Error(err))
 			}
 		}